@@ -7,9 +7,11 @@ import (
 	"github.com/arduino/arduino-cli/cli/globals"
 	"github.com/arduino/arduino-cli/cli/instance"
 	"github.com/arduino/arduino-cli/commands"
+	"github.com/arduino/arduino-cli/commands/board"
 	"github.com/arduino/arduino-cli/commands/compile"
 	"github.com/arduino/arduino-cli/commands/core"
 	"github.com/arduino/arduino-cli/commands/lib"
+	"github.com/arduino/arduino-cli/commands/upload"
 	"github.com/arduino/arduino-cli/configuration"
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 )
@@ -30,6 +32,10 @@ type Cli interface {
 	LibraryUninstall(context.Context, *rpc.LibraryUninstallRequest, rpc.TaskProgressCB) error
 	LibraryList(context.Context, *rpc.LibraryListRequest) (*rpc.LibraryListResponse, error)
 	Compile(context.Context, *rpc.CompileRequest, io.Writer, io.Writer, rpc.TaskProgressCB, bool) (*rpc.CompileResponse, error)
+	ConnectedBoards(*rpc.BoardListRequest) ([]*rpc.DetectedPort, error)
+	BoardDetails(context.Context, *rpc.BoardDetailsRequest) (*rpc.BoardDetailsResponse, error)
+	BoardSearch(context.Context, *rpc.BoardSearchRequest) (*rpc.BoardSearchResponse, error)
+	Upload(context.Context, *rpc.UploadRequest, io.Writer, io.Writer) (*rpc.UploadResponse, error)
 	Version() string
 }
 
@@ -106,6 +112,27 @@ func (c *ArduinoCli) Compile(ctx context.Context, req *rpc.CompileRequest, out i
 	return compile.Compile(ctx, req, out, err, cb, verbose)
 }
 
+// ConnectedBoards wrapper around arduino-cli board.List
+func (c *ArduinoCli) ConnectedBoards(req *rpc.BoardListRequest) ([]*rpc.DetectedPort, error) {
+	ports, _, err := board.List(req)
+	return ports, err
+}
+
+// BoardDetails wrapper around arduino-cli board.Details
+func (c *ArduinoCli) BoardDetails(ctx context.Context, req *rpc.BoardDetailsRequest) (*rpc.BoardDetailsResponse, error) {
+	return board.Details(ctx, req)
+}
+
+// BoardSearch wrapper around arduino-cli board.Search
+func (c *ArduinoCli) BoardSearch(ctx context.Context, req *rpc.BoardSearchRequest) (*rpc.BoardSearchResponse, error) {
+	return board.Search(ctx, req)
+}
+
+// Upload wrapper around arduino-cli Upload
+func (c *ArduinoCli) Upload(ctx context.Context, req *rpc.UploadRequest, out io.Writer, err io.Writer) (*rpc.UploadResponse, error) {
+	return upload.Upload(ctx, req, out, err)
+}
+
 // Version wrapper around arduino-cli global version
 func (c *ArduinoCli) Version() string {
 	return globals.VersionInfo.String()
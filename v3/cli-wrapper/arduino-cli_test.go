@@ -11,6 +11,7 @@ import (
 	"github.com/arduino/arduino-cli/configuration"
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	"github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/paths"
 	"github.com/robgonnella/ardi/v3/testutil"
 	"github.com/robgonnella/ardi/v3/types"
 	"github.com/robgonnella/ardi/v3/util"
@@ -22,7 +23,8 @@ func TestArduinoCli(t *testing.T) {
 	dataDir := path.Join(here, ".ardi")
 
 	ctx := context.Background()
-	settingsPath := util.GetCliSettingsPath()
+	projectPaths := paths.NewProjectPaths(".")
+	settingsPath := projectPaths.ArduinoCliConfig
 
 	tearDown := func() {
 		os.RemoveAll(".ardi")
@@ -30,10 +32,10 @@ func TestArduinoCli(t *testing.T) {
 	}
 
 	writeSettings := func() *types.ArduinoCliSettings {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(projectPaths)
 		config := util.GenArdiConfig()
 		settings := util.GenArduinoCliSettings(dataDir)
-		util.WriteAllSettings(config, settings)
+		util.WriteAllSettings(projectPaths, config, settings)
 		return settings
 	}
 
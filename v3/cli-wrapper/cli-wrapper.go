@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -56,6 +57,11 @@ func WithArduinoCli(arduinoCli Cli) WrapperOption {
 	}
 }
 
+// Context returns the context arduino-cli requests are made with
+func (w *Wrapper) Context() context.Context {
+	return w.ctx
+}
+
 // UpdateIndexFiles updates platform and library index files
 func (w *Wrapper) UpdateIndexFiles() error {
 	if err := w.UpdatePlatformIndex(); err != nil {
@@ -226,6 +232,69 @@ func (w *Wrapper) AllBoards() []*BoardWithPort {
 	return boardList
 }
 
+// ConnectedBoards returns a list of connected arduino boards
+func (w *Wrapper) ConnectedBoards() []*BoardWithPort {
+	inst := w.getRPCInstance()
+
+	w.logger.Debug("Getting list of connected boards...")
+
+	boardList := []*BoardWithPort{}
+
+	req := &rpc.BoardListRequest{
+		Instance: inst,
+	}
+
+	ports, err := w.cli.ConnectedBoards(req)
+	if err != nil {
+		w.logger.WithError(err).Warn("failed to get list of connected boards")
+		return boardList
+	}
+
+	for _, port := range ports {
+		for _, board := range port.GetMatchingBoards() {
+			boardWithPort := BoardWithPort{
+				FQBN: board.GetFqbn(),
+				Name: board.GetName(),
+				Port: port.GetPort().GetAddress(),
+			}
+			boardList = append(boardList, &boardWithPort)
+		}
+	}
+
+	return boardList
+}
+
+// SearchBoards searches boards of all indexed platforms
+func (w *Wrapper) SearchBoards(query string) ([]*rpc.BoardListItem, error) {
+	inst := w.getRPCInstance()
+
+	req := &rpc.BoardSearchRequest{
+		Instance:   inst,
+		SearchArgs: query,
+	}
+
+	resp, err := w.cli.BoardSearch(w.ctx, req)
+
+	return resp.GetBoards(), err
+}
+
+// BoardOptions returns the menu options available for a board
+func (w *Wrapper) BoardOptions(fqbn string) ([]*rpc.ConfigOption, error) {
+	inst := w.getRPCInstance()
+
+	req := &rpc.BoardDetailsRequest{
+		Instance: inst,
+		Fqbn:     fqbn,
+	}
+
+	details, err := w.cli.BoardDetails(w.ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return details.GetConfigOptions(), nil
+}
+
 // SearchLibraries searches available libraries for download
 func (w *Wrapper) SearchLibraries(query string) ([]*rpc.SearchedLibrary, error) {
 	inst := w.getRPCInstance()
@@ -306,6 +375,20 @@ func (w *Wrapper) GetInstalledLibs() ([]*rpc.InstalledLibrary, error) {
 	return res.GetInstalledLibraries(), err
 }
 
+// GetUpdatableLibs returns the installed libraries with a newer release in
+// the library index, the release is only set for these libraries
+func (w *Wrapper) GetUpdatableLibs() ([]*rpc.InstalledLibrary, error) {
+	inst := w.getRPCInstance()
+
+	req := &rpc.LibraryListRequest{
+		Instance:  inst,
+		Updatable: true,
+	}
+
+	res, err := w.cli.LibraryList(w.ctx, req)
+	return res.GetInstalledLibraries(), err
+}
+
 // CompileOpts represents the options passed to the compile command
 type CompileOpts struct {
 	FQBN       string
@@ -313,6 +396,23 @@ type CompileOpts struct {
 	SketchPath string
 	BuildProps []string
 	ShowProps  bool
+	BuildName  string
+	ProjectDir string
+	PreBuild   []string
+	PostBuild  []string
+	// BoardOptions board options set in FQBN that must be validated
+	// against the board's available options before compiling
+	BoardOptions map[string]string
+	// Secrets values redacted from compile and hook output
+	Secrets []string
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// ExportDir returns the directory compiled artifacts are exported to for a
+// sketch directory
+func ExportDir(sketchDir string) string {
+	return path.Join(sketchDir, "build")
 }
 
 // Compile the specified sketch
@@ -329,7 +429,7 @@ func (w *Wrapper) Compile(opts CompileOpts) error {
 		return errors.New("could not resolve sketch directory")
 	}
 
-	exportDir := path.Join(resolvedSketchDir, "build")
+	exportDir := ExportDir(resolvedSketchDir)
 
 	req := &rpc.CompileRequest{
 		Instance:        inst,
@@ -341,11 +441,21 @@ func (w *Wrapper) Compile(opts CompileOpts) error {
 		Verbose:         w.isVerbose(),
 	}
 
+	var stdout io.Writer = os.Stdout
+	if opts.Stdout != nil {
+		stdout = opts.Stdout
+	}
+
+	var stderr io.Writer = os.Stderr
+	if opts.Stderr != nil {
+		stderr = opts.Stderr
+	}
+
 	_, err = w.cli.Compile(
 		w.ctx,
 		req,
-		os.Stdout,
-		os.Stderr,
+		stdout,
+		stderr,
 		w.getTaskProgressFn(),
 		w.isVerbose(),
 	)
@@ -353,6 +463,56 @@ func (w *Wrapper) Compile(opts CompileOpts) error {
 	return err
 }
 
+// UploadOpts represents the options passed to the upload command
+type UploadOpts struct {
+	FQBN       string
+	SketchDir  string
+	SketchPath string
+	Port       string
+	Stdout     io.Writer
+	Stderr     io.Writer
+}
+
+// Upload a previously compiled sketch to target board
+func (w *Wrapper) Upload(opts UploadOpts) error {
+	inst := w.getRPCInstance()
+
+	resolvedSketchPath, err := filepath.Abs(opts.SketchPath)
+	if err != nil {
+		return errors.New("could not resolve sketch path")
+	}
+
+	resolvedSketchDir, err := filepath.Abs(opts.SketchDir)
+	if err != nil {
+		return errors.New("could not resolve sketch directory")
+	}
+
+	req := &rpc.UploadRequest{
+		Instance:   inst,
+		Fqbn:       opts.FQBN,
+		SketchPath: resolvedSketchPath,
+		ImportDir:  ExportDir(resolvedSketchDir),
+		Port: &rpc.Port{
+			Address: opts.Port,
+		},
+		Verbose: w.isVerbose(),
+	}
+
+	var stdout io.Writer = os.Stdout
+	if opts.Stdout != nil {
+		stdout = opts.Stdout
+	}
+
+	var stderr io.Writer = os.Stderr
+	if opts.Stderr != nil {
+		stderr = opts.Stderr
+	}
+
+	_, err = w.cli.Upload(w.ctx, req, stdout, stderr)
+
+	return err
+}
+
 // ClientVersion returns version of arduino-cli
 func (w *Wrapper) ClientVersion() string {
 	return w.cli.Version()
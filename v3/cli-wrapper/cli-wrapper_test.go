@@ -241,6 +241,27 @@ func TestCliWrapperTest(t *testing.T) {
 		assert.Equal(st, expectBoards, boards)
 	})
 
+	runCliTest("searches boards", t, func(env cliTestEnv, st *testing.T) {
+		inst := &rpc.Instance{Id: int32(1)}
+		req := &rpc.BoardSearchRequest{
+			Instance:   inst,
+			SearchArgs: "esp32",
+		}
+
+		resp := &rpc.BoardSearchResponse{
+			Boards: []*rpc.BoardListItem{
+				{Name: "ESP32 Dev Module", Fqbn: "esp32:esp32:esp32"},
+			},
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(inst).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardSearch(gomock.Any(), req).Return(resp, nil)
+
+		boards, err := env.CliWrapper.SearchBoards("esp32")
+		assert.NoError(st, err)
+		assert.Equal(st, resp.Boards, boards)
+	})
+
 	runCliTest("searches libraries", t, func(env cliTestEnv, st *testing.T) {
 		inst := &rpc.Instance{Id: int32(1)}
 		query := "some query"
@@ -339,6 +360,29 @@ func TestCliWrapperTest(t *testing.T) {
 		assert.Equal(st, resp.InstalledLibraries, libs)
 	})
 
+	runCliTest("gets updatable libraries", t, func(env cliTestEnv, st *testing.T) {
+		inst := &rpc.Instance{Id: int32(1)}
+		req := &rpc.LibraryListRequest{
+			Instance:  inst,
+			Updatable: true,
+		}
+		resp := &rpc.LibraryListResponse{
+			InstalledLibraries: []*rpc.InstalledLibrary{
+				{
+					Library: &rpc.Library{Name: "somelib", Version: "1.2.3"},
+					Release: &rpc.LibraryRelease{Version: "1.3.0"},
+				},
+			},
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(inst).AnyTimes()
+		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), req).Return(resp, nil)
+
+		libs, err := env.CliWrapper.GetUpdatableLibs()
+		assert.NoError(st, err)
+		assert.Equal(st, resp.InstalledLibraries, libs)
+	})
+
 	runCliTest("compiles sketches", t, func(env cliTestEnv, st *testing.T) {
 		inst := &rpc.Instance{Id: int32(1)}
 		sketchDir := "."
@@ -401,6 +445,60 @@ func TestCliWrapperTest(t *testing.T) {
 		assert.NoError(st, err)
 	})
 
+	runCliTest("returns connected boards", t, func(env cliTestEnv, st *testing.T) {
+		inst := &rpc.Instance{Id: int32(1)}
+		req := &rpc.BoardListRequest{Instance: inst}
+
+		ports := []*rpc.DetectedPort{
+			{
+				Port: &rpc.Port{Address: "/dev/ttyUSB0"},
+				MatchingBoards: []*rpc.BoardListItem{
+					{Name: "Arduino Mega", Fqbn: "arduino:avr:mega"},
+				},
+			},
+		}
+
+		expected := []*cli.BoardWithPort{
+			{FQBN: "arduino:avr:mega", Name: "Arduino Mega", Port: "/dev/ttyUSB0"},
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(inst).AnyTimes()
+		env.ArduinoCli.EXPECT().ConnectedBoards(req).Return(ports, nil)
+
+		boards := env.CliWrapper.ConnectedBoards()
+		assert.Equal(st, expected, boards)
+	})
+
+	runCliTest("uploads sketches", t, func(env cliTestEnv, st *testing.T) {
+		inst := &rpc.Instance{Id: int32(1)}
+		sketchDir := "."
+		resolvedSketchDir, _ := filepath.Abs(sketchDir)
+		resolvedSketchPath := path.Join(resolvedSketchDir, "some_sketch.ino")
+		resolvedBuildDir := path.Join(resolvedSketchDir, "build")
+
+		opts := cli.UploadOpts{
+			FQBN:       "some:fqbn",
+			SketchDir:  sketchDir,
+			SketchPath: "./some_sketch.ino",
+			Port:       "/dev/ttyUSB0",
+		}
+
+		req := &rpc.UploadRequest{
+			Instance:   inst,
+			Fqbn:       opts.FQBN,
+			SketchPath: resolvedSketchPath,
+			ImportDir:  resolvedBuildDir,
+			Port:       &rpc.Port{Address: opts.Port},
+			Verbose:    false,
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(inst).AnyTimes()
+		env.ArduinoCli.EXPECT().Upload(gomock.Any(), req, gomock.Any(), gomock.Any())
+
+		err := env.CliWrapper.Upload(opts)
+		assert.NoError(st, err)
+	})
+
 	runCliTest("returns client version", t, func(env cliTestEnv, st *testing.T) {
 		inst := &rpc.Instance{Id: int32(1)}
 		version := "1.8.7"
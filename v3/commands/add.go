@@ -1,6 +1,10 @@
 package commands
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/util"
 	"github.com/spf13/cobra"
 )
 
@@ -12,7 +16,7 @@ func newAddPlatformCmd(env *CommandEnv) *cobra.Command {
 		Aliases: []string{"platform"},
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 			for _, p := range args {
@@ -33,31 +37,81 @@ func newAddPlatformCmd(env *CommandEnv) *cobra.Command {
 	return addCmd
 }
 
+// addBuildOpts represents the values used to add a build
+type addBuildOpts struct {
+	name         string
+	fqbn         string
+	sketch       string
+	baud         int
+	buildProps   []string
+	boardOptions []string
+}
+
+// missingFlags returns the required flags not set in opts
+func (o addBuildOpts) missingFlags() []string {
+	missing := []string{}
+	if o.fqbn == "" {
+		missing = append(missing, `"fqbn"`)
+	}
+	if o.name == "" {
+		missing = append(missing, `"name"`)
+	}
+	if o.sketch == "" {
+		missing = append(missing, `"sketch"`)
+	}
+	return missing
+}
+
 func newAddBuildCmd(env *CommandEnv) *cobra.Command {
-	var name string
-	var fqbn string
-	var sketch string
-	var baud int
-	var buildProps []string
+	var opts addBuildOpts
+	var interactive bool
 	addCmd := &cobra.Command{
-		Use:   "build",
-		Long:  "\nAdd build config to project",
+		Use: "build",
+		Long: "\nAdd build config to project. When run in a terminal without " +
+			"flags, or with --interactive, ardi prompts for any build settings " +
+			"not specified, offering the sketches found in the project, the " +
+			"boards of installed platforms, and the board's options.",
 		Short: "Add build config to project",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+
+			noFlags := len(opts.missingFlags()) == 3
+			if interactive || (noFlags && isTerminal(cmd.InOrStdin())) {
+				p := newPrompter(cmd.InOrStdin(), cmd.OutOrStdout())
+				if err := promptBuild(p, env, &opts); err != nil {
+					return err
+				}
+			}
+
+			if missing := opts.missingFlags(); len(missing) > 0 {
+				return fmt.Errorf("required flag(s) %s not set", strings.Join(missing, ", "))
+			}
+
+			boardOptions, err := parseKeyValues("board option", opts.boardOptions)
+			if err != nil {
 				return err
 			}
-			return env.ArdiCore.Config.AddBuild(name, sketch, fqbn, baud, buildProps)
+
+			// fqbns with variable references are validated once resolved
+			// at compile time
+			if fqbn := util.FQBNWithOptions(opts.fqbn, boardOptions); !strings.Contains(fqbn, "${") {
+				if err := env.ArdiCore.Board.ValidateOptions(fqbn); err != nil {
+					return err
+				}
+			}
+
+			return env.ArdiCore.Config.AddBuild(opts.name, opts.sketch, opts.fqbn, opts.baud, opts.buildProps, boardOptions)
 		},
 	}
-	addCmd.Flags().StringVarP(&name, "name", "n", "", "Custom name for the build")
-	addCmd.Flags().StringVarP(&fqbn, "fqbn", "f", "", "Specify fully qualified board name")
-	addCmd.Flags().StringVarP(&sketch, "sketch", "s", "", "Path to .ino file or sketch directory")
-	addCmd.Flags().IntVarP(&baud, "baud", "b", 0, "Specify baud rate for build")
-	addCmd.Flags().StringArrayVarP(&buildProps, "build-prop", "p", []string{}, "Specify build property to compiler")
-	addCmd.MarkFlagRequired("name")
-	addCmd.MarkFlagRequired("fqbn")
-	addCmd.MarkFlagRequired("sketch")
+	addCmd.Flags().StringVarP(&opts.name, "name", "n", "", "Custom name for the build")
+	addCmd.Flags().StringVarP(&opts.fqbn, "fqbn", "f", "", "Specify fully qualified board name")
+	addCmd.Flags().StringVarP(&opts.sketch, "sketch", "s", "", "Path to .ino file or sketch directory")
+	addCmd.Flags().IntVarP(&opts.baud, "baud", "b", 0, "Specify baud rate for build")
+	addCmd.Flags().StringArrayVarP(&opts.buildProps, "build-prop", "p", []string{}, "Specify build property to compiler")
+	addCmd.Flags().StringArrayVar(&opts.boardOptions, "board-option", []string{}, "Specify board option as option=value")
+	addCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Prompt for build settings not specified by flags")
 
 	return addCmd
 }
@@ -70,7 +124,7 @@ func newAddLibCmd(env *CommandEnv) *cobra.Command {
 		Aliases: []string{"libs", "lib", "library"},
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 			for _, l := range args {
@@ -100,7 +154,7 @@ func newAddBoardURLCmd(env *CommandEnv) *cobra.Command {
 		Aliases: []string{"board-urls"},
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 			for _, u := range args {
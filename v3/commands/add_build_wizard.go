@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+// promptBuild prompts for the build settings not already set in opts
+func promptBuild(p *prompter, env *CommandEnv, opts *addBuildOpts) error {
+	if opts.sketch == "" {
+		sketch, err := promptSketch(p, env.ArdiCore.Paths.Root)
+		if err != nil {
+			return err
+		}
+		opts.sketch = sketch
+	}
+
+	project, err := util.ProcessSketch(opts.sketch)
+	if err != nil {
+		return err
+	}
+
+	if opts.fqbn == "" {
+		fqbn, err := promptBoard(p, env)
+		if err != nil {
+			return err
+		}
+		opts.fqbn = fqbn
+	}
+
+	if len(opts.boardOptions) == 0 {
+		boardOptions, err := promptBoardOptions(p, env, opts.fqbn)
+		if err != nil {
+			return err
+		}
+		opts.boardOptions = boardOptions
+	}
+
+	if opts.baud == 0 {
+		if opts.baud, err = p.askInt("Baud", project.Baud); err != nil {
+			return err
+		}
+	}
+
+	if opts.name == "" {
+		def := strings.TrimSuffix(filepath.Base(project.Sketch), ".ino")
+		if opts.name, err = p.ask("Build name", def); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.buildProps) == 0 {
+		buildProps, err := promptBuildProps(p)
+		if err != nil {
+			return err
+		}
+		opts.buildProps = buildProps
+	}
+
+	return nil
+}
+
+// promptSketch prompts to choose one of the sketches found in the project
+// returning its path relative to the working directory
+func promptSketch(p *prompter, root string) (string, error) {
+	sketches, err := util.FindSketches(root)
+	if err != nil {
+		return "", err
+	}
+	if len(sketches) == 0 {
+		return "", fmt.Errorf("no sketches found in %s", root)
+	}
+
+	items := []string{}
+	for _, sketch := range sketches {
+		rel, err := filepath.Rel(root, sketch)
+		if err != nil {
+			rel = sketch
+		}
+		items = append(items, rel)
+	}
+
+	idx, err := p.choose("Sketch", items, 0)
+	if err != nil {
+		return "", err
+	}
+
+	return cwdRelative(sketches[idx]), nil
+}
+
+// cwdRelative returns p relative to the current directory as if typed by the
+// user, falling back to p if it cannot be made relative
+func cwdRelative(p string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return p
+	}
+	rel, err := filepath.Rel(cwd, p)
+	if err != nil {
+		return p
+	}
+	return rel
+}
+
+// promptBoard prompts to search for one of the boards of installed platforms
+func promptBoard(p *prompter, env *CommandEnv) (string, error) {
+	boards := env.ArdiCore.Board.List()
+	if len(boards) == 0 {
+		return "", errors.New("no boards found in installed platforms, add a platform with 'ardi add platform' first")
+	}
+
+	items := []string{}
+	for _, b := range boards {
+		items = append(items, fmt.Sprintf("%s (%s)", b.Name, b.FQBN))
+	}
+
+	idx, err := p.search("Board", items)
+	if err != nil {
+		return "", err
+	}
+
+	return boards[idx].FQBN, nil
+}
+
+// promptBoardOptions prompts for a value of each of the board's options
+// returning the options changed from their defaults as option=value
+func promptBoardOptions(p *prompter, env *CommandEnv, fqbn string) ([]string, error) {
+	options, err := env.ArdiCore.Board.Options(fqbn)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []string{}
+	for _, option := range options {
+		if len(option.Values) == 0 {
+			continue
+		}
+
+		items := []string{}
+		def := 0
+		for i, v := range option.Values {
+			items = append(items, fmt.Sprintf("%s (%s)", v.Label, v.Value))
+			if v.Default {
+				def = i
+			}
+		}
+
+		label := option.Label
+		if label == "" {
+			label = option.Option
+		}
+
+		idx, err := p.choose(label, items, def)
+		if err != nil {
+			return nil, err
+		}
+
+		if !option.Values[idx].Default {
+			selected = append(selected, option.Option+"="+option.Values[idx].Value)
+		}
+	}
+
+	return selected, nil
+}
+
+// promptBuildProps prompts for build props until a blank answer is given
+func promptBuildProps(p *prompter) ([]string, error) {
+	props := []string{}
+	for {
+		prop, err := p.ask("Build prop as key=value (blank to finish)", "")
+		if err != nil {
+			return nil, err
+		}
+		if prop == "" {
+			return props, nil
+		}
+		if !strings.Contains(prop, "=") {
+			fmt.Fprintf(p.out, "%s is not in key=value format\n", prop)
+			continue
+		}
+		props = append(props, prop)
+	}
+}
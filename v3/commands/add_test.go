@@ -2,12 +2,16 @@ package commands_test
 
 import (
 	"errors"
+	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	"github.com/golang/mock/gomock"
 	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -175,6 +179,137 @@ func TestAddBuildCommand(t *testing.T) {
 	})
 }
 
+func TestAddBuildWizard(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+	fqbn := "arduino:avr:mega"
+
+	platforms := []*rpc.Platform{
+		{
+			Id: "arduino:avr",
+			Boards: []*rpc.Board{
+				{Name: "Arduino Uno", Fqbn: "arduino:avr:uno"},
+				{Name: "Arduino Mega", Fqbn: fqbn},
+			},
+		},
+	}
+
+	detailsResp := &rpc.BoardDetailsResponse{
+		ConfigOptions: []*rpc.ConfigOption{
+			{
+				Option:      "cpu",
+				OptionLabel: "Processor",
+				Values: []*rpc.ConfigValue{
+					{Value: "atmega2560", ValueLabel: "ATmega2560", Selected: true},
+					{Value: "atmega1280", ValueLabel: "ATmega1280"},
+				},
+			},
+		},
+	}
+
+	// setup initializes a project containing two sketches and returns the
+	// project directory and the blink sketch path relative to the working
+	// directory
+	setup := func(env *testutil.MockIntegrationTestEnv) (string, string) {
+		dir := env.T.TempDir()
+		assert.NoError(env.T, env.Execute([]string{"init", "-C", dir}))
+
+		for _, name := range []string{"blink", "other"} {
+			sketchDir := path.Join(dir, name)
+			assert.NoError(env.T, os.MkdirAll(sketchDir, 0755))
+			sketch := "void setup() {\n  Serial.begin(115200);\n}\n\nvoid loop() {}\n"
+			assert.NoError(env.T, os.WriteFile(path.Join(sketchDir, name+".ino"), []byte(sketch), 0644))
+		}
+
+		cwd, err := os.Getwd()
+		assert.NoError(env.T, err)
+		sketch, err := filepath.Rel(cwd, path.Join(dir, "blink", "blink.ino"))
+		assert.NoError(env.T, err)
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		return dir, sketch
+	}
+
+	testutil.RunMockIntegrationTest("prompts for build settings", t, func(env *testutil.MockIntegrationTestEnv) {
+		wizardDir, _ := setup(env)
+		env.ArduinoCli.EXPECT().GetPlatforms(gomock.Any()).Return(platforms, nil)
+		// prompted for options then validated for each project
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), &rpc.BoardDetailsRequest{Instance: instance, Fqbn: fqbn}).Return(detailsResp, nil).Times(3)
+
+		answers := []string{
+			"1",                          // sketch
+			"mega",                       // board search
+			"1",                          // board
+			"2",                          // processor
+			"",                           // baud
+			"",                           // build name
+			"build.extra_flags=-DLED=13", // build prop
+			"",                           // finish build props
+		}
+		env.Stdin = strings.NewReader(strings.Join(answers, "\n") + "\n")
+
+		err := env.Execute([]string{"add", "build", "-i", "-C", wizardDir})
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "blink/blink.ino")
+		assert.Contains(env.T, env.Stdout.String(), "Baud [115200]")
+
+		wizardConfig, err := util.ReadArdiConfig(path.Join(wizardDir, "ardi.json"))
+		assert.NoError(env.T, err)
+
+		flagsDir, sketch := setup(env)
+		env.Stdin = nil
+		err = env.Execute([]string{
+			"add", "build", "-C", flagsDir,
+			"-n", "blink", "-f", fqbn, "-s", sketch, "-b", "115200",
+			"-p", "build.extra_flags=-DLED=13", "--board-option", "cpu=atmega1280",
+		})
+		assert.NoError(env.T, err)
+
+		flagsConfig, err := util.ReadArdiConfig(path.Join(flagsDir, "ardi.json"))
+		assert.NoError(env.T, err)
+
+		assert.Equal(env.T, flagsConfig.Builds, wizardConfig.Builds)
+		assert.Equal(env.T, map[string]string{"cpu": "atmega1280"}, wizardConfig.Builds["blink"].BoardOptions)
+		assert.Equal(env.T, path.Join("blink", "blink.ino"), wizardConfig.Builds["blink"].Sketch)
+	})
+
+	testutil.RunMockIntegrationTest("rejects unavailable board options", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir, sketch := setup(env)
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), &rpc.BoardDetailsRequest{Instance: instance, Fqbn: fqbn}).Return(detailsResp, nil).Times(2)
+
+		err := env.Execute([]string{
+			"add", "build", "-C", dir,
+			"-n", "blink", "-f", fqbn, "-s", sketch, "--board-option", "cpu=atmega328",
+		})
+		assert.ErrorContains(env.T, err, "invalid value atmega328")
+
+		err = env.Execute([]string{
+			"add", "build", "-C", dir,
+			"-n", "blink", "-f", fqbn + ":speed=fast", "-s", sketch,
+		})
+		assert.ErrorContains(env.T, err, "invalid board option speed")
+
+		config, err := util.ReadArdiConfig(path.Join(dir, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Empty(env.T, config.Builds)
+	})
+
+	testutil.RunMockIntegrationTest("requires flags when not interactive", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir, _ := setup(env)
+
+		err := env.Execute([]string{"add", "build", "-C", dir})
+		assert.EqualError(env.T, err, `required flag(s) "fqbn", "name", "sketch" not set`)
+	})
+
+	testutil.RunMockIntegrationTest("errors when input ends", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir, _ := setup(env)
+		env.Stdin = strings.NewReader("")
+
+		err := env.Execute([]string{"add", "build", "-i", "-C", dir})
+		assert.Error(env.T, err)
+		assert.Contains(env.T, err.Error(), "no answer for choose")
+	})
+}
+
 func TestAddBoardURLCommand(t *testing.T) {
 	testutil.RunMockIntegrationTest("errors if project not initialized", t, func(env *testutil.MockIntegrationTestEnv) {
 		args := []string{"add", "board-url", "https://someboardurl.com"}
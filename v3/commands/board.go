@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/spf13/cobra"
+)
+
+func newBoardListCmd(env *CommandEnv) *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Long:  "\nList boards from installed platforms",
+		Short: "List boards from installed platforms",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+			boards := env.ArdiCore.Board.List()
+			return render(cmd, env, boards, boardsTable(boards, "", nil))
+		},
+	}
+	return listCmd
+}
+
+func newBoardSearchCmd(env *CommandEnv) *cobra.Command {
+	searchCmd := &cobra.Command{
+		Use: "search [query]",
+		Long: "\nSearch boards across all indexed platforms, including " +
+			"platforms that are not installed",
+		Short: "Search boards across all indexed platforms",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+			query := ""
+			if len(args) > 0 {
+				query = args[0]
+			}
+			boards, err := env.ArdiCore.Board.Search(query)
+			if err != nil {
+				return err
+			}
+			table := boardsTable(boards, "Platform", func(b types.Board) string {
+				return b.Platform
+			})
+			return render(cmd, env, boards, table)
+		},
+	}
+	return searchCmd
+}
+
+func newBoardAttachedCmd(env *CommandEnv) *cobra.Command {
+	attachedCmd := &cobra.Command{
+		Use:   "attached",
+		Long:  "\nList boards detected on connected ports",
+		Short: "List boards detected on connected ports",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+			boards := env.ArdiCore.Board.Attached()
+			table := boardsTable(boards, "Port", func(b types.Board) string {
+				return b.Port
+			})
+			return render(cmd, env, boards, table)
+		},
+	}
+	return attachedCmd
+}
+
+func newBoardOptionsCmd(env *CommandEnv) *cobra.Command {
+	optionsCmd := &cobra.Command{
+		Use: "options <fqbn>",
+		Long: "\nList the menu options available for a board from its installed " +
+			"platform. Set these options on a build with boardOptions in " +
+			"ardi.json rather than appending them to the build's fqbn.",
+		Short: "List the menu options available for a board",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+			options, err := env.ArdiCore.Board.Options(args[0])
+			if err != nil {
+				return err
+			}
+			return render(cmd, env, options, boardOptionsTable(options))
+		},
+	}
+	return optionsCmd
+}
+
+func newBoardCmd(env *CommandEnv) *cobra.Command {
+	boardCmd := &cobra.Command{
+		Use:   "board",
+		Short: "List, search, detect, and inspect arduino boards",
+		Long:  "\nList, search, detect, and inspect arduino boards",
+	}
+	boardCmd.AddCommand(newBoardListCmd(env))
+	boardCmd.AddCommand(newBoardSearchCmd(env))
+	boardCmd.AddCommand(newBoardAttachedCmd(env))
+	boardCmd.AddCommand(newBoardOptionsCmd(env))
+	return boardCmd
+}
@@ -0,0 +1,198 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoardListCommand(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	platforms := []*rpc.Platform{
+		{
+			Id: "arduino:avr",
+			Boards: []*rpc.Board{
+				{Name: "Arduino Uno", Fqbn: "arduino:avr:uno"},
+				{Name: "Arduino Mega", Fqbn: "arduino:avr:mega"},
+			},
+		},
+	}
+
+	testutil.RunMockIntegrationTest("errors if project not initialized", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.Execute([]string{"board", "list"})
+		assert.Error(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("lists boards from installed platforms", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().GetPlatforms(gomock.Any()).Return(platforms, nil)
+
+		env.ClearStdout()
+		err = env.Execute([]string{"board", "list", "-o", "json"})
+		assert.NoError(env.T, err)
+
+		var boards []types.Board
+		err = json.Unmarshal(env.Stdout.Bytes(), &boards)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []types.Board{
+			{Name: "Arduino Mega", FQBN: "arduino:avr:mega"},
+			{Name: "Arduino Uno", FQBN: "arduino:avr:uno"},
+		}, boards)
+	})
+}
+
+func TestBoardSearchCommand(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	testutil.RunMockIntegrationTest("searches boards across indexed platforms", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		req := &rpc.BoardSearchRequest{Instance: instance, SearchArgs: "esp32"}
+		resp := &rpc.BoardSearchResponse{
+			Boards: []*rpc.BoardListItem{
+				{Name: "ESP32 Dev Module", Fqbn: "esp32:esp32:esp32", Platform: &rpc.Platform{Id: "esp32:esp32"}},
+			},
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardSearch(gomock.Any(), req).Return(resp, nil)
+
+		err = env.Execute([]string{"board", "search", "esp32"})
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "ESP32 Dev Module")
+		assert.Contains(env.T, env.Stdout.String(), "esp32:esp32:esp32")
+	})
+
+	testutil.RunMockIntegrationTest("returns search error", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardSearch(gomock.Any(), gomock.Any()).Return(nil, errors.New("dummy error"))
+
+		err = env.Execute([]string{"board", "search", "esp32"})
+		assert.EqualError(env.T, err, "dummy error")
+	})
+}
+
+func TestBoardAttachedCommand(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	testutil.RunMockIntegrationTest("lists attached boards with ports", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		ports := []*rpc.DetectedPort{
+			{
+				Port: &rpc.Port{Address: "/dev/ttyACM0"},
+				MatchingBoards: []*rpc.BoardListItem{
+					{Name: "Arduino Mega", Fqbn: "arduino:avr:mega"},
+				},
+			},
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().ConnectedBoards(&rpc.BoardListRequest{Instance: instance}).Return(ports, nil)
+
+		env.ClearStdout()
+		err = env.Execute([]string{"board", "attached", "-o", "json"})
+		assert.NoError(env.T, err)
+
+		var boards []types.Board
+		err = json.Unmarshal(env.Stdout.Bytes(), &boards)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []types.Board{
+			{Name: "Arduino Mega", FQBN: "arduino:avr:mega", Port: "/dev/ttyACM0"},
+		}, boards)
+	})
+}
+
+func TestBoardOptionsCommand(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+	fqbn := "esp32:esp32:esp32"
+
+	detailsReq := &rpc.BoardDetailsRequest{
+		Instance: instance,
+		Fqbn:     fqbn,
+	}
+
+	detailsResp := &rpc.BoardDetailsResponse{
+		ConfigOptions: []*rpc.ConfigOption{
+			{
+				Option:      "PartitionScheme",
+				OptionLabel: "Partition Scheme",
+				Values: []*rpc.ConfigValue{
+					{Value: "default", ValueLabel: "Default 4MB", Selected: true},
+					{Value: "min_spiffs", ValueLabel: "Minimal SPIFFS"},
+				},
+			},
+		},
+	}
+
+	testutil.RunMockIntegrationTest("errors if project not initialized", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.Execute([]string{"board", "options", fqbn})
+		assert.Error(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("lists board options", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), detailsReq).Return(detailsResp, nil)
+
+		err = env.Execute([]string{"board", "options", fqbn})
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "Partition Scheme")
+		assert.Contains(env.T, env.Stdout.String(), "Default 4MB (default)")
+		assert.Contains(env.T, env.Stdout.String(), "min_spiffs")
+	})
+
+	testutil.RunMockIntegrationTest("lists board options as json", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), detailsReq).Return(detailsResp, nil)
+
+		env.ClearStdout()
+		err = env.Execute([]string{"board", "options", fqbn + ":PartitionScheme=default", "-o", "json"})
+		assert.NoError(env.T, err)
+
+		var options []types.BoardOption
+		err = json.Unmarshal(env.Stdout.Bytes(), &options)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []types.BoardOption{
+			{
+				Option: "PartitionScheme",
+				Label:  "Partition Scheme",
+				Values: []types.BoardOptionValue{
+					{Value: "default", Label: "Default 4MB", Default: true},
+					{Value: "min_spiffs", Label: "Minimal SPIFFS"},
+				},
+			},
+		}, options)
+	})
+
+	testutil.RunMockIntegrationTest("returns board details error", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), detailsReq).Return(nil, errors.New("platform not installed"))
+
+		err = env.Execute([]string{"board", "options", fqbn})
+		assert.EqualError(env.T, err, "platform not installed")
+	})
+}
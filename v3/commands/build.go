@@ -2,40 +2,147 @@ package commands
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/robgonnella/ardi/v3/core"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+func compileBuild(env *CommandEnv, ardiCore *core.ArdiCore, name string, showProps bool) error {
+	opts, err := ardiCore.Config.GetCompileOpts(name)
+	if err != nil {
+		return err
+	}
+
+	opts.BuildName = name
+	opts.ShowProps = showProps
+
+	if env.structuredLogs() {
+		entry := env.Logger.WithFields(log.Fields{
+			"build":  name,
+			"sketch": opts.SketchPath,
+			"fqbn":   opts.FQBN,
+		})
+		stdout := core.NewLogStream(entry.WithField("stream", "stdout"), log.InfoLevel)
+		stderr := core.NewLogStream(entry.WithField("stream", "stderr"), log.WarnLevel)
+		defer stdout.Close()
+		defer stderr.Close()
+		opts.Stdout = stdout
+		opts.Stderr = stderr
+	}
+
+	return ardiCore.Compiler.Compile(*opts)
+}
+
+// resolveBuild returns the ArdiCore and build name for a build argument.
+// Arguments of the form member/build address a build in a workspace member.
+func resolveBuild(env *CommandEnv, ref string) (*core.ArdiCore, string, error) {
+	if env.Workspace == nil || !strings.Contains(ref, "/") {
+		return env.ArdiCore, ref, nil
+	}
+	if _, ok := env.ArdiCore.Config.GetBuilds()[ref]; ok && !env.workspaceMode() {
+		return env.ArdiCore, ref, nil
+	}
+	return env.Workspace.ResolveBuild(ref)
+}
+
+func compileWorkspace(env *CommandEnv, showProps bool) error {
+	compiled := 0
+	for _, name := range env.Workspace.Members() {
+		member, err := env.Workspace.Member(name)
+		if err != nil {
+			return err
+		}
+
+		builds := []string{}
+		for build := range member.Config.GetBuilds() {
+			builds = append(builds, build)
+		}
+		sort.Strings(builds)
+
+		for _, build := range builds {
+			env.Logger.Infof("Compiling workspace build: %s/%s", name, build)
+			if err := compileBuild(env, member, build, showProps); err != nil {
+				return err
+			}
+			compiled++
+		}
+	}
+
+	if compiled == 0 {
+		return errors.New("no builds defined in any workspace member")
+	}
+
+	return nil
+}
+
+// parseKeyValues parses key=value flag values naming kind in errors
+func parseKeyValues(kind string, values []string) (map[string]string, error) {
+	parsed := make(map[string]string)
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid %s %s, expected key=value", kind, v)
+		}
+		parsed[parts[0]] = parts[1]
+	}
+	return parsed, nil
+}
+
+// overrideVars applies var overrides to the project and all workspace
+// members
+func overrideVars(env *CommandEnv, vars map[string]string) {
+	env.ArdiCore.Config.OverrideVars(vars)
+	if env.Workspace == nil {
+		return
+	}
+	for _, name := range env.Workspace.Members() {
+		if member, err := env.Workspace.Member(name); err == nil {
+			member.Config.OverrideVars(vars)
+		}
+	}
+}
+
 func newBuildCmd(env *CommandEnv) *cobra.Command {
 	var all bool
 	var showProps bool
+	var vars []string
 
 	var buildCmd = &cobra.Command{
-		Use:   "build",
-		Long:  "\nCompiles builds defined in ardi.json",
+		Use: "build",
+		Long: "\nCompiles builds defined in ardi.json. Within a workspace, builds " +
+			"in any member may be specified as member/build, and --all compiles " +
+			"the builds of every member when run outside of a member project. " +
+			"Build sketch paths, fqbns, and props may reference ${env:NAME}, " +
+			"${var:name}, and ${project.dir}.",
 		Short: "Compiles builds defined in ardi.json",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			overrides, err := parseKeyValues("var", vars)
+			if err != nil {
+				return err
+			}
+			overrideVars(env, overrides)
+
+			if env.workspaceMode() {
+				if all {
+					return compileWorkspace(env, showProps)
+				}
+			} else if err := requireProjectInit(env); err != nil {
 				return err
 			}
 
 			ardiBuilds := env.ArdiCore.Config.GetBuilds()
 
-			if len(ardiBuilds) == 0 {
+			if len(ardiBuilds) == 0 && (all || env.Workspace == nil) {
 				return errors.New("no builds defined in ardi.json")
 			}
 
 			if all {
 				for name := range ardiBuilds {
-					opts, err := env.ArdiCore.Config.GetCompileOpts(name)
-
-					if err != nil {
-						return err
-					}
-
-					opts.ShowProps = showProps
-
-					if err := env.ArdiCore.Compiler.Compile(*opts); err != nil {
+					if err := compileBuild(env, env.ArdiCore, name, showProps); err != nil {
 						return err
 					}
 				}
@@ -43,15 +150,11 @@ func newBuildCmd(env *CommandEnv) *cobra.Command {
 			}
 
 			for _, build := range args {
-				opts, err := env.ArdiCore.Config.GetCompileOpts(build)
-
+				ardiCore, name, err := resolveBuild(env, build)
 				if err != nil {
 					return err
 				}
-
-				opts.ShowProps = showProps
-
-				if err := env.ArdiCore.Compiler.Compile(*opts); err != nil {
+				if err := compileBuild(env, ardiCore, name, showProps); err != nil {
 					return err
 				}
 			}
@@ -62,6 +165,7 @@ func newBuildCmd(env *CommandEnv) *cobra.Command {
 
 	buildCmd.Flags().BoolVarP(&all, "all", "a", false, "Compile all builds specified in ardi.json")
 	buildCmd.Flags().BoolVarP(&showProps, "show-props", "s", false, "Show all build properties (does not compile)")
+	buildCmd.Flags().StringArrayVar(&vars, "var", []string{}, "Override a var used in ${var:name} references (key=value)")
 
 	return buildCmd
 }
@@ -1,13 +1,21 @@
 package commands_test
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"io/ioutil"
 	"path"
+	"strings"
 	"testing"
 
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	"github.com/golang/mock/gomock"
 	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -263,4 +271,313 @@ func TestBuildCommand(t *testing.T) {
 		err = env.Execute(args)
 		assert.NoError(env.T, err)
 	})
+
+	writeCompileOutput := func(ctx context.Context, req *rpc.CompileRequest, stdout, stderr io.Writer, cb rpc.TaskProgressCB, debug bool) (*rpc.CompileResponse, error) {
+		io.WriteString(stdout, "Sketch uses 1024 bytes\n")
+		io.WriteString(stderr, "warning: unused variable\n")
+		return &rpc.CompileResponse{}, nil
+	}
+
+	testutil.RunMockIntegrationTest("logs compiler output as structured json", t, func(env *testutil.MockIntegrationTestEnv) {
+		env.RunProjectInit()
+
+		expectUsual(env)
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(writeCompileOutput)
+
+		args := []string{"add", "build", "-n", buildName1, "-f", fqbn1, "-s", sketchDir1}
+		err := env.Execute(args)
+		assert.NoError(env.T, err)
+
+		env.ClearStdout()
+
+		args = []string{"build", buildName1, "--log-format", "json"}
+		err = env.Execute(args)
+		assert.NoError(env.T, err)
+
+		entries := []map[string]interface{}{}
+		scanner := bufio.NewScanner(strings.NewReader(env.Stdout.String()))
+		for scanner.Scan() {
+			entry := map[string]interface{}{}
+			err := json.Unmarshal(scanner.Bytes(), &entry)
+			assert.NoError(env.T, err)
+			entries = append(entries, entry)
+		}
+
+		streams := map[string]map[string]interface{}{}
+		for _, e := range entries {
+			assert.Equal(env.T, buildName1, e["build"])
+			assert.Equal(env.T, fqbn1, e["fqbn"])
+			if stream, ok := e["stream"].(string); ok {
+				streams[stream] = e
+			}
+		}
+
+		assert.Equal(env.T, "Sketch uses 1024 bytes", streams["stdout"]["msg"])
+		assert.Equal(env.T, "info", streams["stdout"]["level"])
+		assert.Equal(env.T, "warning: unused variable", streams["stderr"]["msg"])
+		assert.Equal(env.T, "warning", streams["stderr"]["level"])
+	})
+
+	testutil.RunMockIntegrationTest("writes logs to file", t, func(env *testutil.MockIntegrationTestEnv) {
+		env.RunProjectInit()
+
+		logFile := path.Join(env.T.TempDir(), "ardi.log")
+
+		expectUsual(env)
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(writeCompileOutput)
+
+		args := []string{"add", "build", "-n", buildName1, "-f", fqbn1, "-s", sketchDir1}
+		err := env.Execute(args)
+		assert.NoError(env.T, err)
+
+		args = []string{"build", buildName1, "--log-file", logFile}
+		err = env.Execute(args)
+		assert.NoError(env.T, err)
+
+		contents, err := ioutil.ReadFile(logFile)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, string(contents), "Compilation successful")
+		assert.Contains(env.T, string(contents), "Sketch uses 1024 bytes")
+		assert.Contains(env.T, string(contents), "build="+buildName1)
+	})
+
+	testutil.RunMockIntegrationTest("errors on unsupported log format", t, func(env *testutil.MockIntegrationTestEnv) {
+		env.RunProjectInit()
+
+		args := []string{"build", buildName1, "--log-format", "xml"}
+		err := env.Execute(args)
+		assert.Error(env.T, err)
+	})
+}
+
+func TestBuildWorkspace(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	fqbn1 := testutil.Esp8266WifiduinoFQBN()
+	sketchDir1 := testutil.BlinkProjectDir()
+
+	fqbn2 := testutil.ArduinoMegaFQBN()
+	sketchDir2 := testutil.PixieProjectDir()
+
+	memberConfig := func(name, fqbn, sketchDir string) types.ArdiConfig {
+		config := util.GenArdiConfig()
+		config.Builds[name] = types.ArdiBuild{
+			Directory: sketchDir,
+			Sketch:    path.Join(sketchDir, name+".ino"),
+			FQBN:      fqbn,
+		}
+		return *config
+	}
+
+	compileReq := func(fqbn, sketchDir, sketch string) *rpc.CompileRequest {
+		return &rpc.CompileRequest{
+			Instance:        instance,
+			Fqbn:            fqbn,
+			SketchPath:      path.Join(sketchDir, sketch),
+			BuildProperties: []string{},
+			ExportDir:       path.Join(sketchDir, "build"),
+		}
+	}
+
+	setup := func(env *testutil.MockIntegrationTestEnv) string {
+		root := env.T.TempDir()
+		writeWorkspace(env.T, root, map[string]types.ArdiConfig{
+			"firmware/sensor":  memberConfig("blink", fqbn1, sketchDir1),
+			"firmware/gateway": memberConfig("pixie", fqbn2, sketchDir2),
+		})
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		return root
+	}
+
+	testutil.RunMockIntegrationTest("compiles all member builds", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := setup(env)
+
+		req1 := &compileReqMatcher{expectedReq: compileReq(fqbn1, sketchDir1, "blink.ino")}
+		req2 := &compileReqMatcher{expectedReq: compileReq(fqbn2, sketchDir2, "pixie.ino")}
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), req1, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), req2, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+		err := env.Execute([]string{"build", "--all", "-C", root})
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("compiles member build by reference", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := setup(env)
+
+		req := &compileReqMatcher{expectedReq: compileReq(fqbn2, sketchDir2, "pixie.ino")}
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), req, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+		err := env.Execute([]string{"build", "firmware/gateway/pixie", "-C", path.Join(root, "firmware", "sensor")})
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("compiles only member builds from within member", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := setup(env)
+
+		req := &compileReqMatcher{expectedReq: compileReq(fqbn1, sketchDir1, "blink.ino")}
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), req, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+		err := env.Execute([]string{"build", "--all", "-C", path.Join(root, "firmware", "sensor")})
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("errors on unknown member build", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := setup(env)
+
+		err := env.Execute([]string{"build", "firmware/noop/blink", "-C", root})
+		assert.Error(env.T, err)
+	})
+}
+
+func TestBuildHooks(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	testutil.RunMockIntegrationTest("fails build when hook fails", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		sketchDir := testutil.BlinkProjectDir()
+
+		config := util.GenArdiConfig()
+		config.Builds["release"] = types.ArdiBuild{
+			Directory: sketchDir,
+			Sketch:    path.Join(sketchDir, "blink.ino"),
+			FQBN:      testutil.ArduinoMegaFQBN(),
+			PostBuild: []string{"echo $ARDI_BUILD_NAME > hook.txt", "exit 1"},
+		}
+		data, err := json.Marshal(config)
+		assert.NoError(env.T, err)
+		assert.NoError(env.T, ioutil.WriteFile(path.Join(dir, "ardi.json"), data, 0644))
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+		err = env.Execute([]string{"build", "release", "-C", dir})
+		assert.EqualError(env.T, err, "postBuild hook \"exit 1\" failed for build release: exit status 1")
+
+		out, err := ioutil.ReadFile(path.Join(dir, "hook.txt"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "release\n", string(out))
+	})
+}
+
+func TestBuildVars(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+	sketchDir := testutil.BlinkProjectDir()
+
+	setup := func(env *testutil.MockIntegrationTestEnv) string {
+		dir := env.T.TempDir()
+		config := util.GenArdiConfig()
+		config.Vars = map[string]string{"region": "eu"}
+		config.Builds["release"] = types.ArdiBuild{
+			Directory: sketchDir,
+			Sketch:    path.Join(sketchDir, "blink.ino"),
+			FQBN:      testutil.ArduinoMegaFQBN(),
+			Props:     map[string]string{"build.extra_flags": "-DREGION=${var:region}"},
+		}
+		data, err := json.Marshal(config)
+		assert.NoError(env.T, err)
+		assert.NoError(env.T, ioutil.WriteFile(path.Join(dir, "ardi.json"), data, 0644))
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		return dir
+	}
+
+	expectCompile := func(env *testutil.MockIntegrationTestEnv, flags string) {
+		req := &rpc.CompileRequest{
+			Instance:        instance,
+			Fqbn:            testutil.ArduinoMegaFQBN(),
+			SketchPath:      path.Join(sketchDir, "blink.ino"),
+			BuildProperties: []string{flags},
+			ExportDir:       path.Join(sketchDir, "build"),
+		}
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), &compileReqMatcher{expectedReq: req}, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+	}
+
+	testutil.RunMockIntegrationTest("interpolates vars from ardi.json", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env)
+		expectCompile(env, "build.extra_flags=-DREGION=eu")
+
+		err := env.Execute([]string{"build", "release", "-C", dir})
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("overrides vars with --var", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env)
+		expectCompile(env, "build.extra_flags=-DREGION=us")
+
+		err := env.Execute([]string{"build", "release", "--var", "region=us", "-C", dir})
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("errors on invalid --var", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env)
+
+		err := env.Execute([]string{"build", "release", "--var", "region", "-C", dir})
+		assert.Error(env.T, err)
+	})
+}
+
+func TestBuildBoardOptions(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+	sketchDir := testutil.BlinkProjectDir()
+	fqbn := "esp32:esp32:esp32"
+
+	detailsResp := &rpc.BoardDetailsResponse{
+		ConfigOptions: []*rpc.ConfigOption{
+			{
+				Option: "PartitionScheme",
+				Values: []*rpc.ConfigValue{{Value: "default"}, {Value: "min_spiffs"}},
+			},
+			{
+				Option: "FlashFreq",
+				Values: []*rpc.ConfigValue{{Value: "80"}, {Value: "40"}},
+			},
+		},
+	}
+
+	setup := func(env *testutil.MockIntegrationTestEnv, options map[string]string) string {
+		dir := env.T.TempDir()
+		config := util.GenArdiConfig()
+		config.Builds["release"] = types.ArdiBuild{
+			Directory:    sketchDir,
+			Sketch:       path.Join(sketchDir, "blink.ino"),
+			FQBN:         fqbn,
+			Props:        map[string]string{},
+			BoardOptions: options,
+		}
+		data, err := json.Marshal(config)
+		assert.NoError(env.T, err)
+		assert.NoError(env.T, ioutil.WriteFile(path.Join(dir, "ardi.json"), data, 0644))
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), &rpc.BoardDetailsRequest{Instance: instance, Fqbn: fqbn}).Return(detailsResp, nil)
+		return dir
+	}
+
+	testutil.RunMockIntegrationTest("compiles with assembled fqbn", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{"PartitionScheme": "min_spiffs", "FlashFreq": "80"})
+
+		req := &rpc.CompileRequest{
+			Instance:        instance,
+			Fqbn:            fqbn + ":FlashFreq=80,PartitionScheme=min_spiffs",
+			SketchPath:      path.Join(sketchDir, "blink.ino"),
+			BuildProperties: []string{},
+			ExportDir:       path.Join(sketchDir, "build"),
+		}
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), &compileReqMatcher{expectedReq: req}, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+		err := env.Execute([]string{"build", "release", "-C", dir})
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("errors on invalid board option value", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{"PartitionScheme": "min_spifs"})
+
+		err := env.Execute([]string{"build", "release", "-C", dir})
+		assert.EqualError(env.T, err, "invalid value min_spifs for board option PartitionScheme on esp32:esp32:esp32, expected one of: default, min_spiffs")
+	})
+
+	testutil.RunMockIntegrationTest("errors on unknown board option", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{"FlashMode": "qio"})
+
+		err := env.Execute([]string{"build", "release", "-C", dir})
+		assert.EqualError(env.T, err, "invalid board option FlashMode for esp32:esp32:esp32, expected one of: PartitionScheme, FlashFreq")
+	})
 }
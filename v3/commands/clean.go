@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"github.com/robgonnella/ardi/v3/paths"
 	"github.com/robgonnella/ardi/v3/util"
 	"github.com/spf13/cobra"
 )
@@ -13,7 +12,7 @@ func newCleanCmd(env *CommandEnv) *cobra.Command {
 		Long: "\nRemoves all installed platforms and libraries from project " +
 			"data directory.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			dir := paths.ArduinoCliProjectDataDir
+			dir := env.ArdiCore.Paths.ArduinoCliDataDir
 			env.Logger.Infof("Cleaning ardi data directory: %s", dir)
 			util.CleanDataDirectory(dir)
 			env.Logger.Infof("Successfully removed all data from %s", dir)
@@ -4,7 +4,6 @@ import (
 	"os"
 	"testing"
 
-	"github.com/robgonnella/ardi/v3/paths"
 	"github.com/robgonnella/ardi/v3/testutil"
 	"github.com/stretchr/testify/assert"
 )
@@ -14,19 +13,19 @@ func TestCleanCommand(t *testing.T) {
 		err := env.RunProjectInit()
 		assert.NoError(env.T, err)
 
-		assert.DirExists(env.T, paths.ArduinoCliProjectDataDir)
-		assert.FileExists(env.T, paths.ArdiProjectConfig)
-		assert.FileExists(env.T, paths.ArduinoCliProjectConfig)
+		assert.DirExists(env.T, projectPaths.ArduinoCliDataDir)
+		assert.FileExists(env.T, projectPaths.ArdiConfig)
+		assert.FileExists(env.T, projectPaths.ArduinoCliConfig)
 
 		args := []string{"clean"}
 		err = env.Execute(args)
 		assert.NoError(env.T, err)
 
-		_, dirErr := os.Stat(paths.ArduinoCliProjectDataDir)
-		_, cliConfErr := os.Stat(paths.ArduinoCliProjectConfig)
+		_, dirErr := os.Stat(projectPaths.ArduinoCliDataDir)
+		_, cliConfErr := os.Stat(projectPaths.ArduinoCliConfig)
 
 		assert.True(env.T, os.IsNotExist(dirErr))
 		assert.True(env.T, os.IsNotExist(cliConfErr))
-		assert.FileExists(env.T, paths.ArdiProjectConfig)
+		assert.FileExists(env.T, projectPaths.ArdiConfig)
 	})
 }
@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/schema"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/spf13/cobra"
+)
+
+// validateConfigs validates each ardi.json returning the problems found in
+// every file, files without problems have no errors
+func validateConfigs(files []string) ([]*schema.ConfigError, int, error) {
+	results := []*schema.ConfigError{}
+	invalid := 0
+
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			return nil, 0, err
+		}
+
+		result := &schema.ConfigError{File: file, Errors: []schema.Error{}}
+
+		var configErr *schema.ConfigError
+		if _, err := util.ReadArdiConfig(file); errors.As(err, &configErr) {
+			result.Errors = configErr.Errors
+			invalid++
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, invalid, nil
+}
+
+func newConfigValidateCmd(env *CommandEnv) *cobra.Command {
+	validateCmd := &cobra.Command{
+		Use:   "validate [ardi.json...]",
+		Short: "Validate ardi.json against its schema",
+		Long: "\nValidate ardi.json files against the ardi.json JSON Schema. " +
+			"Every problem is reported with its line and column and the " +
+			"command exits non-zero if any file is invalid. Validates the " +
+			"project's ardi.json if no files are specified.",
+		// validation must not require a loadable project
+		PersistentPreRunE: standalonePreRun(env),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files := args
+			if len(files) == 0 {
+				projectPaths, err := paths.ResolveProjectPaths(env.ProjectDir)
+				if err != nil {
+					return err
+				}
+				files = []string{cwdRelative(projectPaths.ArdiConfig)}
+			}
+
+			results, invalid, err := validateConfigs(files)
+			if err != nil {
+				return err
+			}
+
+			if err := render(cmd, env, results, configErrorsTable(results)); err != nil {
+				return err
+			}
+
+			if invalid > 0 {
+				return problemsFound(cmd, "%d of %d config files invalid", invalid, len(results))
+			}
+
+			return nil
+		},
+	}
+
+	return validateCmd
+}
+
+// settingStore a config file with settings addressed by dotted paths
+type settingStore interface {
+	HasSetting(path string) bool
+	GetSetting(path string) (interface{}, error)
+	SetSetting(path, value string) error
+}
+
+// findSetting returns the config file the dotted path belongs to and its
+// name, ardi.json is checked before arduino-cli.yaml
+func findSetting(env *CommandEnv, settingPath string) (settingStore, string, error) {
+	if env.ArdiCore.Config.HasSetting(settingPath) {
+		return env.ArdiCore.Config, path.Base(env.ArdiCore.Paths.ArdiConfig), nil
+	}
+	if env.ArdiCore.CliConfig.HasSetting(settingPath) {
+		return env.ArdiCore.CliConfig, path.Base(env.ArdiCore.Paths.ArduinoCliConfig), nil
+	}
+	return nil, "", fmt.Errorf("unknown setting %q", settingPath)
+}
+
+func renderSetting(cmd *cobra.Command, env *CommandEnv, store settingStore, file, settingPath string) error {
+	value, err := store.GetSetting(settingPath)
+	if err != nil {
+		return err
+	}
+	setting := types.Setting{File: file, Path: settingPath, Value: value}
+	return render(cmd, env, setting, settingTable(setting))
+}
+
+func newConfigGetCmd(env *CommandEnv) *cobra.Command {
+	getCmd := &cobra.Command{
+		Use:   "get <path>",
+		Short: "Print a setting from ardi.json or arduino-cli.yaml",
+		Long: "\nPrint the setting at a dotted path from ardi.json or the " +
+			"project's arduino-cli.yaml, e.g. " +
+			"builds.release.props.build.extra_flags or logging.level. Lists " +
+			"and objects are printed as JSON.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+			store, file, err := findSetting(env, args[0])
+			if err != nil {
+				return err
+			}
+			return renderSetting(cmd, env, store, file, args[0])
+		},
+	}
+
+	return getCmd
+}
+
+func newConfigSetCmd(env *CommandEnv) *cobra.Command {
+	setCmd := &cobra.Command{
+		Use:   "set <path> <value>",
+		Short: "Set a setting in ardi.json or arduino-cli.yaml",
+		Long: "\nSet the setting at a dotted path in ardi.json or the " +
+			"project's arduino-cli.yaml. Numbers and booleans are parsed, " +
+			"lists and objects are given as JSON, and missing map entries such " +
+			"as build props are created. Changes that don't pass validation " +
+			"are rejected, as are builds given a malformed fqbn or board " +
+			"options their board doesn't offer.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+			store, file, err := findSetting(env, args[0])
+			if err != nil {
+				return err
+			}
+			if err := store.SetSetting(args[0], args[1]); err != nil {
+				return err
+			}
+			return renderSetting(cmd, env, store, file, args[0])
+		},
+	}
+
+	return setCmd
+}
+
+func newConfigCmd(env *CommandEnv) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Long:  "\nInspect, edit, and validate project configuration",
+		Short: "Inspect, edit, and validate project configuration",
+	}
+	configCmd.AddCommand(newConfigGetCmd(env))
+	configCmd.AddCommand(newConfigSetCmd(env))
+	configCmd.AddCommand(newConfigValidateCmd(env))
+	return configCmd
+}
@@ -0,0 +1,202 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/schema"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigValidateCommand(t *testing.T) {
+	testutil.RunIntegrationTest("validates project ardi.json", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+		err := env.Execute([]string{"init", "-C", dir})
+		assert.NoError(env.T, err)
+
+		env.ClearStdout()
+		err = env.Execute([]string{"config", "validate", "-C", dir})
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "valid")
+	})
+
+	testutil.RunIntegrationTest("reports invalid ardi.json without replacing it", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+		conf := path.Join(dir, "ardi.json")
+		content := []byte("{\n  \"builds\": {\n    \"uno\": {\"fqbm\": \"arduino:avr:uno\", \"baud\": \"9600\"}\n  }\n}\n")
+		err := os.WriteFile(conf, content, 0644)
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"config", "validate", conf, "--output", "json"})
+		assert.EqualError(env.T, err, "1 of 1 config files invalid")
+
+		var results []schema.ConfigError
+		err = json.Unmarshal(env.Stdout.Bytes(), &results)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []schema.ConfigError{
+			{
+				File: conf,
+				Errors: []schema.Error{
+					{Line: 3, Column: 13, Path: "builds.uno", Message: `unknown field "fqbm", did you mean "fqbn"?`},
+					{Line: 3, Column: 48, Path: "builds.uno.baud", Message: "expected integer, got string"},
+				},
+			},
+		}, results)
+
+		err = env.Execute([]string{"build", "-C", dir})
+		assert.ErrorContains(env.T, err, "unknown field")
+
+		data, err := os.ReadFile(conf)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, content, data)
+	})
+
+	testutil.RunIntegrationTest("errors if file not found", t, func(env *testutil.IntegrationTestEnv) {
+		err := env.Execute([]string{"config", "validate", path.Join(env.T.TempDir(), "ardi.json")})
+		assert.Error(env.T, err)
+	})
+}
+
+func TestConfigGetSetCommand(t *testing.T) {
+	getSetting := func(env *testutil.IntegrationTestEnv, dir, settingPath string) types.Setting {
+		env.ClearStdout()
+		err := env.Execute([]string{"config", "get", settingPath, "-C", dir, "--output", "json"})
+		assert.NoError(env.T, err)
+		var setting types.Setting
+		err = json.Unmarshal(env.Stdout.Bytes(), &setting)
+		assert.NoError(env.T, err)
+		return setting
+	}
+
+	testutil.RunIntegrationTest("sets and gets ardi.json settings", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+		err := env.Execute([]string{"init", "-C", dir})
+		assert.NoError(env.T, err)
+
+		build := `{"directory": "blink", "sketch": "blink/blink.ino", "fqbn": "arduino:avr:uno"}`
+		err = env.Execute([]string{"config", "set", "builds.release", build, "-C", dir})
+		assert.NoError(env.T, err)
+		err = env.Execute([]string{"config", "set", "-C", dir, "--", "builds.release.props.build.extra_flags", "-DNDEBUG"})
+		assert.NoError(env.T, err)
+		err = env.Execute([]string{"config", "set", "builds.release.baud", "115200", "-C", dir})
+		assert.NoError(env.T, err)
+
+		setting := getSetting(env, dir, "builds.release.props.build.extra_flags")
+		assert.Equal(env.T, types.Setting{File: "ardi.json", Path: "builds.release.props.build.extra_flags", Value: "-DNDEBUG"}, setting)
+		assert.Equal(env.T, float64(115200), getSetting(env, dir, "builds.release.baud").Value)
+
+		env.ClearStdout()
+		err = env.Execute([]string{"config", "get", "builds.release.fqbn", "-C", dir})
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "arduino:avr:uno\n", env.Stdout.String())
+
+		config, err := util.ReadArdiConfig(path.Join(dir, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"build.extra_flags": "-DNDEBUG"}, config.Builds["release"].Props)
+	})
+
+	testutil.RunIntegrationTest("sets and gets arduino-cli.yaml settings", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+		err := env.Execute([]string{"init", "-C", dir})
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"config", "set", "library.enable_unsafe_install", "true", "-C", dir})
+		assert.NoError(env.T, err)
+		err = env.Execute([]string{"config", "set", "logging.level", "debug", "-C", dir})
+		assert.NoError(env.T, err)
+
+		setting := getSetting(env, dir, "library.enable_unsafe_install")
+		assert.Equal(env.T, types.Setting{File: "arduino-cli.yaml", Path: "library.enable_unsafe_install", Value: true}, setting)
+		assert.Equal(env.T, map[string]interface{}{"file": "", "format": "text", "level": "debug"}, getSetting(env, dir, "logging").Value)
+
+		settings, err := util.ReadArduinoCliSettings(paths.NewProjectPaths(dir).ArduinoCliConfig)
+		assert.NoError(env.T, err)
+		assert.True(env.T, settings.Library.EnableUnsafeInstall)
+		assert.Equal(env.T, "debug", settings.Logging.Level)
+	})
+
+	testutil.RunIntegrationTest("rejects invalid changes", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+		err := env.Execute([]string{"init", "-C", dir})
+		assert.NoError(env.T, err)
+		before, err := os.ReadFile(path.Join(dir, "ardi.json"))
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"config", "set", "logging.level", "loud", "-C", dir})
+		assert.ErrorContains(env.T, err, "logging.level must be one of")
+
+		err = env.Execute([]string{"config", "set", "builds.release", `{"fqbm": "arduino:avr:uno"}`, "-C", dir})
+		assert.ErrorContains(env.T, err, `unknown field "fqbm"`)
+
+		err = env.Execute([]string{"config", "set", "builds.release", `{"extends": "missing"}`, "-C", dir})
+		assert.ErrorContains(env.T, err, "extends unknown build missing")
+
+		err = env.Execute([]string{"config", "set", "builds.release", `{"directory": "blink", "sketch": "blink/blink.ino", "fqbn": "arduino:uno"}`, "-C", dir})
+		assert.ErrorContains(env.T, err, "build release: invalid fqbn arduino:uno")
+
+		err = env.Execute([]string{"config", "set", "boardUrls", "https://example.com", "-C", dir})
+		assert.ErrorContains(env.T, err, "invalid value for boardUrls")
+
+		err = env.Execute([]string{"config", "set", "buidls.release.baud", "9600", "-C", dir})
+		assert.EqualError(env.T, err, `unknown setting "buidls.release.baud"`)
+
+		err = env.Execute([]string{"config", "get", "builds.missing.fqbn", "-C", dir})
+		assert.EqualError(env.T, err, `setting "builds.missing.fqbn" not found`)
+
+		after, err := os.ReadFile(path.Join(dir, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, before, after)
+	})
+}
+
+func TestConfigSetBoardOptions(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+	fqbn := "arduino:avr:mega"
+	detailsResp := &rpc.BoardDetailsResponse{
+		ConfigOptions: []*rpc.ConfigOption{
+			{
+				Option: "cpu",
+				Values: []*rpc.ConfigValue{
+					{Value: "atmega2560", Selected: true},
+					{Value: "atmega1280"},
+				},
+			},
+		},
+	}
+
+	testutil.RunMockIntegrationTest("validates board options of changed builds", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		assert.NoError(env.T, env.Execute([]string{"init", "-C", dir}))
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), &rpc.BoardDetailsRequest{Instance: instance, Fqbn: fqbn}).Return(detailsResp, nil).Times(3)
+
+		build := `{"directory": "blink", "sketch": "blink/blink.ino", "fqbn": "arduino:avr:mega"}`
+		err := env.Execute([]string{"config", "set", "builds.release", build, "-C", dir})
+		assert.NoError(env.T, err)
+		err = env.Execute([]string{"config", "set", "builds.release.boardOptions.cpu", "atmega1280", "-C", dir})
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"config", "set", "builds.release.boardOptions.cpu", "atmega328", "-C", dir})
+		assert.ErrorContains(env.T, err, "build release: invalid value atmega328")
+
+		err = env.Execute([]string{"config", "set", "builds.release.fqbn", fqbn + ":speed=fast", "-C", dir})
+		assert.ErrorContains(env.T, err, "build release: invalid board option speed")
+
+		// unchanged builds aren't validated again
+		err = env.Execute([]string{"config", "set", "builds.release.baud", "9600", "-C", dir})
+		assert.NoError(env.T, err)
+
+		config, err := util.ReadArdiConfig(path.Join(dir, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"cpu": "atmega1280"}, config.Builds["release"].BoardOptions)
+		assert.Equal(env.T, 9600, config.Builds["release"].Baud)
+	})
+}
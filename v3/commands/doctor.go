@@ -0,0 +1,393 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/schema"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	doctorOK      = "ok"
+	doctorWarning = "warning"
+	doctorFailed  = "failed"
+	doctorFixed   = "fixed"
+)
+
+// doctorCheck a health check result along with the fix applied by --fix,
+// fix is nil for problems that must be fixed by hand
+type doctorCheck struct {
+	types.DoctorCheck
+	fix func() (string, error)
+}
+
+func newDoctorCheck(check, status, message, suggestion string) doctorCheck {
+	return doctorCheck{DoctorCheck: types.DoctorCheck{
+		Check:   check,
+		Status:  status,
+		Message: message,
+		Fix:     suggestion,
+	}}
+}
+
+// checkConfigFile reports whether the config at confPath can be read, along
+// with every schema violation found
+func checkConfigFile(label, confPath, missingFix string, read func(string) error) doctorCheck {
+	if _, err := os.Stat(confPath); os.IsNotExist(err) {
+		return newDoctorCheck(label, doctorFailed, fmt.Sprintf("%s not found", cwdRelative(confPath)), missingFix)
+	}
+
+	var configErr *schema.ConfigError
+	if err := read(confPath); errors.As(err, &configErr) {
+		problems := []string{}
+		for _, e := range configErr.Errors {
+			problems = append(problems, e.Error())
+		}
+		return newDoctorCheck(label, doctorFailed, strings.Join(problems, "; "), "fix the problems reported by 'ardi config validate'")
+	} else if err != nil {
+		return newDoctorCheck(label, doctorFailed, err.Error(), "")
+	}
+
+	return newDoctorCheck(label, doctorOK, "valid", "")
+}
+
+func readArdiConfig(confPath string) error {
+	_, err := util.ReadArdiConfig(confPath)
+	return err
+}
+
+func readWorkspaceConfig(confPath string) error {
+	_, err := util.ReadWorkspaceConfig(confPath)
+	return err
+}
+
+// checkConfigs checks the project's ardi.json and, within a workspace, the
+// workspace config and the ardi.json of every member
+func checkConfigs(projectPaths paths.ProjectPaths) ([]doctorCheck, error) {
+	checks := []doctorCheck{
+		checkConfigFile("ardi.json", projectPaths.ArdiConfig, "run 'ardi init'", readArdiConfig),
+	}
+
+	root, err := paths.FindWorkspaceRoot(projectPaths.Root)
+	if errors.Is(err, paths.ErrWorkspaceNotFound) {
+		return checks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	workspacePaths := paths.NewWorkspacePaths(root)
+	workspaceCheck := checkConfigFile("ardi-workspace.json", workspacePaths.WorkspaceConfig, "", readWorkspaceConfig)
+	checks = append(checks, workspaceCheck)
+	if workspaceCheck.Status != doctorOK {
+		return checks, nil
+	}
+
+	workspaceConfig, err := util.ReadWorkspaceConfig(workspacePaths.WorkspaceConfig)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range workspaceConfig.Members {
+		memberPaths := workspacePaths.MemberPaths(path.Clean(member))
+		if memberPaths.Root == projectPaths.Root {
+			continue
+		}
+		label := path.Join(path.Clean(member), "ardi.json")
+		missingFix := fmt.Sprintf("run 'ardi init' in %s or remove it from the workspace members", member)
+		checks = append(checks, checkConfigFile(label, memberPaths.ArdiConfig, missingFix, readArdiConfig))
+	}
+
+	return checks, nil
+}
+
+// checkBoardURLs compares the board urls in ardi.json to those in
+// arduino-cli.yaml. Workspace members share arduino-cli.yaml so urls of
+// other members are expected there.
+func checkBoardURLs(env *CommandEnv) doctorCheck {
+	configured := env.ArdiCore.Config.GetBoardURLS()
+	synced := env.ArdiCore.CliConfig.Config.BoardManager.AdditionalUrls
+
+	missing := []string{}
+	for _, url := range configured {
+		if !util.ArrayContains(synced, url) {
+			missing = append(missing, url)
+		}
+	}
+	extra := []string{}
+	if env.Workspace == nil {
+		for _, url := range synced {
+			if !util.ArrayContains(configured, url) {
+				extra = append(extra, url)
+			}
+		}
+	}
+
+	if len(missing) == 0 && len(extra) == 0 {
+		return newDoctorCheck("board urls", doctorOK, "arduino-cli.yaml matches ardi.json", "")
+	}
+
+	problems := []string{}
+	status := doctorWarning
+	if len(missing) > 0 {
+		status = doctorFailed
+		problems = append(problems, "missing from arduino-cli.yaml: "+strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		problems = append(problems, "not in ardi.json: "+strings.Join(extra, ", "))
+	}
+
+	check := newDoctorCheck("board urls", status, strings.Join(problems, "; "), "run 'ardi doctor --fix'")
+	check.fix = func() (string, error) {
+		for _, url := range missing {
+			if err := env.ArdiCore.CliConfig.AddBoardURL(url); err != nil {
+				return "", err
+			}
+		}
+		for _, url := range extra {
+			if err := env.ArdiCore.CliConfig.RemoveBoardURL(url); err != nil {
+				return "", err
+			}
+		}
+		return "synced arduino-cli.yaml with ardi.json", nil
+	}
+	return check
+}
+
+// checkInstalled compares the declared versions of dependencies to those
+// installed, install installs a dependency at the given version
+func checkInstalled(kind string, declared, installed map[string]string, install func(string) error) []doctorCheck {
+	checks := []doctorCheck{}
+
+	for _, name := range util.SortedKeys(declared) {
+		version := declared[name]
+		current, ok := installed[name]
+		label := fmt.Sprintf("%s %s", kind, name)
+
+		switch {
+		case ok && (version == "" || version == current):
+			checks = append(checks, newDoctorCheck(label, doctorOK, fmt.Sprintf("%s installed", current), ""))
+			continue
+		case ok:
+			checks = append(checks, newDoctorCheck(label, doctorFailed, fmt.Sprintf("%s installed, ardi.json declares %s", current, version), "run 'ardi install'"))
+		default:
+			checks = append(checks, newDoctorCheck(label, doctorFailed, "not installed", "run 'ardi install'"))
+		}
+
+		target := name
+		if version != "" {
+			target = fmt.Sprintf("%s@%s", name, version)
+		}
+		checks[len(checks)-1].fix = func() (string, error) {
+			if err := install(target); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("installed %s", target), nil
+		}
+	}
+
+	return checks
+}
+
+func checkPlatforms(env *CommandEnv) ([]doctorCheck, error) {
+	platforms, err := env.ArdiCore.Cli.GetInstalledPlatforms()
+	if err != nil {
+		return nil, err
+	}
+
+	installed := make(map[string]string)
+	for _, p := range platforms {
+		installed[p.GetId()] = p.GetInstalled()
+	}
+
+	return checkInstalled("platform", env.ArdiCore.Config.GetPlatforms(), installed, func(platform string) error {
+		_, _, err := env.ArdiCore.Platform.Add(platform)
+		return err
+	}), nil
+}
+
+func checkLibraries(env *CommandEnv) ([]doctorCheck, error) {
+	libraries, err := env.ArdiCore.Cli.GetInstalledLibs()
+	if err != nil {
+		return nil, err
+	}
+
+	installed := make(map[string]string)
+	for _, l := range libraries {
+		installed[l.GetLibrary().GetName()] = l.GetLibrary().GetVersion()
+	}
+
+	return checkInstalled("library", env.ArdiCore.Config.GetLibraries(), installed, func(library string) error {
+		_, _, err := env.ArdiCore.Lib.Add(library)
+		return err
+	}), nil
+}
+
+func checkBuilds(env *CommandEnv) []doctorCheck {
+	checks := []doctorCheck{}
+
+	builds := env.ArdiCore.Config.GetBuilds()
+	names := []string{}
+	for name := range builds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		label := fmt.Sprintf("build %s", name)
+		suggestion := fmt.Sprintf("run 'ardi config set builds.%s.sketch <path>'", name)
+
+		sketch, err := env.ArdiCore.Config.BuildSketchPath(name)
+		if err != nil {
+			checks = append(checks, newDoctorCheck(label, doctorFailed, err.Error(), ""))
+			continue
+		}
+		if _, err := os.Stat(sketch); err != nil {
+			checks = append(checks, newDoctorCheck(label, doctorFailed, fmt.Sprintf("sketch not found: %s", cwdRelative(sketch)), suggestion))
+			continue
+		}
+		checks = append(checks, newDoctorCheck(label, doctorOK, cwdRelative(sketch), ""))
+	}
+
+	return checks
+}
+
+func checkSerialPorts() doctorCheck {
+	issue := util.CheckSerialPorts()
+	if issue == nil {
+		return newDoctorCheck("serial ports", doctorOK, "accessible", "")
+	}
+
+	status := doctorWarning
+	if issue.Blocking {
+		status = doctorFailed
+	}
+	return newDoctorCheck("serial ports", status, issue.Message, issue.Fix)
+}
+
+func checksPassed(checks []doctorCheck) bool {
+	for _, check := range checks {
+		if check.Status != doctorOK {
+			return false
+		}
+	}
+	return true
+}
+
+// runDoctor runs every project check, applying fixes when fix is set
+func runDoctor(env *CommandEnv, fix bool) ([]doctorCheck, error) {
+	checks := []doctorCheck{checkBoardURLs(env)}
+
+	platforms, err := checkPlatforms(env)
+	if err != nil {
+		return nil, err
+	}
+	checks = append(checks, platforms...)
+
+	libraries, err := checkLibraries(env)
+	if err != nil {
+		return nil, err
+	}
+	checks = append(checks, libraries...)
+
+	checks = append(checks, checkBuilds(env)...)
+	checks = append(checks, checkSerialPorts())
+
+	if !fix {
+		return checks, nil
+	}
+
+	// board urls are fixed first so newly added platform indexes are used
+	for i, check := range checks {
+		if check.Status == doctorOK || check.fix == nil {
+			continue
+		}
+		env.Logger.Infof("Fixing %s", check.Check)
+		message, err := check.fix()
+		if err != nil {
+			checks[i].Message = fmt.Sprintf("%s: fix failed: %s", check.Message, err)
+			continue
+		}
+		checks[i].Status = doctorFixed
+		checks[i].Message = message
+		checks[i].Fix = ""
+	}
+
+	return checks, nil
+}
+
+func newDoctorCmd(env *CommandEnv) *cobra.Command {
+	var fix bool
+	var configChecks []doctorCheck
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check project and environment health",
+		Long: "\nCheck that ardi.json is valid, every platform and library is " +
+			"installed at its declared version, arduino-cli.yaml board urls " +
+			"match ardi.json, every build's sketch exists, and serial ports " +
+			"are accessible. Within a workspace the workspace config and the " +
+			"ardi.json of every member are checked too. Each problem is " +
+			"reported with a suggested fix. Checking never writes project " +
+			"files, use --fix to install dependencies and sync board urls.",
+		// invalid configs are reported as checks rather than failing the
+		// project settings sync in the root command, which is skipped as
+		// checking is read only
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := standalonePreRun(env)(cmd, args); err != nil {
+				return err
+			}
+			projectPaths, err := paths.ResolveProjectPaths(env.ProjectDir)
+			if err != nil {
+				return err
+			}
+			if configChecks, err = checkConfigs(projectPaths); err != nil {
+				return err
+			}
+			if !checksPassed(configChecks) {
+				return nil
+			}
+			return loadArdiCore(cmd, env, false)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			checks := configChecks
+			if checksPassed(configChecks) {
+				projectChecks, err := runDoctor(env, fix)
+				if err != nil {
+					return err
+				}
+				checks = append(checks, projectChecks...)
+			}
+
+			results := []types.DoctorCheck{}
+			failed := 0
+			for _, check := range checks {
+				results = append(results, check.DoctorCheck)
+				if check.Status == doctorFailed {
+					failed++
+				}
+			}
+
+			if err := render(cmd, env, results, doctorTable(results)); err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				return problemsFound(cmd, "%d of %d checks failed", failed, len(results))
+			}
+
+			return nil
+		},
+	}
+
+	doctorCmd.Flags().BoolVar(&fix, "fix", false, "Install missing dependencies and sync board urls")
+
+	return doctorCmd
+}
@@ -0,0 +1,207 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	newProject := func(env *testutil.MockIntegrationTestEnv, boardURLs ...string) string {
+		dir := env.T.TempDir()
+		sketch := path.Join(dir, "blink", "blink.ino")
+		assert.NoError(env.T, os.MkdirAll(path.Dir(sketch), 0755))
+		assert.NoError(env.T, os.WriteFile(sketch, []byte("void setup() {}\n"), 0644))
+
+		config := util.GenArdiConfig()
+		config.Platforms["arduino:avr"] = "1.8.5"
+		config.Libraries["Adafruit Pixie"] = "1.0.3"
+		config.BoardURLS = append(config.BoardURLS, boardURLs...)
+		config.Builds["blink"] = types.ArdiBuild{Directory: "blink", Sketch: "blink/blink.ino", FQBN: "arduino:avr:uno"}
+		config.Builds["missing"] = types.ArdiBuild{Directory: "missing", Sketch: "missing/missing.ino", FQBN: "arduino:avr:uno"}
+		data, err := json.Marshal(config)
+		assert.NoError(env.T, err)
+		assert.NoError(env.T, os.WriteFile(path.Join(dir, "ardi.json"), data, 0644))
+
+		return dir
+	}
+
+	expectInstalled := func(env *testutil.MockIntegrationTestEnv, platformVersion string, libraries ...*rpc.InstalledLibrary) {
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().GetPlatforms(gomock.Any()).Return([]*rpc.Platform{
+			{Id: "arduino:avr", Installed: platformVersion},
+		}, nil).AnyTimes()
+		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), gomock.Any()).Return(&rpc.LibraryListResponse{
+			InstalledLibraries: libraries,
+		}, nil).AnyTimes()
+	}
+
+	runDoctor := func(env *testutil.MockIntegrationTestEnv, args ...string) (map[string]types.DoctorCheck, error) {
+		env.ClearStdout()
+		err := env.Execute(append([]string{"doctor", "--output", "json", "--quiet"}, args...))
+
+		var checks []types.DoctorCheck
+		assert.NoError(env.T, json.Unmarshal(env.Stdout.Bytes(), &checks))
+		results := make(map[string]types.DoctorCheck)
+		for _, c := range checks {
+			results[c.Check] = c
+		}
+		return results, err
+	}
+
+	pixie := &rpc.InstalledLibrary{Library: &rpc.Library{Name: "Adafruit Pixie", Version: "1.0.3"}}
+
+	testutil.RunMockIntegrationTest("reports healthy project", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := newProject(env)
+		expectInstalled(env, "1.8.5", pixie)
+
+		checks, err := runDoctor(env, "-C", dir)
+		assert.EqualError(env.T, err, "1 of 7 checks failed")
+		assert.Equal(env.T, "ok", checks["ardi.json"].Status)
+		assert.Equal(env.T, "ok", checks["board urls"].Status)
+		assert.Equal(env.T, types.DoctorCheck{Check: "platform arduino:avr", Status: "ok", Message: "1.8.5 installed"}, checks["platform arduino:avr"])
+		assert.Equal(env.T, "ok", checks["library Adafruit Pixie"].Status)
+		assert.Equal(env.T, "ok", checks["build blink"].Status)
+		assert.Contains(env.T, checks, "serial ports")
+
+		missing := checks["build missing"]
+		assert.Equal(env.T, "failed", missing.Status)
+		assert.Contains(env.T, missing.Message, "sketch not found")
+		assert.Equal(env.T, "run 'ardi config set builds.missing.sketch <path>'", missing.Fix)
+	})
+
+	testutil.RunMockIntegrationTest("reports mismatched dependencies and board urls", t, func(env *testutil.MockIntegrationTestEnv) {
+		url := "https://example.com/package_index.json"
+		dir := newProject(env, url)
+		expectInstalled(env, "1.8.6")
+
+		// arduino-cli.yaml has a url removed from ardi.json
+		cliConf := paths.NewProjectPaths(dir).ArduinoCliConfig
+		assert.NoError(env.T, util.CreateDataDir(path.Dir(cliConf)))
+		settings := util.GenArduinoCliSettings(path.Dir(cliConf))
+		settings.BoardManager.AdditionalUrls = []string{"https://example.com/old_index.json"}
+		assert.NoError(env.T, util.WriteAllSettings(paths.NewProjectPaths(dir), mustReadConfig(env, dir), settings))
+
+		checks, err := runDoctor(env, "-C", dir)
+		assert.EqualError(env.T, err, "4 of 7 checks failed")
+		assert.Equal(env.T, types.DoctorCheck{
+			Check:   "board urls",
+			Status:  "failed",
+			Message: "missing from arduino-cli.yaml: " + url + "; not in ardi.json: https://example.com/old_index.json",
+			Fix:     "run 'ardi doctor --fix'",
+		}, checks["board urls"])
+		assert.Equal(env.T, types.DoctorCheck{
+			Check:   "platform arduino:avr",
+			Status:  "failed",
+			Message: "1.8.6 installed, ardi.json declares 1.8.5",
+			Fix:     "run 'ardi install'",
+		}, checks["platform arduino:avr"])
+		assert.Equal(env.T, "not installed", checks["library Adafruit Pixie"].Message)
+	})
+
+	testutil.RunMockIntegrationTest("fixes dependencies and board urls", t, func(env *testutil.MockIntegrationTestEnv) {
+		url := "https://example.com/package_index.json"
+		dir := newProject(env, url)
+		expectInstalled(env, "1.8.6")
+
+		env.ArduinoCli.EXPECT().UpdateIndex(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		env.ArduinoCli.EXPECT().UpdateLibrariesIndex(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		env.ArduinoCli.EXPECT().PlatformInstall(gomock.Any(), &rpc.PlatformInstallRequest{
+			Instance:        instance,
+			PlatformPackage: "arduino",
+			Architecture:    "avr",
+			Version:         "1.8.5",
+		}, gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().LibraryInstall(gomock.Any(), &rpc.LibraryInstallRequest{
+			Instance: instance,
+			Name:     "Adafruit Pixie",
+			Version:  "1.0.3",
+		}, gomock.Any(), gomock.Any())
+
+		checks, err := runDoctor(env, "--fix", "-C", dir)
+		assert.EqualError(env.T, err, "1 of 7 checks failed")
+		assert.Equal(env.T, types.DoctorCheck{Check: "platform arduino:avr", Status: "fixed", Message: "installed arduino:avr@1.8.5"}, checks["platform arduino:avr"])
+		assert.Equal(env.T, "fixed", checks["library Adafruit Pixie"].Status)
+		assert.Equal(env.T, "fixed", checks["board urls"].Status)
+		assert.Equal(env.T, "failed", checks["build missing"].Status)
+
+		settings, err := util.ReadArduinoCliSettings(paths.NewProjectPaths(dir).ArduinoCliConfig)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []string{url}, settings.BoardManager.AdditionalUrls)
+	})
+
+	testutil.RunMockIntegrationTest("reports invalid ardi.json", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		err := os.WriteFile(path.Join(dir, "ardi.json"), []byte(`{"platform": {}}`), 0644)
+		assert.NoError(env.T, err)
+
+		checks, err := runDoctor(env, "-C", dir)
+		assert.EqualError(env.T, err, "1 of 1 checks failed")
+		assert.Equal(env.T, types.DoctorCheck{
+			Check:   "ardi.json",
+			Status:  "failed",
+			Message: `1:2: unknown field "platform", did you mean "platforms"?`,
+			Fix:     "fix the problems reported by 'ardi config validate'",
+		}, checks["ardi.json"])
+	})
+
+	testutil.RunMockIntegrationTest("doesn't write project files", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := newProject(env)
+		expectInstalled(env, "1.8.5", pixie)
+		original, err := os.ReadFile(path.Join(dir, "ardi.json"))
+		assert.NoError(env.T, err)
+
+		_, err = runDoctor(env, "-C", dir)
+		assert.EqualError(env.T, err, "1 of 7 checks failed")
+
+		data, err := os.ReadFile(path.Join(dir, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, original, data)
+		assert.NoDirExists(env.T, paths.NewProjectPaths(dir).ArduinoCliDataDir)
+	})
+
+	testutil.RunMockIntegrationTest("reports invalid workspace member configs", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := env.T.TempDir()
+		workspace := `{"members": ["sensor", "gateway", "display"]}`
+		assert.NoError(env.T, os.WriteFile(path.Join(root, "ardi-workspace.json"), []byte(workspace), 0644))
+		for member, config := range map[string]string{
+			"sensor":  `{"platforms": {}}`,
+			"gateway": `{"builds": {"main": {"fqbm": "arduino:avr:uno"}}}`,
+		} {
+			assert.NoError(env.T, os.MkdirAll(path.Join(root, member), 0755))
+			assert.NoError(env.T, os.WriteFile(path.Join(root, member, "ardi.json"), []byte(config), 0644))
+		}
+
+		checks, err := runDoctor(env, "-C", path.Join(root, "sensor"))
+		assert.EqualError(env.T, err, "2 of 4 checks failed")
+		assert.Equal(env.T, "ok", checks["ardi.json"].Status)
+		assert.Equal(env.T, "ok", checks["ardi-workspace.json"].Status)
+		assert.Equal(env.T, "failed", checks["gateway/ardi.json"].Status)
+		assert.Contains(env.T, checks["gateway/ardi.json"].Message, `unknown field "fqbm"`)
+		assert.Equal(env.T, "failed", checks["display/ardi.json"].Status)
+		assert.Contains(env.T, checks["display/ardi.json"].Fix, "ardi init")
+	})
+
+	testutil.RunMockIntegrationTest("reports uninitialized project", t, func(env *testutil.MockIntegrationTestEnv) {
+		checks, err := runDoctor(env, "-C", env.T.TempDir())
+		assert.EqualError(env.T, err, "1 of 1 checks failed")
+		assert.Equal(env.T, "run 'ardi init'", checks["ardi.json"].Fix)
+	})
+}
+
+func mustReadConfig(env *testutil.MockIntegrationTestEnv, dir string) *types.ArdiConfig {
+	config, err := util.ReadArdiConfig(path.Join(dir, "ardi.json"))
+	assert.NoError(env.T, err)
+	return config
+}
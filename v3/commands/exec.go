@@ -2,7 +2,6 @@ package commands
 
 import (
 	"github.com/arduino/arduino-cli/cli"
-	"github.com/robgonnella/ardi/v3/paths"
 	"github.com/spf13/cobra"
 )
 
@@ -22,14 +21,14 @@ func newExecCmd(env *CommandEnv) *cobra.Command {
 		Short: "Execute arduino-cli command",
 		Long:  "\nExecutes an arudion-cli command. All arduino-cli options are supported",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 
 			args = filter(args, func(a string) bool {
 				return a != "arduino-cli"
 			})
-			args = append(args, "--config-file", paths.ArduinoCliProjectConfig)
+			args = append(args, "--config-file", env.ArdiCore.Paths.ArduinoCliConfig)
 
 			arduinoCliCmd := cli.NewCommand()
 			arduinoCliCmd.SetArgs(args)
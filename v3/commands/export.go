@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/spf13/cobra"
+)
+
+func newExportSketchYAMLCmd(env *CommandEnv) *cobra.Command {
+	var force bool
+
+	exportCmd := &cobra.Command{
+		Use:   "sketch-yaml",
+		Short: "Export builds as arduino-cli sketch.yaml profiles",
+		Long: "\nExport builds as arduino-cli sketch.yaml profiles. A " +
+			paths.SketchYAMLFile + " is written to each sketch directory with a " +
+			"profile for every build of the sketch pinning the project's " +
+			"platforms and the installed libraries the sketch includes. Build props, hooks, and version " +
+			"injection can't be represented in sketch.yaml and are reported " +
+			"as warnings.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+
+			installed := util.InstalledLibraryContents(env.ArdiCore.CliConfig.Config.Directories.User)
+			sketchYAMLs, err := env.ArdiCore.Config.ExportSketchYAML(installed)
+			if err != nil {
+				return err
+			}
+
+			dirs := []string{}
+			for dir := range sketchYAMLs {
+				file := path.Join(dir, paths.SketchYAMLFile)
+				if _, err := os.Stat(file); err == nil && !force {
+					return fmt.Errorf("%s already exists, use --force to overwrite", file)
+				}
+				dirs = append(dirs, dir)
+			}
+			sort.Strings(dirs)
+
+			for _, dir := range dirs {
+				file := path.Join(dir, paths.SketchYAMLFile)
+				if err := util.WriteSketchYAML(file, sketchYAMLs[dir]); err != nil {
+					return err
+				}
+				env.Logger.WithField("file", file).Info("Exported sketch.yaml")
+			}
+
+			return nil
+		},
+	}
+
+	exportCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing sketch.yaml files")
+
+	return exportCmd
+}
+
+func newExportCmd(env *CommandEnv) *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Long:  "\nExport project to other build tool formats",
+		Short: "Export project to other build tool formats",
+	}
+	exportCmd.AddCommand(newExportSketchYAMLCmd(env))
+	return exportCmd
+}
@@ -0,0 +1,45 @@
+package commands_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCommand(t *testing.T) {
+	testutil.RunIntegrationTest("errors if project not initialized", t, func(env *testutil.IntegrationTestEnv) {
+		err := env.Execute([]string{"export", "sketch-yaml", "-C", env.T.TempDir()})
+		assert.Error(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("exports builds to sketch.yaml", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		err := env.Execute([]string{"new", dir, "--no-install"})
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"export", "sketch-yaml", "-C", dir})
+		assert.NoError(env.T, err)
+
+		file := path.Join(dir, "blink", "sketch.yaml")
+		sketchYAML, err := util.ReadSketchYAML(file)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "arduino:avr:uno", sketchYAML.Profiles["blink"].FQBN)
+
+		err = env.Execute([]string{"export", "sketch-yaml", "-C", dir})
+		assert.ErrorContains(env.T, err, "already exists")
+
+		err = os.WriteFile(file, []byte{}, 0644)
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"export", "sketch-yaml", "--force", "-C", dir})
+		assert.NoError(env.T, err)
+
+		sketchYAML, err = util.ReadSketchYAML(file)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, sketchYAML.Profiles, "blink")
+	})
+}
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/spf13/cobra"
+)
+
+// ensureProjectInit initializes the project directory if it has not been
+// initialized yet
+func ensureProjectInit(env *CommandEnv) error {
+	projectPaths := env.ArdiCore.Paths
+	if util.IsProjectDirectory(projectPaths) {
+		return nil
+	}
+	if err := util.InitProjectDirectory(projectPaths); err != nil {
+		return err
+	}
+	return util.EnsureGitIgnored(projectPaths.Root, paths.SecretsFile, paths.DotEnvFile)
+}
+
+func newImportSketchYAMLCmd(env *CommandEnv) *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "sketch-yaml [sketch-dir]",
+		Short: "Import builds from an arduino-cli sketch.yaml",
+		Long: "\nImport builds from the arduino-cli " + paths.SketchYAMLFile +
+			" in a sketch directory, which defaults to the current directory. " +
+			"A build is added to ardi.json for every profile along with the " +
+			"platforms, board urls, and libraries the profiles pin. The " +
+			"project is initialized if needed. Profile fields that can't be " +
+			"represented in ardi.json are reported as warnings.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := "."
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			if filepath.Base(dir) == paths.SketchYAMLFile {
+				dir = filepath.Dir(dir)
+			}
+
+			file := path.Join(dir, paths.SketchYAMLFile)
+			sketchYAML, err := util.ReadSketchYAML(file)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return fmt.Errorf("no %s found in %s", paths.SketchYAMLFile, dir)
+				}
+				return fmt.Errorf("failed to read %s: %w", file, err)
+			}
+
+			if err := ensureProjectInit(env); err != nil {
+				return err
+			}
+
+			if err := env.ArdiCore.Config.ImportSketchYAML(dir, sketchYAML); err != nil {
+				return err
+			}
+
+			env.Logger.Info("Run 'ardi install' to install the project dependencies")
+			return nil
+		},
+	}
+
+	return importCmd
+}
+
+func newImportPlatformIOCmd(env *CommandEnv) *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "platformio [platformio.ini]",
+		Short: "Import builds from a PlatformIO project",
+		Long: "\nImport builds from a PlatformIO project's platformio.ini, which " +
+			"defaults to the one in the current directory. A build is added to " +
+			"ardi.json for every [env:NAME] section. Boards and platforms are " +
+			"translated to fqbns and platforms where a mapping exists, lib_deps " +
+			"are added as libraries, and build_flags are added as " +
+			"compiler.cpp.extra_flags props. The project is initialized if " +
+			"needed. Settings that can't be mapped are listed in a report.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := "platformio.ini"
+			if len(args) > 0 {
+				file = args[0]
+			}
+
+			if _, err := os.Stat(file); err != nil {
+				return fmt.Errorf("failed to read %s: %w", file, err)
+			}
+
+			if err := ensureProjectInit(env); err != nil {
+				return err
+			}
+
+			issues, err := env.ArdiCore.Config.ImportPlatformIO(file)
+			if err != nil {
+				return err
+			}
+
+			if len(issues) == 0 {
+				env.Logger.Info("Imported all platformio settings")
+			} else {
+				env.Logger.Warnf("%d platformio settings could not be imported", len(issues))
+			}
+			env.Logger.Info("Run 'ardi install' to install the project dependencies")
+
+			return render(cmd, env, issues, importIssuesTable(issues))
+		},
+	}
+
+	return importCmd
+}
+
+func newImportCmd(env *CommandEnv) *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Long:  "\nImport project from other build tool formats",
+		Short: "Import project from other build tool formats",
+	}
+	importCmd.AddCommand(newImportPlatformIOCmd(env))
+	importCmd.AddCommand(newImportSketchYAMLCmd(env))
+	return importCmd
+}
@@ -0,0 +1,88 @@
+package commands_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportCommand(t *testing.T) {
+	sketchYAML := `profiles:
+  uno:
+    fqbn: arduino:avr:uno
+    port_config:
+      baudrate: 115200
+    platforms:
+      - platform: arduino:avr (1.8.6)
+    libraries:
+      - Adafruit Pixie (1.0.4)
+`
+
+	testutil.RunMockIntegrationTest("creates ardi.json from sketch.yaml", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		sketchDir := path.Join(dir, "blink")
+		err := os.MkdirAll(sketchDir, 0755)
+		assert.NoError(env.T, err)
+		err = os.WriteFile(path.Join(sketchDir, "blink.ino"), []byte("void setup() {}\n"), 0644)
+		assert.NoError(env.T, err)
+		err = os.WriteFile(path.Join(sketchDir, "sketch.yaml"), []byte(sketchYAML), 0644)
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"import", "sketch-yaml", sketchDir, "-C", dir})
+		assert.NoError(env.T, err)
+
+		config, err := util.ReadArdiConfig(paths.NewProjectPaths(dir).ArdiConfig)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"arduino:avr": "1.8.6"}, config.Platforms)
+		assert.Equal(env.T, map[string]string{"Adafruit Pixie": "1.0.4"}, config.Libraries)
+
+		build := config.Builds["uno"]
+		assert.Equal(env.T, "blink", build.Directory)
+		assert.Equal(env.T, "blink/blink.ino", build.Sketch)
+		assert.Equal(env.T, "arduino:avr:uno", build.FQBN)
+		assert.Equal(env.T, 115200, build.Baud)
+	})
+
+	testutil.RunMockIntegrationTest("errors if sketch.yaml not found", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+
+		err := env.Execute([]string{"import", "sketch-yaml", dir, "-C", dir})
+		assert.ErrorContains(env.T, err, "no sketch.yaml found")
+		assert.NoFileExists(env.T, paths.NewProjectPaths(dir).ArdiConfig)
+	})
+	testutil.RunMockIntegrationTest("creates ardi.json from platformio.ini", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		sketchDir := path.Join(dir, "src")
+		err := os.MkdirAll(sketchDir, 0755)
+		assert.NoError(env.T, err)
+		err = os.WriteFile(path.Join(sketchDir, "src.ino"), []byte("void setup() {}\n"), 0644)
+		assert.NoError(env.T, err)
+
+		ini := "[env:uno]\nplatform = atmelavr\nboard = uno\nframework = arduino\nupload_port = /dev/ttyACM0\n"
+		iniPath := path.Join(dir, "platformio.ini")
+		err = os.WriteFile(iniPath, []byte(ini), 0644)
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"import", "platformio", iniPath, "-C", dir})
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "upload_port")
+
+		config, err := util.ReadArdiConfig(paths.NewProjectPaths(dir).ArdiConfig)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "arduino:avr:uno", config.Builds["uno"].FQBN)
+		assert.Equal(env.T, "src/src.ino", config.Builds["uno"].Sketch)
+	})
+
+	testutil.RunMockIntegrationTest("errors if platformio.ini not found", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+
+		err := env.Execute([]string{"import", "platformio", path.Join(dir, "platformio.ini"), "-C", dir})
+		assert.Error(env.T, err)
+		assert.NoFileExists(env.T, paths.NewProjectPaths(dir).ArdiConfig)
+	})
+}
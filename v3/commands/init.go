@@ -1,20 +1,103 @@
 package commands
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/paths"
 	"github.com/robgonnella/ardi/v3/util"
 	"github.com/spf13/cobra"
 )
 
+// importSketch generates a build for the sketch in the project directory and
+// adds the libraries providing the headers it includes to ardi.json
+func importSketch(cmd *cobra.Command, env *CommandEnv, projectPaths paths.ProjectPaths, fqbn string) error {
+	project, err := util.ProcessSketch(projectPaths.Root)
+	if err != nil {
+		return fmt.Errorf("failed to find sketch in %s: %w", projectPaths.Root, err)
+	}
+
+	headers, err := util.ScanIncludes(project.Directory)
+	if err != nil {
+		return err
+	}
+
+	projectCore, err := newProjectCore(commandContext(cmd), env, projectPaths)
+	if err != nil {
+		return err
+	}
+
+	includes, err := projectCore.Lib.ResolveIncludes(headers)
+	if err != nil {
+		return err
+	}
+
+	for _, include := range includes {
+		if include.Library == "" {
+			env.Logger.WithField("include", include.Include).Warn("No library found for include, it may be provided by the board platform")
+			continue
+		}
+		if err := projectCore.Config.AddLibrary(include.Library, include.Version); err != nil {
+			return err
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(project.Sketch), filepath.Ext(project.Sketch))
+	if err := projectCore.Config.AddBuild(name, cwdRelative(project.Sketch), fqbn, 0, nil, nil); err != nil {
+		return err
+	}
+	if fqbn == "" {
+		env.Logger.Warnf("No fqbn specified, set the fqbn of build %s in ardi.json", name)
+	}
+
+	env.Logger.Info("Run 'ardi install' to install the project dependencies")
+
+	return render(cmd, env, includes, sketchIncludesTable(includes))
+}
+
 func newProjectInitCmd(env *CommandEnv) *cobra.Command {
+	var fromSketch bool
+	var fqbn string
+
 	initCmd := &cobra.Command{
 		Use:     "init",
 		Aliases: []string{"project-init"},
 		Short:   "Initialize directory as an ardi project",
-		Long:    "\nInitialize directory as an ardi project",
+		Long: "\nInitialize directory as an ardi project. The current directory " +
+			"is initialized unless a directory is specified with --project-dir " +
+			"or " + paths.ProjectDirEnv + ". Members of a workspace share the " +
+			"workspace data directory. The " + paths.SecretsFile + " and " +
+			paths.DotEnvFile + " secrets files are added to .gitignore.\n\n" +
+			"Use --from-sketch to adopt ardi in an existing sketch folder. The " +
+			"sketch's .ino, .cpp, and .h files are scanned for #include <...> " +
+			"directives, which are mapped to the libraries providing them in " +
+			"the library index. The libraries and a build for the sketch, " +
+			"using the baud passed to Serial.begin, are added to ardi.json.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return util.InitProjectDirectory()
+			dir := paths.ProjectDir(env.ProjectDir)
+			if dir == "" {
+				dir = "."
+			}
+			projectPaths, err := memberPaths(paths.NewProjectPaths(dir))
+			if err != nil {
+				return err
+			}
+			if err := util.InitProjectDirectory(projectPaths); err != nil {
+				return err
+			}
+			if err := util.EnsureGitIgnored(projectPaths.Root, paths.SecretsFile, paths.DotEnvFile); err != nil {
+				return err
+			}
+			if !fromSketch {
+				return nil
+			}
+			return importSketch(cmd, env, projectPaths, fqbn)
 		},
 	}
 
+	initCmd.Flags().BoolVar(&fromSketch, "from-sketch", false, "Generate ardi.json from the sketch in the project directory")
+	initCmd.Flags().StringVarP(&fqbn, "fqbn", "f", "", "Specify fully qualified board name for the generated build")
+
 	return initCmd
 }
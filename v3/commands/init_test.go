@@ -2,17 +2,23 @@ package commands_test
 
 import (
 	"os"
+	"path"
 	"testing"
 
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
 	"github.com/robgonnella/ardi/v3/paths"
 	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
 	"github.com/stretchr/testify/assert"
 )
 
+var projectPaths = paths.NewProjectPaths(".")
+
 func TestProjectInitCommand(t *testing.T) {
 	testutil.RunIntegrationTest("initializes a project directory", t, func(env *testutil.IntegrationTestEnv) {
-		_, dataConfigErr := os.Stat(paths.ArduinoCliProjectConfig)
-		_, buildConfigErr := os.Stat(paths.ArdiProjectConfig)
+		_, dataConfigErr := os.Stat(projectPaths.ArduinoCliConfig)
+		_, buildConfigErr := os.Stat(projectPaths.ArdiConfig)
 		assert.True(env.T, os.IsNotExist(dataConfigErr))
 		assert.True(env.T, os.IsNotExist(buildConfigErr))
 
@@ -20,10 +26,84 @@ func TestProjectInitCommand(t *testing.T) {
 		err := env.Execute(args)
 		assert.NoError(env.T, err)
 
-		_, dataConfigErr = os.Stat(paths.ArduinoCliProjectConfig)
-		_, buildConfigErr = os.Stat(paths.ArdiProjectConfig)
+		_, dataConfigErr = os.Stat(projectPaths.ArduinoCliConfig)
+		_, buildConfigErr = os.Stat(projectPaths.ArdiConfig)
 		assert.NoError(env.T, dataConfigErr)
 		assert.NoError(env.T, buildConfigErr)
 
 	})
+
+	testutil.RunIntegrationTest("initializes specified project directory", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+
+		args := []string{"init", "--project-dir", dir}
+		err := env.Execute(args)
+		assert.NoError(env.T, err)
+
+		p := paths.NewProjectPaths(dir)
+		assert.FileExists(env.T, p.ArdiConfig)
+		assert.FileExists(env.T, p.ArduinoCliConfig)
+		assert.NoFileExists(env.T, projectPaths.ArdiConfig)
+	})
+
+	testutil.RunIntegrationTest("adds secrets files to gitignore", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+		err := os.WriteFile(path.Join(dir, ".gitignore"), []byte("build\n"), 0644)
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"init", "-C", dir})
+		assert.NoError(env.T, err)
+
+		data, err := os.ReadFile(path.Join(dir, ".gitignore"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "build\nardi.secrets.json\n.env\n", string(data))
+	})
+
+	testutil.RunIntegrationTest("initializes project directory from environment", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+		env.T.Setenv(paths.ProjectDirEnv, dir)
+
+		args := []string{"init"}
+		err := env.Execute(args)
+		assert.NoError(env.T, err)
+
+		assert.FileExists(env.T, paths.NewProjectPaths(dir).ArdiConfig)
+		assert.NoFileExists(env.T, projectPaths.ArdiConfig)
+	})
+	testutil.RunMockIntegrationTest("initializes project from sketch", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := path.Join(env.T.TempDir(), "weather")
+		err := os.MkdirAll(dir, 0755)
+		assert.NoError(env.T, err)
+
+		sketch := "#include <Adafruit_BME280.h>\n#include <Wire.h>\n\nvoid setup()\n{\n  Serial.begin(115200);\n}\n\nvoid loop() {}\n"
+		err = os.WriteFile(path.Join(dir, "weather.ino"), []byte(sketch), 0644)
+		assert.NoError(env.T, err)
+
+		instance := &rpc.Instance{Id: 1}
+		resp := &rpc.LibrarySearchResponse{
+			Libraries: []*rpc.SearchedLibrary{
+				{
+					Name:   "Adafruit BME280 Library",
+					Latest: &rpc.LibraryRelease{Version: "2.2.2", ProvidesIncludes: []string{"Adafruit_BME280.h"}},
+				},
+			},
+		}
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().UpdateLibrariesIndex(gomock.Any(), gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().LibrarySearch(gomock.Any(), gomock.Any()).Return(resp, nil)
+
+		err = env.Execute([]string{"init", "--from-sketch", "--fqbn", "arduino:avr:uno", "-C", dir})
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "Adafruit BME280 Library")
+		assert.Contains(env.T, env.Stdout.String(), "(not found)")
+
+		config, err := util.ReadArdiConfig(paths.NewProjectPaths(dir).ArdiConfig)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"Adafruit BME280 Library": "2.2.2"}, config.Libraries)
+
+		build := config.Builds["weather"]
+		assert.Equal(env.T, "weather.ino", build.Sketch)
+		assert.Equal(env.T, "arduino:avr:uno", build.FQBN)
+		assert.Equal(env.T, 115200, build.Baud)
+	})
 }
@@ -3,41 +3,85 @@ package commands
 import (
 	"fmt"
 
+	"github.com/robgonnella/ardi/v3/core"
 	"github.com/robgonnella/ardi/v3/util"
 	"github.com/spf13/cobra"
 )
 
+func installDependencies(env *CommandEnv, ardiCore *core.ArdiCore, boardURLs []string, platforms, libraries map[string]string) error {
+	installedURLs := ardiCore.CliConfig.Config.BoardManager.AdditionalUrls
+	for _, url := range boardURLs {
+		if !util.ArrayContains(installedURLs, url) {
+			env.Logger.WithField("board-url", url).Info("Adding board url")
+			if err := ardiCore.CliConfig.AddBoardURL(url); err != nil {
+				return err
+			}
+		}
+	}
+	for plat, vers := range platforms {
+		_, _, err := ardiCore.Platform.Add(fmt.Sprintf("%s@%s", plat, vers))
+		if err != nil {
+			return err
+		}
+	}
+	for lib, vers := range libraries {
+		_, _, err := ardiCore.Lib.Add(fmt.Sprintf("%s@%s", lib, vers))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installWorkspace installs the dependencies of every workspace member into
+// the shared workspace data directory, installing each dependency once
+func installWorkspace(env *CommandEnv) error {
+	platforms, err := env.Workspace.Platforms()
+	if err != nil {
+		return err
+	}
+
+	libraries, err := env.Workspace.Libraries()
+	if err != nil {
+		return err
+	}
+
+	members := env.Workspace.Members()
+	if len(members) == 0 {
+		return nil
+	}
+
+	// all members share the workspace data directory so any member can
+	// be used to install dependencies
+	installer, err := env.Workspace.Member(members[0])
+	if err != nil {
+		return err
+	}
+
+	return installDependencies(env, installer, env.Workspace.BoardURLS(), platforms, libraries)
+}
+
 func newInstallCmd(env *CommandEnv) *cobra.Command {
 	installCmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install all project dependencies",
-		Long:  "\nInstall all project dependencies",
+		Long: "\nInstall all project dependencies. When run within a workspace " +
+			"but outside of any member project, the dependencies of all members " +
+			"are installed into the shared workspace data directory.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
-				return err
-			}
-			boardURLs := env.ArdiCore.CliConfig.Config.BoardManager.AdditionalUrls
-			for _, url := range env.ArdiCore.Config.GetBoardURLS() {
-				if !util.ArrayContains(boardURLs, url) {
-					env.Logger.WithField("board-url", url).Info("Adding board url")
-					if err := env.ArdiCore.CliConfig.AddBoardURL(url); err != nil {
-						return err
-					}
-				}
+			if env.workspaceMode() {
+				return installWorkspace(env)
 			}
-			for plat, vers := range env.ArdiCore.Config.GetPlatforms() {
-				_, _, err := env.ArdiCore.Platform.Add(fmt.Sprintf("%s@%s", plat, vers))
-				if err != nil {
-					return err
-				}
-			}
-			for lib, vers := range env.ArdiCore.Config.GetLibraries() {
-				_, _, err := env.ArdiCore.Lib.Add(fmt.Sprintf("%s@%s", lib, vers))
-				if err != nil {
-					return err
-				}
+			if err := requireProjectInit(env); err != nil {
+				return err
 			}
-			return nil
+			return installDependencies(
+				env,
+				env.ArdiCore,
+				env.ArdiCore.Config.GetBoardURLS(),
+				env.ArdiCore.Config.GetPlatforms(),
+				env.ArdiCore.Config.GetLibraries(),
+			)
 		},
 	}
 	return installCmd
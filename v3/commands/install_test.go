@@ -7,6 +7,8 @@ import (
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	"github.com/golang/mock/gomock"
 	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -134,3 +136,57 @@ func TestInstallCommand(t *testing.T) {
 		assert.ErrorIs(env.T, err, dummyErr)
 	})
 }
+
+func TestInstallWorkspace(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	memberConfig := func(platformVers string) types.ArdiConfig {
+		config := util.GenArdiConfig()
+		config.Platforms["some:platform"] = platformVers
+		config.Libraries["Some_Library"] = "3.4.1"
+		return *config
+	}
+
+	testutil.RunMockIntegrationTest("installs shared member dependencies once", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := env.T.TempDir()
+		writeWorkspace(env.T, root, map[string]types.ArdiConfig{
+			"firmware/sensor":  memberConfig("3.1.0"),
+			"firmware/gateway": memberConfig("3.1.0"),
+		})
+
+		installPlatReq := &rpc.PlatformInstallRequest{
+			Instance:        instance,
+			PlatformPackage: "some",
+			Architecture:    "platform",
+			Version:         "3.1.0",
+		}
+
+		installLibReq := &rpc.LibraryInstallRequest{
+			Instance: instance,
+			Name:     "Some_Library",
+			Version:  "3.4.1",
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance)
+		env.ArduinoCli.EXPECT().UpdateIndex(gomock.Any(), gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().PlatformInstall(gomock.Any(), installPlatReq, gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().GetPlatforms(gomock.Any())
+		env.ArduinoCli.EXPECT().UpdateLibrariesIndex(gomock.Any(), gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().LibraryInstall(gomock.Any(), installLibReq, gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), gomock.Any())
+
+		err := env.Execute([]string{"install", "-C", root})
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("errors on conflicting member versions", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := env.T.TempDir()
+		writeWorkspace(env.T, root, map[string]types.ArdiConfig{
+			"sensor":  memberConfig("3.1.0"),
+			"gateway": memberConfig("3.2.0"),
+		})
+
+		err := env.Execute([]string{"install", "-C", root})
+		assert.EqualError(env.T, err, "platform some:platform version conflict: gateway requires 3.2.0, sensor requires 3.1.0")
+	})
+}
@@ -1,6 +1,9 @@
 package commands
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/spf13/cobra"
+)
 
 func newListPlatformCmd(env *CommandEnv) *cobra.Command {
 	listCmd := &cobra.Command{
@@ -9,18 +12,18 @@ func newListPlatformCmd(env *CommandEnv) *cobra.Command {
 		Short:   "List project platforms",
 		Aliases: []string{"platform"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
-			env.Logger.Info("Platforms specified in ardi.json")
-			env.ArdiCore.Config.ListPlatforms()
-
-			env.Logger.Info("Installed platforms")
-			if err := env.ArdiCore.Platform.ListInstalled(); err != nil {
+			installed, err := env.ArdiCore.Platform.ListInstalled()
+			if err != nil {
 				return err
 			}
-
-			return nil
+			platforms := types.ProjectPlatforms{
+				Config:    env.ArdiCore.Config.GetPlatforms(),
+				Installed: installed,
+			}
+			return render(cmd, env, platforms, projectPlatformsTable(platforms))
 		},
 	}
 	return listCmd
@@ -33,35 +36,47 @@ func newListLibrariesCmd(env *CommandEnv) *cobra.Command {
 		Short:   "List project libraries",
 		Aliases: []string{"libs"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
-			env.Logger.Info("Libraries specified in ardi.json")
-			env.ArdiCore.Config.ListLibraries()
-			env.Logger.Info("Installed libraries")
-			if err := env.ArdiCore.Lib.ListInstalled(); err != nil {
+			installed, err := env.ArdiCore.Lib.ListInstalled()
+			if err != nil {
 				return err
 			}
-			return nil
+			libraries := types.ProjectLibraries{
+				Config:    env.ArdiCore.Config.GetLibraries(),
+				Installed: installed,
+			}
+			return render(cmd, env, libraries, projectLibrariesTable(libraries))
 		},
 	}
 	return listCmd
 }
 
 func newListBuildsCmd(env *CommandEnv) *cobra.Command {
+	var resolved bool
 	listCmd := &cobra.Command{
-		Use:     "builds",
-		Long:    "\nList project builds",
+		Use: "builds",
+		Long: "\nList project builds. Use --resolved to show builds merged with " +
+			"the builds they extend.",
 		Short:   "List project builds",
 		Aliases: []string{"build"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+			if !resolved {
+				builds := env.ArdiCore.Config.ListBuilds(args)
+				return render(cmd, env, builds, buildsTable(builds))
+			}
+			builds, err := env.ArdiCore.Config.ListResolvedBuilds(args)
+			if err != nil {
 				return err
 			}
-			env.ArdiCore.Config.ListBuilds(args)
-			return nil
+			return render(cmd, env, builds, buildsTable(builds))
 		},
 	}
+	listCmd.Flags().BoolVar(&resolved, "resolved", false, "Show builds merged with the builds they extend")
 	return listCmd
 }
 
@@ -71,11 +86,11 @@ func newListBoardURLSCmd(env *CommandEnv) *cobra.Command {
 		Long:  "\nList project board urls",
 		Short: "List project board urls",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
-			env.ArdiCore.Config.ListBoardURLS()
-			return nil
+			urls := env.ArdiCore.Config.GetBoardURLS()
+			return render(cmd, env, urls, boardURLsTable(urls))
 		},
 	}
 	return listCmd
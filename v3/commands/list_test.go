@@ -1,14 +1,19 @@
 package commands_test
 
 import (
+	"encoding/json"
 	"errors"
+	"os"
 	"path"
 	"testing"
 
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	"github.com/golang/mock/gomock"
 	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 func TestListPlatformCommand(t *testing.T) {
@@ -48,6 +53,32 @@ func TestListPlatformCommand(t *testing.T) {
 		assert.Contains(env.T, env.Stdout.String(), expectedPlatform.Name)
 	})
 
+	testutil.RunMockIntegrationTest("lists platforms as yaml", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		expectedPlatform := &rpc.Platform{
+			Id:        "cool:platform",
+			Installed: "1.2.3",
+			Name:      "Super Cool Platform",
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance)
+		env.ArduinoCli.EXPECT().GetPlatforms(platformReq).Return([]*rpc.Platform{expectedPlatform}, nil)
+
+		env.ClearStdout()
+		args := []string{"list", "platforms", "--output", "yaml"}
+		err = env.Execute(args)
+		assert.NoError(env.T, err)
+
+		var platforms types.ProjectPlatforms
+		err = yaml.Unmarshal(env.Stdout.Bytes(), &platforms)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, 1, len(platforms.Installed))
+		assert.Equal(env.T, expectedPlatform.Id, platforms.Installed[0].ID)
+		assert.Equal(env.T, expectedPlatform.Installed, platforms.Installed[0].Installed)
+	})
+
 	testutil.RunMockIntegrationTest("returns list platforms error", t, func(env *testutil.MockIntegrationTestEnv) {
 		err := env.RunProjectInit()
 		assert.NoError(env.T, err)
@@ -93,6 +124,7 @@ func TestListLibraryCommand(t *testing.T) {
 
 		env.ArduinoCli.EXPECT().CreateInstance().Return(instance)
 		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), listReq).Return(listResp, nil)
+		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), &rpc.LibraryListRequest{Instance: instance, Updatable: true}).Return(&rpc.LibraryListResponse{}, nil)
 
 		args := []string{"list", "libs"}
 		err = env.Execute(args)
@@ -146,6 +178,66 @@ func TestListBuildCommand(t *testing.T) {
 		assert.Contains(env.T, env.Stdout.String(), sketch)
 	})
 
+	testutil.RunMockIntegrationTest("lists builds as json", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		args := []string{"add", "build", "-n", build, "-f", fqbn, "-s", sketch}
+		err = env.Execute(args)
+		assert.NoError(env.T, err)
+
+		env.ClearStdout()
+		args = []string{"list", "builds", "-o", "json"}
+		err = env.Execute(args)
+		assert.NoError(env.T, err)
+
+		var builds map[string]types.ArdiBuild
+		err = json.Unmarshal(env.Stdout.Bytes(), &builds)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, fqbn, builds[build].FQBN)
+		assert.Equal(env.T, sketch, builds[build].Sketch)
+	})
+
+	testutil.RunMockIntegrationTest("errors on unsupported output format", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		args := []string{"list", "builds", "--output", "xml"}
+		err = env.Execute(args)
+		assert.Error(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("lists resolved builds", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		config := util.GenArdiConfig()
+		config.Builds["base"] = types.ArdiBuild{
+			Sketch: sketch,
+			FQBN:   fqbn,
+			Props:  map[string]string{"build.extra_flags": "-DLED=13", "compiler.optimization_flags": "-Os"},
+		}
+		config.Builds["debug"] = types.ArdiBuild{
+			Extends: "base",
+			Props:   map[string]string{"compiler.optimization_flags": "-Og"},
+		}
+		data, err := json.Marshal(config)
+		assert.NoError(env.T, err)
+		assert.NoError(env.T, os.WriteFile(path.Join(dir, "ardi.json"), data, 0644))
+
+		env.ClearStdout()
+		args := []string{"list", "builds", "debug", "--resolved", "-o", "json", "-C", dir}
+		err = env.Execute(args)
+		assert.NoError(env.T, err)
+
+		var builds map[string]types.ArdiBuild
+		err = json.Unmarshal(env.Stdout.Bytes(), &builds)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, types.ArdiBuild{
+			Sketch: sketch,
+			FQBN:   fqbn,
+			Props:  map[string]string{"build.extra_flags": "-DLED=13", "compiler.optimization_flags": "-Og"},
+		}, builds["debug"])
+	})
+
 	testutil.RunMockIntegrationTest("doesnt error if no builds to list", t, func(env *testutil.MockIntegrationTestEnv) {
 		err := env.RunProjectInit()
 		assert.NoError(env.T, err)
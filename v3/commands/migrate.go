@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/spf13/cobra"
+)
+
+// migrateBackupPrefix prefix of the directory original project files are
+// backed up to before migrating
+const migrateBackupPrefix = ".ardi-v2-backup-"
+
+// v2DataDirs returns the data and user directories used by the v2 data
+// directory in the project root, falling back to the default layout if its
+// arduino-cli.yaml can't be read
+func v2DataDirs(localDataDir string) (string, string) {
+	settings, err := util.ReadArduinoCliSettings(path.Join(localDataDir, "arduino-cli.yaml"))
+	if err != nil || settings.Directories.Data == "" {
+		return localDataDir, path.Join(localDataDir, "Arduino")
+	}
+	userDir := settings.Directories.User
+	if userDir == "" {
+		userDir = path.Join(settings.Directories.Data, "Arduino")
+	}
+	return settings.Directories.Data, userDir
+}
+
+// v2Markers returns what identifies the project as an ardi v2 project, an
+// empty result means it's already a v3 project. Besides the shape of
+// ardi.json, v2 data directories either point arduino-cli somewhere other
+// than the project data directory or sit in a workspace member root.
+func v2Markers(env *CommandEnv, localDataDir string) []string {
+	markers := env.ArdiCore.Config.V2Markers()
+
+	stat, err := os.Stat(localDataDir)
+	if err != nil || !stat.IsDir() {
+		return markers
+	}
+	if localDataDir != env.ArdiCore.Paths.ArduinoCliDataDir {
+		return append(markers, fmt.Sprintf("data directory %s in a workspace member", localDataDir))
+	}
+	if dataDir, _ := v2DataDirs(localDataDir); path.Clean(dataDir) != localDataDir {
+		markers = append(markers, fmt.Sprintf("arduino-cli data directory %s outside the project", dataDir))
+	}
+
+	return markers
+}
+
+// copyFile copies src to dst preserving its permissions
+func copyFile(src, dst string) error {
+	stat, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, stat.Mode())
+}
+
+func migrateProject(cmd *cobra.Command, env *CommandEnv, noInstall bool) ([]types.MigrationChange, error) {
+	projectPaths := env.ArdiCore.Paths
+	root := projectPaths.Root
+	// workspace members share the workspace data directory so any data
+	// directory in the member root was left behind by v2
+	localDataDir := paths.NewProjectPaths(root).ArduinoCliDataDir
+
+	if len(v2Markers(env, localDataDir)) == 0 {
+		return nil, errors.New("already a v3 project")
+	}
+
+	changes := []types.MigrationChange{}
+	record := func(kind, target, change string, args ...interface{}) {
+		changes = append(changes, types.MigrationChange{
+			Target: target,
+			Kind:   kind,
+			Change: fmt.Sprintf(change, args...),
+		})
+	}
+
+	backupDir := path.Join(root, migrateBackupPrefix+time.Now().Format("20060102150405"))
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return nil, err
+	}
+	if err := copyFile(projectPaths.ArdiConfig, path.Join(backupDir, "ardi.json")); err != nil {
+		return nil, err
+	}
+	record(core.MigrationChanged, "ardi.json", "backed up to %s", backupDir)
+
+	installedPlatforms := make(map[string]string)
+	installedLibraries := make(map[string]string)
+
+	if stat, err := os.Stat(localDataDir); err == nil && stat.IsDir() {
+		dataDir, userDir := v2DataDirs(localDataDir)
+		installedPlatforms = util.InstalledPlatformVersions(dataDir)
+		installedLibraries = util.InstalledLibraryVersions(userDir)
+
+		if dataDir != projectPaths.ArduinoCliDataDir {
+			record(core.MigrationChanged, "data directory", "moved from %s to %s", dataDir, projectPaths.ArduinoCliDataDir)
+		}
+		if localDataDir != projectPaths.ArduinoCliDataDir {
+			record(core.MigrationChanged, localDataDir, "removed stale data directory, workspace members share the workspace data directory")
+		}
+
+		if err := os.Rename(localDataDir, path.Join(backupDir, path.Base(localDataDir))); err != nil {
+			return nil, err
+		}
+		record(core.MigrationChanged, localDataDir, "backed up to %s", backupDir)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		globalDataDir := path.Join(home, ".ardi")
+		if stat, err := os.Stat(globalDataDir); err == nil && stat.IsDir() && globalDataDir != localDataDir {
+			record(core.MigrationWarning, globalDataDir, "global data directory is not used by v3 and can be removed once all projects are migrated")
+		}
+	}
+
+	configChanges, err := env.ArdiCore.Config.MigrateV2(installedPlatforms, installedLibraries)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, configChanges...)
+
+	if err := util.InitProjectDirectory(projectPaths); err != nil {
+		return nil, err
+	}
+	if err := util.EnsureGitIgnored(root, paths.SecretsFile, paths.DotEnvFile, migrateBackupPrefix+"*"); err != nil {
+		return nil, err
+	}
+	record(core.MigrationChanged, "data directory", "rebuilt %s", projectPaths.ArduinoCliDataDir)
+
+	if noInstall {
+		record(core.MigrationWarning, "dependencies", "not reinstalled, run 'ardi install'")
+		return changes, nil
+	}
+
+	projectCore, err := newProjectCore(commandContext(cmd), env, projectPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := installDependencies(
+		env,
+		projectCore,
+		projectCore.Config.GetBoardURLS(),
+		projectCore.Config.GetPlatforms(),
+		projectCore.Config.GetLibraries(),
+	); err != nil {
+		return nil, fmt.Errorf("failed to reinstall dependencies, original files are in %s: %w", backupDir, err)
+	}
+	record(core.MigrationChanged, "dependencies", "reinstalled")
+
+	return changes, nil
+}
+
+func newMigrateCmd(env *CommandEnv) *cobra.Command {
+	var noInstall bool
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate an ardi v2 project to v3",
+		Long: "\nMigrate an ardi v2 project to v3. The original ardi.json and " +
+			"data directory are backed up to a " + migrateBackupPrefix +
+			"<timestamp> directory in the project. Projects already in v3 shape " +
+			"are left untouched. Unpinned platforms and " +
+			"libraries are pinned to the versions v2 installed, build paths " +
+			"are made relative to the project, and literal ${ in build values " +
+			"are escaped so they aren't interpolated. The data directory is " +
+			"then rebuilt and dependencies reinstalled so the project compiles " +
+			"identically. A summary of the changes and anything needing " +
+			"attention, such as a global data directory, is printed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+
+			changes, err := migrateProject(cmd, env, noInstall)
+			if err != nil {
+				return err
+			}
+
+			return render(cmd, env, changes, migrationTable(changes))
+		},
+	}
+
+	migrateCmd.Flags().BoolVar(&noInstall, "no-install", false, "Do not reinstall the project dependencies")
+
+	return migrateCmd
+}
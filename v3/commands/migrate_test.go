@@ -0,0 +1,133 @@
+package commands_test
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateCommand(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	newV2Project := func(env *testutil.MockIntegrationTestEnv) string {
+		dir := env.T.TempDir()
+		sketch := path.Join(dir, "blink", "blink.ino")
+		assert.NoError(env.T, os.MkdirAll(path.Dir(sketch), 0755))
+		assert.NoError(env.T, os.WriteFile(sketch, []byte("void setup() {}\n"), 0644))
+
+		config := fmt.Sprintf(`{
+	"platforms": {"arduino:avr": ""},
+	"boardUrls": null,
+	"libraries": {"Adafruit Pixie": ""},
+	"builds": {
+		"blink": {"sketch": %q, "fqbn": "arduino:avr:uno", "props": {"build.extra_flags": "-DNAME=${NAME}"}}
+	}
+}`, sketch)
+		assert.NoError(env.T, os.WriteFile(path.Join(dir, "ardi.json"), []byte(config), 0644))
+
+		dataDir := path.Join(dir, ".ardi")
+		assert.NoError(env.T, os.MkdirAll(path.Join(dataDir, "packages", "arduino", "hardware", "avr", "1.8.5"), 0755))
+		libDir := path.Join(dataDir, "Arduino", "libraries", "Adafruit_Pixie")
+		assert.NoError(env.T, os.MkdirAll(libDir, 0755))
+		props := "name=Adafruit Pixie\nversion=1.0.3\n"
+		assert.NoError(env.T, os.WriteFile(path.Join(libDir, "library.properties"), []byte(props), 0644))
+
+		return dir
+	}
+
+	testutil.RunMockIntegrationTest("migrates v2 project", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := newV2Project(env)
+
+		err := env.Execute([]string{"migrate", "--no-install", "-C", dir})
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "pinned to installed version 1.8.5")
+
+		p := paths.NewProjectPaths(dir)
+		config, err := util.ReadArdiConfig(p.ArdiConfig)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"arduino:avr": "1.8.5"}, config.Platforms)
+		assert.Equal(env.T, map[string]string{"Adafruit Pixie": "1.0.3"}, config.Libraries)
+		assert.Equal(env.T, []string{}, config.BoardURLS)
+
+		build := config.Builds["blink"]
+		assert.Equal(env.T, "blink/blink.ino", build.Sketch)
+		assert.Equal(env.T, "blink", build.Directory)
+		assert.Equal(env.T, "-DNAME=$${NAME}", build.Props["build.extra_flags"])
+
+		backups, err := filepath.Glob(path.Join(dir, ".ardi-v2-backup-*"))
+		assert.NoError(env.T, err)
+		assert.Len(env.T, backups, 1)
+		assert.FileExists(env.T, path.Join(backups[0], "ardi.json"))
+		assert.DirExists(env.T, path.Join(backups[0], ".ardi", "packages", "arduino", "hardware", "avr", "1.8.5"))
+
+		assert.FileExists(env.T, p.ArduinoCliConfig)
+		assert.NoDirExists(env.T, path.Join(p.ArduinoCliDataDir, "packages"))
+	})
+
+	testutil.RunMockIntegrationTest("reinstalls pinned dependencies", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := newV2Project(env)
+
+		installPlatReq := &rpc.PlatformInstallRequest{
+			Instance:        instance,
+			PlatformPackage: "arduino",
+			Architecture:    "avr",
+			Version:         "1.8.5",
+		}
+		installLibReq := &rpc.LibraryInstallRequest{
+			Instance: instance,
+			Name:     "Adafruit Pixie",
+			Version:  "1.0.3",
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().UpdateIndex(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		env.ArduinoCli.EXPECT().UpdateLibrariesIndex(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		env.ArduinoCli.EXPECT().PlatformInstall(gomock.Any(), installPlatReq, gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().GetPlatforms(gomock.Any()).AnyTimes()
+		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), gomock.Any()).AnyTimes()
+		env.ArduinoCli.EXPECT().LibraryInstall(gomock.Any(), installLibReq, gomock.Any(), gomock.Any())
+
+		err := env.Execute([]string{"migrate", "-C", dir})
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "reinstalled")
+	})
+
+	testutil.RunMockIntegrationTest("leaves a v3 project untouched", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		config := `{
+	"platforms": {"arduino:avr": "1.8.5"},
+	"boardUrls": [],
+	"libraries": {},
+	"builds": {
+		"blink": {"directory": "blink", "sketch": "blink/blink.ino", "fqbn": "arduino:avr:uno", "props": {"build.extra_flags": "-DNAME=${env:NAME}"}}
+	}
+}`
+		assert.NoError(env.T, os.WriteFile(path.Join(dir, "ardi.json"), []byte(config), 0644))
+		pkgDir := path.Join(dir, ".ardi", "packages", "arduino", "hardware", "avr", "1.8.5")
+		assert.NoError(env.T, os.MkdirAll(pkgDir, 0755))
+
+		err := env.Execute([]string{"migrate", "--no-install", "-C", dir})
+		assert.ErrorContains(env.T, err, "already a v3 project")
+
+		assert.DirExists(env.T, pkgDir)
+		backups, err := filepath.Glob(path.Join(dir, ".ardi-v2-backup-*"))
+		assert.NoError(env.T, err)
+		assert.Empty(env.T, backups)
+	})
+
+	testutil.RunMockIntegrationTest("errors if not a project", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+
+		err := env.Execute([]string{"migrate", "-C", dir})
+		assert.ErrorContains(env.T, err, "not an ardi project directory")
+	})
+}
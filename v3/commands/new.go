@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/templates"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/spf13/cobra"
+)
+
+func builtinTemplatesHelp() string {
+	lines := []string{}
+	for _, t := range templates.Builtin() {
+		lines = append(lines, fmt.Sprintf("  %-12s %s", t.Name, t.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func requireEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("directory %s is not empty", dir)
+	}
+	return nil
+}
+
+func newProjectNewCmd(env *CommandEnv) *cobra.Command {
+	var template string
+	var noInstall bool
+
+	newCmd := &cobra.Command{
+		Use:   "new <dir>",
+		Short: "Create a new ardi project from a template",
+		Long: "\nCreate a new ardi project in the specified directory from a " +
+			"template. The template's sketch files are copied into the " +
+			"directory, which is then initialized as an ardi project with the " +
+			"platforms, libraries, and builds of the template. Dependencies " +
+			"are installed right away unless --no-install is specified.\n\n" +
+			"A template is either the name of a builtin template, a local " +
+			"directory, or a git repository url. A template directory must " +
+			"contain an ardi.json along with the sketch files of its builds. " +
+			"Builtin templates:\n\n" + builtinTemplatesHelp(),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+
+			if err := requireEmptyDir(dir); err != nil {
+				return err
+			}
+
+			t, err := templates.Load(template)
+			if err != nil {
+				return err
+			}
+			defer t.Close()
+
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+
+			if err := t.Render(dir); err != nil {
+				return err
+			}
+
+			projectPaths, err := memberPaths(paths.NewProjectPaths(dir))
+			if err != nil {
+				return err
+			}
+			if err := util.InitProjectDirectory(projectPaths); err != nil {
+				return err
+			}
+			if err := util.EnsureGitIgnored(projectPaths.Root, paths.SecretsFile, paths.DotEnvFile); err != nil {
+				return err
+			}
+
+			env.Logger.WithField("template", t.Name).Infof("Created project %s", dir)
+
+			if noInstall || env.NewArdiCore == nil {
+				return nil
+			}
+
+			projectCore, err := newProjectCore(commandContext(cmd), env, projectPaths)
+			if err != nil {
+				return err
+			}
+
+			return installDependencies(
+				env,
+				projectCore,
+				projectCore.Config.GetBoardURLS(),
+				projectCore.Config.GetPlatforms(),
+				projectCore.Config.GetLibraries(),
+			)
+		},
+	}
+
+	newCmd.Flags().StringVarP(&template, "template", "t", "blink", "Builtin template name, template directory, or template git repository url")
+	newCmd.Flags().BoolVar(&noInstall, "no-install", false, "Do not install the project dependencies")
+
+	return newCmd
+}
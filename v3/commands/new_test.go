@@ -0,0 +1,87 @@
+package commands_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCommand(t *testing.T) {
+	instance := &rpc.Instance{Id: 1}
+
+	testutil.RunIntegrationTest("creates project from builtin template", t, func(env *testutil.IntegrationTestEnv) {
+		dir := path.Join(env.T.TempDir(), "blinky")
+
+		err := env.Execute([]string{"new", dir, "--no-install"})
+		assert.NoError(env.T, err)
+
+		p := paths.NewProjectPaths(dir)
+		assert.FileExists(env.T, p.ArduinoCliConfig)
+		assert.FileExists(env.T, path.Join(dir, "blink", "blink.ino"))
+		assert.FileExists(env.T, path.Join(dir, ".gitignore"))
+
+		config, err := util.ReadArdiConfig(p.ArdiConfig)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "1.8.6", config.Platforms["arduino:avr"])
+		assert.Equal(env.T, "arduino:avr:uno", config.Builds["blink"].FQBN)
+	})
+
+	testutil.RunIntegrationTest("creates project from template directory", t, func(env *testutil.IntegrationTestEnv) {
+		src := env.T.TempDir()
+		err := os.WriteFile(path.Join(src, "ardi.json"), []byte(`{"builds": {"demo": {"sketch": "demo/demo.ino"}}}`), 0644)
+		assert.NoError(env.T, err)
+
+		dir := path.Join(env.T.TempDir(), "demo")
+		err = env.Execute([]string{"new", dir, "--template", src, "--no-install"})
+		assert.NoError(env.T, err)
+
+		config, err := util.ReadArdiConfig(paths.NewProjectPaths(dir).ArdiConfig)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, config.Builds, "demo")
+	})
+
+	testutil.RunIntegrationTest("errors if directory is not empty", t, func(env *testutil.IntegrationTestEnv) {
+		dir := env.T.TempDir()
+		err := os.WriteFile(path.Join(dir, "notes.txt"), []byte("notes"), 0644)
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"new", dir, "--no-install"})
+		assert.ErrorContains(env.T, err, "is not empty")
+		assert.NoFileExists(env.T, path.Join(dir, "ardi.json"))
+	})
+
+	testutil.RunIntegrationTest("errors for unknown template", t, func(env *testutil.IntegrationTestEnv) {
+		dir := path.Join(env.T.TempDir(), "noop")
+
+		err := env.Execute([]string{"new", dir, "--template", "noop"})
+		assert.ErrorContains(env.T, err, "unknown template noop")
+		assert.NoDirExists(env.T, dir)
+	})
+
+	testutil.RunMockIntegrationTest("installs template dependencies", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := path.Join(env.T.TempDir(), "blinky")
+
+		installPlatReq := &rpc.PlatformInstallRequest{
+			Instance:        instance,
+			PlatformPackage: "arduino",
+			Architecture:    "avr",
+			Version:         "1.8.6",
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().UpdateIndex(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		env.ArduinoCli.EXPECT().PlatformInstall(gomock.Any(), installPlatReq, gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().GetPlatforms(gomock.Any())
+
+		err := env.Execute([]string{"new", dir})
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, paths.NewProjectPaths(dir).Root, env.ArdiCore.Paths.Root)
+	})
+}
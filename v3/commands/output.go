@@ -0,0 +1,292 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/robgonnella/ardi/v3/schema"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// tableRenderer writes human readable output for a command
+type tableRenderer = func(w *tabwriter.Writer)
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s (expected table, json, or yaml)", format)
+	}
+}
+
+// render writes data to the command's output in the format requested via
+// the global --output flag
+func render(cmd *cobra.Command, env *CommandEnv, data interface{}, table tableRenderer) error {
+	out := cmd.OutOrStdout()
+
+	switch env.Output {
+	case outputJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case outputYAML:
+		b, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(b)
+		return err
+	case outputTable, "":
+		w := tabwriter.NewWriter(out, 0, 0, 8, ' ', 0)
+		table(w)
+		return w.Flush()
+	default:
+		return validateOutputFormat(env.Output)
+	}
+}
+
+func writeRow(w io.Writer, cols ...string) {
+	fmt.Fprintln(w, strings.Join(cols, "\t"))
+}
+
+func platformTable(platforms []types.Platform, versionHeader string, version func(types.Platform) string) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Platform", "ID", versionHeader)
+		for _, p := range platforms {
+			writeRow(w, p.Name, p.ID, version(p))
+		}
+	}
+}
+
+func projectPlatformsTable(platforms types.ProjectPlatforms) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Platforms specified in ardi.json")
+		writeRow(w, "Platform", "Version")
+		for _, p := range util.SortedKeys(platforms.Config) {
+			writeRow(w, p, platforms.Config[p])
+		}
+		writeRow(w)
+		writeRow(w, "Installed platforms")
+		installed := platformTable(platforms.Installed, "Installed", func(p types.Platform) string {
+			return p.Installed
+		})
+		installed(w)
+	}
+}
+
+func projectLibrariesTable(libraries types.ProjectLibraries) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Libraries specified in ardi.json")
+		writeRow(w, "Library", "Version")
+		for _, l := range util.SortedKeys(libraries.Config) {
+			writeRow(w, l, libraries.Config[l])
+		}
+		writeRow(w)
+		writeRow(w, "Installed libraries")
+		writeRow(w, "Library", "Version", "Description")
+		for _, l := range libraries.Installed {
+			writeRow(w, l.Name, l.Version, l.Description)
+		}
+	}
+}
+
+func searchedLibrariesTable(libraries []types.SearchedLibrary) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Library", "Latest", "Other Releases")
+		for _, lib := range libraries {
+			releases := []string{}
+			if len(lib.Releases) > 1 {
+				releases = lib.Releases[1:]
+			}
+			if len(releases) > 4 {
+				releases = append(releases[:4:4], "...")
+			}
+			writeRow(w, lib.Name, lib.Latest, strings.Join(releases, ", "))
+		}
+	}
+}
+
+// boardsTable renders boards with an optional extra column
+func sketchIncludesTable(includes []types.SketchInclude) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Include", "Library", "Version")
+		for _, i := range includes {
+			library := i.Library
+			if library == "" {
+				library = "(not found)"
+			}
+			writeRow(w, i.Include, library, i.Version)
+		}
+	}
+}
+
+func importIssuesTable(issues []types.ImportIssue) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Section", "Key", "Value", "Reason")
+		for _, i := range issues {
+			value := strings.ReplaceAll(i.Value, "\n", ", ")
+			writeRow(w, i.Section, i.Key, value, i.Reason)
+		}
+	}
+}
+
+func migrationTable(changes []types.MigrationChange) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Target", "Kind", "Change")
+		for _, c := range changes {
+			writeRow(w, c.Target, c.Kind, c.Change)
+		}
+	}
+}
+
+func configErrorsTable(results []*schema.ConfigError) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "File", "Line", "Column", "Path", "Problem")
+		for _, r := range results {
+			if len(r.Errors) == 0 {
+				writeRow(w, r.File, "", "", "", "valid")
+			}
+			for _, e := range r.Errors {
+				writeRow(w, r.File, fmt.Sprintf("%d", e.Line), fmt.Sprintf("%d", e.Column), e.Path, e.Message)
+			}
+		}
+	}
+}
+
+// settingTable prints only the value so it can be used in scripts, lists
+// and objects are printed as indented JSON
+func settingTable(setting types.Setting) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		switch value := setting.Value.(type) {
+		case map[string]interface{}, []interface{}:
+			data, _ := json.MarshalIndent(value, "", "  ")
+			fmt.Fprintln(w, string(data))
+		case nil:
+			fmt.Fprintln(w, "null")
+		default:
+			fmt.Fprintln(w, value)
+		}
+	}
+}
+
+func doctorTable(checks []types.DoctorCheck) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Check", "Status", "Details", "Fix")
+		for _, c := range checks {
+			writeRow(w, c.Check, c.Status, c.Message, c.Fix)
+		}
+	}
+}
+
+func boardsTable(boards []types.Board, header string, column func(types.Board) string) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		if column == nil {
+			writeRow(w, "Board", "FQBN")
+		} else {
+			writeRow(w, "Board", "FQBN", header)
+		}
+		for _, b := range boards {
+			if column == nil {
+				writeRow(w, b.Name, b.FQBN)
+			} else {
+				writeRow(w, b.Name, b.FQBN, column(b))
+			}
+		}
+	}
+}
+
+func boardOptionsTable(options []types.BoardOption) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Option", "Value", "Label")
+		for _, o := range options {
+			writeRow(w, o.Option, "", o.Label)
+			for _, v := range o.Values {
+				label := v.Label
+				if v.Default {
+					label += " (default)"
+				}
+				writeRow(w, "", v.Value, label)
+			}
+		}
+	}
+}
+
+func buildsTable(builds map[string]types.ArdiBuild) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		names := []string{}
+		for name := range builds {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b := builds[name]
+			writeRow(w, name+":")
+			if b.Extends != "" {
+				writeRow(w, "  Extends:", b.Extends)
+			}
+			writeRow(w, "  Directory:", b.Directory)
+			writeRow(w, "  Sketch:", b.Sketch)
+			writeRow(w, "  Baud:", fmt.Sprintf("%d", b.Baud))
+			writeRow(w, "  FQBN:", b.FQBN)
+			writeRow(w, "  Props:")
+			for _, prop := range util.SortedKeys(b.Props) {
+				writeRow(w, "    "+prop+":", b.Props[prop])
+			}
+			if len(b.BoardOptions) > 0 {
+				writeRow(w, "  BoardOptions:")
+				for _, option := range util.SortedKeys(b.BoardOptions) {
+					writeRow(w, "    "+option+":", b.BoardOptions[option])
+				}
+			}
+			if b.VersionInjected() {
+				writeRow(w, "  InjectVersion:", "true")
+			}
+			if len(b.PreBuild) > 0 {
+				writeRow(w, "  PreBuild:", strings.Join(b.PreBuild, "; "))
+			}
+			if len(b.PostBuild) > 0 {
+				writeRow(w, "  PostBuild:", strings.Join(b.PostBuild, "; "))
+			}
+			writeRow(w)
+		}
+	}
+}
+
+func scriptsTable(scripts map[string]string) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Script", "Command")
+		for _, name := range util.SortedKeys(scripts) {
+			writeRow(w, name, scripts[name])
+		}
+	}
+}
+
+func boardURLsTable(urls []string) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "Board URLS")
+		for _, url := range urls {
+			writeRow(w, url)
+		}
+	}
+}
+
+func versionTable(info types.VersionInfo) tableRenderer {
+	return func(w *tabwriter.Writer) {
+		writeRow(w, "ardi:", "v"+info.Ardi)
+		writeRow(w, "arduino-cli:", info.ArduinoCli)
+	}
+}
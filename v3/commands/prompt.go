@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/util"
+	"golang.org/x/term"
+)
+
+// maxSearchResults maximum number of matches listed for a search prompt
+const maxSearchResults = 15
+
+// prompter reads answers to interactive prompts
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	return &prompter{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// ask prompts for a value returning def if the answer is blank
+func (p *prompter) ask(label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", label)
+	}
+
+	answer, err := p.in.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if err != nil && !(errors.Is(err, io.EOF) && answer != "") {
+		return "", fmt.Errorf("no answer for %s: %w", strings.ToLower(label), err)
+	}
+
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// askInt prompts for a number returning def if the answer is blank
+func (p *prompter) askInt(label string, def int) (int, error) {
+	for {
+		answer, err := p.ask(label, strconv.Itoa(def))
+		if err != nil {
+			return 0, err
+		}
+		value, err := strconv.Atoi(answer)
+		if err == nil {
+			return value, nil
+		}
+		fmt.Fprintf(p.out, "%s is not a number\n", answer)
+	}
+}
+
+// choose prompts to pick one of items by number returning its index
+func (p *prompter) choose(label string, items []string, def int) (int, error) {
+	fmt.Fprintf(p.out, "%s:\n", label)
+	for i, item := range items {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, item)
+	}
+
+	for {
+		answer, err := p.ask("Choose", strconv.Itoa(def+1))
+		if err != nil {
+			return 0, err
+		}
+		if idx, err := strconv.Atoi(answer); err == nil && idx > 0 && idx <= len(items) {
+			return idx - 1, nil
+		}
+		fmt.Fprintf(p.out, "%s is not between 1 and %d\n", answer, len(items))
+	}
+}
+
+// search prompts for a query, lists the items fuzzy matching it, and returns
+// the index of the chosen item. Answering with anything other than a listed
+// number starts a new search.
+func (p *prompter) search(label string, items []string) (int, error) {
+	query, err := p.ask(label+" (type to search)", "")
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		matches := fuzzyFilter(query, items)
+		if len(matches) == 0 {
+			fmt.Fprintf(p.out, "No matches for %q\n", query)
+		}
+		for i, idx := range matches {
+			fmt.Fprintf(p.out, "  %d) %s\n", i+1, items[idx])
+		}
+
+		answer, err := p.ask("Choose by number or search again", "")
+		if err != nil {
+			return 0, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n > 0 && n <= len(matches) {
+			return matches[n-1], nil
+		}
+		query = answer
+	}
+}
+
+// private helpers
+// fuzzyFilter returns the indices of items matching query, best match first
+func fuzzyFilter(query string, items []string) []int {
+	type match struct {
+		idx   int
+		score int
+	}
+
+	matches := []match{}
+	for i, item := range items {
+		if score, ok := util.FuzzyMatch(query, item); ok {
+			matches = append(matches, match{idx: i, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score < matches[j].score
+	})
+
+	if len(matches) > maxSearchResults {
+		matches = matches[:maxSearchResults]
+	}
+
+	indices := []int{}
+	for _, m := range matches {
+		indices = append(indices, m.idx)
+	}
+	return indices
+}
+
+// isTerminal returns whether r is an interactive terminal
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
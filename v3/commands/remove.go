@@ -10,7 +10,7 @@ func newRemovePlatformCmd(env *CommandEnv) *cobra.Command {
 		Aliases: []string{"platform"},
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 			for _, p := range args {
@@ -39,7 +39,7 @@ func newRemoveBuildCmd(env *CommandEnv) *cobra.Command {
 		Aliases: []string{"build"},
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 			for _, b := range args {
@@ -61,7 +61,7 @@ func newRemoveLibCmd(env *CommandEnv) *cobra.Command {
 		Aliases: []string{"libs", "lib", "library"},
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 			for _, l := range args {
@@ -89,7 +89,7 @@ func newRemoveBoardURLCmd(env *CommandEnv) *cobra.Command {
 		Aliases: []string{"board-url"},
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 			for _, url := range args {
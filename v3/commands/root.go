@@ -1,26 +1,49 @@
 package commands
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path"
 	"strings"
 
 	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
 	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/types"
 	"github.com/robgonnella/ardi/v3/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+// ArdiCoreFactory creates the ArdiCore used by commands once the project
+// directory has been resolved
+type ArdiCoreFactory = func(opts core.NewArdiCoreOpts) *core.ArdiCore
+
 // CommandEnv environment for all commands
 type CommandEnv struct {
-	Logger   *log.Logger
-	Verbose  bool
-	Quiet    bool
-	ArdiCore *core.ArdiCore
-	MockCli  cli.Cli
+	Logger      *log.Logger
+	Verbose     bool
+	Quiet       bool
+	Output      string
+	LogFormat   string
+	LogFile     string
+	ProjectDir  string
+	NewArdiCore ArdiCoreFactory
+	ArdiCore    *core.ArdiCore
+	Workspace   *core.Workspace
+	MockCli     cli.Cli
+	// scripts being run by ardi run in this and parent commands
+	scripts []string
 }
 
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
 type ardiLogFormatter struct {
 	log.TextFormatter
 }
@@ -35,18 +58,59 @@ func (a *ardiLogFormatter) Format(e *log.Entry) ([]byte, error) {
 	return []byte(str), nil
 }
 
-func setLogger(env *CommandEnv) {
-	env.Logger.SetFormatter(&ardiLogFormatter{
-		TextFormatter: log.TextFormatter{
-			DisableTimestamp:       true,
-			DisableLevelTruncation: true,
-			PadLevelText:           true,
-		},
-	})
+// logFileHook writes every log entry to a file in addition to the logger's
+// regular output
+type logFileHook struct {
+	file      *os.File
+	formatter log.Formatter
+}
+
+func (h *logFileHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *logFileHook) Fire(e *log.Entry) error {
+	b, err := h.formatter.Format(e)
+	if err != nil {
+		return err
+	}
+	_, err = h.file.Write(b)
+	return err
+}
+
+func validateLogFormat(format string) error {
+	switch format {
+	case logFormatText, logFormatJSON, "":
+		return nil
+	default:
+		return fmt.Errorf("unsupported log format: %s (expected text or json)", format)
+	}
+}
+
+func setLogger(env *CommandEnv) error {
+	if err := validateLogFormat(env.LogFormat); err != nil {
+		return err
+	}
+
+	if env.LogFormat == logFormatJSON {
+		env.Logger.SetFormatter(&log.JSONFormatter{})
+	} else {
+		env.Logger.SetFormatter(&ardiLogFormatter{
+			TextFormatter: log.TextFormatter{
+				DisableTimestamp:       true,
+				DisableLevelTruncation: true,
+				PadLevelText:           true,
+			},
+		})
+	}
+
+	if err := setLogFile(env); err != nil {
+		return err
+	}
 
 	if env.Verbose {
 		env.Logger.SetLevel(log.DebugLevel)
-		return
+		return nil
 	}
 
 	if env.Quiet {
@@ -56,10 +120,189 @@ func setLogger(env *CommandEnv) {
 	}
 
 	log.SetOutput(ioutil.Discard)
+	return nil
 }
 
-func requireProjectInit() error {
-	if !util.IsProjectDirectory() {
+func setLogFile(env *CommandEnv) error {
+	for _, hooks := range env.Logger.Hooks {
+		for _, h := range hooks {
+			if fileHook, ok := h.(*logFileHook); ok {
+				fileHook.file.Close()
+			}
+		}
+	}
+	env.Logger.ReplaceHooks(make(log.LevelHooks))
+
+	if env.LogFile == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(env.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	var formatter log.Formatter = &log.TextFormatter{
+		DisableColors: true,
+		FullTimestamp: true,
+	}
+	if env.LogFormat == logFormatJSON {
+		formatter = &log.JSONFormatter{}
+	}
+
+	env.Logger.AddHook(&logFileHook{file: file, formatter: formatter})
+	return nil
+}
+
+// standalonePreRun replaces the root pre-run for commands that must work
+// without a loadable project. Logging and the output format are set up but
+// project settings files aren't synced.
+func standalonePreRun(env *CommandEnv) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := setLogger(env); err != nil {
+			return err
+		}
+		return validateOutputFormat(env.Output)
+	}
+}
+
+// problemsFound returns an error for commands whose output is a report of
+// problems. Usage is silenced as it would only add noise to the report.
+func problemsFound(cmd *cobra.Command, format string, args ...interface{}) error {
+	cmd.SilenceUsage = true
+	return fmt.Errorf(format, args...)
+}
+
+// initArdiCore resolves the project directory, syncs project settings files,
+// and creates the ArdiCore for the command. When the project is within a
+// workspace an ArdiCore is also created for every workspace member.
+func initArdiCore(cmd *cobra.Command, env *CommandEnv) error {
+	return loadArdiCore(cmd, env, true)
+}
+
+// loadArdiCore creates the ArdiCore for the command, and those of workspace
+// members, syncing project settings files when sync is set. Without sync no
+// files are written and defaults are used for missing settings files.
+func loadArdiCore(cmd *cobra.Command, env *CommandEnv, sync bool) error {
+	if env.NewArdiCore == nil {
+		return nil
+	}
+
+	projectPaths, err := paths.ResolveProjectPaths(env.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	ctx := commandContext(cmd)
+
+	workspacePaths, workspaceConfig, err := findWorkspace(projectPaths.Root)
+	if err != nil {
+		return err
+	}
+
+	if workspaceConfig != nil {
+		env.Workspace = core.NewWorkspace(workspacePaths, env.Logger)
+		for _, member := range workspaceConfig.Members {
+			name := path.Clean(member)
+			memberPaths := workspacePaths.MemberPaths(name)
+			memberCore, err := loadProjectCore(ctx, env, memberPaths, sync)
+			if err != nil {
+				return err
+			}
+			env.Workspace.AddMember(name, memberCore)
+			if memberPaths.Root == projectPaths.Root {
+				projectPaths = memberPaths
+			}
+		}
+	}
+
+	env.ArdiCore, err = loadProjectCore(ctx, env, projectPaths, sync)
+	return err
+}
+
+func newProjectCore(ctx context.Context, env *CommandEnv, projectPaths paths.ProjectPaths) (*core.ArdiCore, error) {
+	return loadProjectCore(ctx, env, projectPaths, true)
+}
+
+func loadProjectCore(ctx context.Context, env *CommandEnv, projectPaths paths.ProjectPaths, sync bool) (*core.ArdiCore, error) {
+	ardiConfig, cliSettings, err := util.GetAllSettings(projectPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	if sync && util.IsProjectDirectory(projectPaths) {
+		if err := util.WriteAllSettings(projectPaths, ardiConfig, cliSettings); err != nil {
+			return nil, fmt.Errorf("failed to write settings files: %w", err)
+		}
+	}
+
+	return env.NewArdiCore(core.NewArdiCoreOpts{
+		Ctx:                ctx,
+		Logger:             env.Logger,
+		Paths:              projectPaths,
+		ArdiConfig:         *ardiConfig,
+		ArduinoCliSettings: *cliSettings,
+	}), nil
+}
+
+// findWorkspace returns the paths and config of the workspace enclosing dir.
+// A nil config is returned if dir is not within a workspace.
+func findWorkspace(dir string) (paths.WorkspacePaths, *types.ArdiWorkspace, error) {
+	root, err := paths.FindWorkspaceRoot(dir)
+	if errors.Is(err, paths.ErrWorkspaceNotFound) {
+		return paths.WorkspacePaths{}, nil, nil
+	}
+	if err != nil {
+		return paths.WorkspacePaths{}, nil, err
+	}
+
+	workspacePaths := paths.NewWorkspacePaths(root)
+	config, err := util.ReadWorkspaceConfig(workspacePaths.WorkspaceConfig)
+	if err != nil {
+		return paths.WorkspacePaths{}, nil, fmt.Errorf("failed to read workspace config: %w", err)
+	}
+
+	return workspacePaths, config, nil
+}
+
+// memberPaths returns the workspace member paths for a project if it is
+// listed as a member of an enclosing workspace, otherwise the project paths
+// are returned unchanged
+func memberPaths(projectPaths paths.ProjectPaths) (paths.ProjectPaths, error) {
+	workspacePaths, workspaceConfig, err := findWorkspace(projectPaths.Root)
+	if err != nil || workspaceConfig == nil {
+		return projectPaths, err
+	}
+
+	name, err := workspacePaths.MemberName(projectPaths.Root)
+	if err != nil {
+		return projectPaths, err
+	}
+
+	for _, member := range workspaceConfig.Members {
+		if path.Clean(member) == name {
+			return workspacePaths.MemberPaths(name), nil
+		}
+	}
+
+	return projectPaths, nil
+}
+
+// workspaceMode returns whether commands should operate on all workspace
+// members, which is the case when run within a workspace but outside of any
+// member project
+func (env *CommandEnv) workspaceMode() bool {
+	return env.Workspace != nil && !util.IsProjectDirectory(env.ArdiCore.Paths)
+}
+
+// structuredLogs returns whether command output should be captured as
+// structured log entries rather than written directly to the terminal
+func (env *CommandEnv) structuredLogs() bool {
+	return env.LogFormat == logFormatJSON || env.LogFile != ""
+}
+
+func requireProjectInit(env *CommandEnv) error {
+	if !util.IsProjectDirectory(env.ArdiCore.Paths) {
 		return errors.New("not an ardi project directory, run 'ardi init' first")
 	}
 	return nil
@@ -74,28 +317,53 @@ func newRootCommand(env *CommandEnv) *cobra.Command {
 			"- Compile & upload sketches to connected boards\n- Watch log output from connected boards in terminal\n" +
 			"- Auto recompile / reupload on save",
 		DisableAutoGenTag: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := setLogger(env); err != nil {
+				return err
+			}
+			if err := validateOutputFormat(env.Output); err != nil {
+				return err
+			}
+			return initArdiCore(cmd, env)
+		},
 	}
 
 	rootCmd.PersistentFlags().BoolVarP(&env.Verbose, "verbose", "v", false, "Print all logs")
 	rootCmd.PersistentFlags().BoolVarP(&env.Quiet, "quiet", "q", false, "Silence all logs")
+	rootCmd.PersistentFlags().StringVarP(&env.Output, "output", "o", outputTable, "Output format for list, search, and version commands (table|json|yaml)")
+	rootCmd.PersistentFlags().StringVar(&env.LogFormat, "log-format", logFormatText, "Log format (text|json)")
+	rootCmd.PersistentFlags().StringVarP(&env.ProjectDir, "project-dir", "C", "", "Run as if ardi was started in this project directory (env: "+paths.ProjectDirEnv+")")
+	rootCmd.PersistentFlags().StringVar(&env.LogFile, "log-file", "", "Also write logs to the specified file")
 	rootCmd.SetHelpFunc(Help)
 	return rootCmd
 }
 
 // NewRootCmd adds all ardi commands to root and returns root command
 func NewRootCmd(env *CommandEnv) *cobra.Command {
+	// configure defaults for output produced before flags are parsed,
+	// flags are applied again in the root PersistentPreRunE
 	setLogger(env)
 	rootCmd := newRootCommand(env)
 	rootCmd.AddCommand(
 		newAddCmd(env),
+		newBoardCmd(env),
 		newCleanCmd(env),
+		newConfigCmd(env),
 		newBuildCmd(env),
+		newDoctorCmd(env),
 		newExecCmd(env),
+		newExportCmd(env),
+		newImportCmd(env),
 		newInstallCmd(env),
 		newListCmd(env),
+		newMigrateCmd(env),
+		newProjectNewCmd(env),
 		newProjectInitCmd(env),
 		newRemoveCmd(env),
+		newRunCmd(env),
 		newSearchCmd(env),
+		newTestCmd(env),
+		newUICmd(env),
 		newVersionCmd(env),
 	)
 	return rootCmd
@@ -0,0 +1,126 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectDiscovery(t *testing.T) {
+	buildName := "blink"
+	fqbn := testutil.ArduinoMegaFQBN()
+
+	listBuilds := func(env *testutil.MockIntegrationTestEnv, args ...string) map[string]types.ArdiBuild {
+		env.ClearStdout()
+		err := env.Execute(append([]string{"list", "builds", "--output", "json"}, args...))
+		assert.NoError(env.T, err)
+
+		builds := map[string]types.ArdiBuild{}
+		err = json.Unmarshal(env.Stdout.Bytes(), &builds)
+		assert.NoError(env.T, err)
+		return builds
+	}
+
+	testutil.RunMockIntegrationTest("uses project specified with --project-dir", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+
+		err := env.Execute([]string{"init", "-C", dir})
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"add", "build", "-C", dir, "-n", buildName, "-f", fqbn, "-s", testutil.BlinkProjectDir()})
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, dir, env.ArdiCore.Paths.Root)
+
+		builds := listBuilds(env, "-C", dir)
+		assert.Contains(env.T, builds, buildName)
+
+		err = env.Execute([]string{"list", "builds"})
+		assert.Error(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("finds project root from subdirectory", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := env.T.TempDir()
+		subDir := path.Join(dir, "sketches", "blink")
+		assert.NoError(env.T, os.MkdirAll(subDir, 0755))
+
+		err := env.Execute([]string{"init", "-C", dir})
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"add", "build", "-C", dir, "-n", buildName, "-f", fqbn, "-s", testutil.BlinkProjectDir()})
+		assert.NoError(env.T, err)
+
+		cwd, _ := os.Getwd()
+		assert.NoError(env.T, os.Chdir(subDir))
+		defer os.Chdir(cwd)
+
+		builds := listBuilds(env)
+		assert.Contains(env.T, builds, buildName)
+		assert.Equal(env.T, dir, env.ArdiCore.Paths.Root)
+	})
+}
+
+// writeWorkspace creates a workspace in root with a member project for each
+// entry in members
+func writeWorkspace(t *testing.T, root string, members map[string]types.ArdiConfig) {
+	workspace := types.ArdiWorkspace{Members: []string{}}
+
+	for name, config := range members {
+		workspace.Members = append(workspace.Members, name)
+		memberDir := path.Join(root, name)
+		assert.NoError(t, os.MkdirAll(memberDir, 0755))
+
+		data, err := json.Marshal(config)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(path.Join(memberDir, "ardi.json"), data, 0644))
+	}
+
+	data, err := json.Marshal(workspace)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path.Join(root, "ardi-workspace.json"), data, 0644))
+}
+
+func TestWorkspaceDiscovery(t *testing.T) {
+	testutil.RunMockIntegrationTest("members share workspace data directory", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := env.T.TempDir()
+		writeWorkspace(env.T, root, map[string]types.ArdiConfig{
+			"firmware/sensor": *util.GenArdiConfig(),
+		})
+
+		err := env.Execute([]string{"list", "builds", "-C", path.Join(root, "firmware", "sensor")})
+		assert.NoError(env.T, err)
+
+		assert.Equal(env.T, path.Join(root, ".ardi"), env.ArdiCore.Paths.ArduinoCliDataDir)
+		assert.FileExists(env.T, path.Join(root, ".ardi", "arduino-cli.yaml"))
+		assert.NoDirExists(env.T, path.Join(root, "firmware", "sensor", ".ardi"))
+	})
+
+	testutil.RunMockIntegrationTest("initializes new member in workspace data directory", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := env.T.TempDir()
+		err := os.WriteFile(path.Join(root, "ardi-workspace.json"), []byte(`{"members": ["gateway"]}`), 0644)
+		assert.NoError(env.T, err)
+		memberDir := path.Join(root, "gateway")
+		assert.NoError(env.T, os.MkdirAll(memberDir, 0755))
+
+		err = env.Execute([]string{"init", "-C", memberDir})
+		assert.NoError(env.T, err)
+
+		assert.FileExists(env.T, path.Join(memberDir, "ardi.json"))
+		assert.FileExists(env.T, path.Join(root, ".ardi", "arduino-cli.yaml"))
+		assert.NoDirExists(env.T, path.Join(memberDir, ".ardi"))
+	})
+
+	testutil.RunMockIntegrationTest("errors on malformed workspace file", t, func(env *testutil.MockIntegrationTestEnv) {
+		root := env.T.TempDir()
+		err := os.WriteFile(path.Join(root, "ardi-workspace.json"), []byte("noop"), 0644)
+		assert.NoError(env.T, err)
+
+		err = env.Execute([]string{"list", "builds", "-C", root})
+		assert.Error(env.T, err)
+	})
+}
@@ -0,0 +1,165 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// runScript runs the named script from ardi.json along with its pre and
+// post scripts if defined
+func runScript(cmd *cobra.Command, env *CommandEnv, name string) error {
+	scripts := env.ArdiCore.Config.GetScripts()
+	if _, ok := scripts[name]; !ok {
+		return fmt.Errorf("no script named %s in ardi.json", name)
+	}
+
+	if util.ArrayContains(env.scripts, name) {
+		chain := strings.Join(append(env.scripts, name), " -> ")
+		return fmt.Errorf("script cycle detected: %s", chain)
+	}
+
+	for _, stage := range []string{"pre" + name, name, "post" + name} {
+		script, ok := scripts[stage]
+		if !ok {
+			continue
+		}
+		if err := execScript(cmd, env, name, stage, script); err != nil {
+			return fmt.Errorf("script %s failed: %w", stage, err)
+		}
+	}
+
+	return nil
+}
+
+// execScript runs each step of a script. Steps starting with "ardi" are run
+// in-process through a new root command and can't use shell operators,
+// consecutive shell steps are run together with sh. The ardi steps of every
+// stage of a script record the script's name, so a pre or post script that
+// runs the script itself is detected as a cycle.
+func execScript(cmd *cobra.Command, env *CommandEnv, name, stage, script string) error {
+	steps, err := util.SplitScript(script)
+	if err != nil {
+		return err
+	}
+
+	shellSteps := []string{}
+	flushShell := func() error {
+		if len(shellSteps) == 0 {
+			return nil
+		}
+		command := strings.Join(shellSteps, " && ")
+		shellSteps = []string{}
+		env.Logger.WithField("script", stage).Infof("> %s", command)
+		return util.RunShell(commandContext(cmd), util.ShellOpts{
+			Command: command,
+			Dir:     env.ArdiCore.Paths.Root,
+			Env:     append(env.ArdiCore.Paths.Env(), "ARDI_SCRIPT="+stage),
+			Stdout:  cmd.OutOrStdout(),
+			Stderr:  cmd.ErrOrStderr(),
+		})
+	}
+
+	// steps are checked before any are run
+	stepArgs := make([][]string, len(steps))
+	for i, step := range steps {
+		args, err := util.SplitArgs(step)
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 && args[0] == "ardi" {
+			if op := util.ShellOperator(step); op != "" {
+				return fmt.Errorf("%s in step %q is not supported, ardi steps run in-process and can only be joined with &&", op, step)
+			}
+		}
+		stepArgs[i] = args
+	}
+
+	for i, step := range steps {
+		args := stepArgs[i]
+		if len(args) == 0 || args[0] != "ardi" {
+			shellSteps = append(shellSteps, step)
+			continue
+		}
+
+		if err := flushShell(); err != nil {
+			return err
+		}
+
+		env.Logger.WithField("script", stage).Infof("> %s", step)
+		if err := runArdi(cmd, env, name, args[1:]); err != nil {
+			return err
+		}
+	}
+
+	return flushShell()
+}
+
+// runArdi runs an ardi command in-process for the project. Global flags
+// passed to the current command are passed along.
+func runArdi(cmd *cobra.Command, env *CommandEnv, script string, args []string) error {
+	child := &CommandEnv{
+		Logger:      env.Logger,
+		NewArdiCore: env.NewArdiCore,
+		MockCli:     env.MockCli,
+		scripts:     append(append([]string{}, env.scripts...), script),
+	}
+
+	flags := []string{"--project-dir=" + env.ArdiCore.Paths.Root}
+	cmd.Root().PersistentFlags().Visit(func(f *pflag.Flag) {
+		if f.Name != "project-dir" {
+			flags = append(flags, fmt.Sprintf("--%s=%s", f.Name, f.Value.String()))
+		}
+	})
+
+	rootCmd := NewRootCmd(child)
+	rootCmd.SetOut(cmd.OutOrStdout())
+	rootCmd.SetErr(cmd.ErrOrStderr())
+	rootCmd.SetArgs(append(flags, args...))
+	// errors are reported by the parent command
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	return rootCmd.ExecuteContext(commandContext(cmd))
+}
+
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+func newRunCmd(env *CommandEnv) *cobra.Command {
+	runCmd := &cobra.Command{
+		Use:   "run [script]",
+		Short: "Run scripts defined in ardi.json",
+		Long: "\nRun scripts defined in the scripts section of ardi.json. Steps " +
+			"are separated by &&. Steps starting with \"ardi\" run in-process " +
+			"and can't use shell operators such as |, ||, or ;. All other " +
+			"steps run with sh in the project directory with ARDI_PROJECT_DIR, " +
+			"ARDI_CONFIG, ARDI_DATA_DIR, ARDI_CLI_CONFIG, and ARDI_SCRIPT " +
+			"exported. Scripts named pre<script> and post<script> " +
+			"run before and after <script>. Lists available scripts if no " +
+			"script is specified.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				scripts := env.ArdiCore.Config.GetScripts()
+				if scripts == nil {
+					scripts = map[string]string{}
+				}
+				return render(cmd, env, scripts, scriptsTable(scripts))
+			}
+			return runScript(cmd, env, args[0])
+		},
+	}
+	return runCmd
+}
@@ -0,0 +1,136 @@
+package commands_test
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	setup := func(env *testutil.MockIntegrationTestEnv, scripts map[string]string) string {
+		dir := env.T.TempDir()
+		config := util.GenArdiConfig()
+		config.Scripts = scripts
+		data, err := json.Marshal(config)
+		assert.NoError(env.T, err)
+		assert.NoError(env.T, os.WriteFile(path.Join(dir, "ardi.json"), data, 0644))
+		return dir
+	}
+
+	testutil.RunMockIntegrationTest("lists available scripts", t, func(env *testutil.MockIntegrationTestEnv) {
+		scripts := map[string]string{
+			"release": "ardi build release && ./size.sh",
+			"size":    "./size.sh",
+		}
+		dir := setup(env, scripts)
+
+		env.ClearStdout()
+		err := env.Execute([]string{"run", "-C", dir, "--output", "json"})
+		assert.NoError(env.T, err)
+
+		listed := map[string]string{}
+		err = json.Unmarshal(env.Stdout.Bytes(), &listed)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, scripts, listed)
+	})
+
+	testutil.RunMockIntegrationTest("runs shell script with project environment", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{
+			"env": "echo \"$ARDI_SCRIPT $ARDI_PROJECT_DIR\" > out.txt",
+		})
+
+		err := env.Execute([]string{"run", "env", "-C", dir})
+		assert.NoError(env.T, err)
+
+		out, err := os.ReadFile(path.Join(dir, "out.txt"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "env "+dir+"\n", string(out))
+	})
+
+	testutil.RunMockIntegrationTest("runs pre and post scripts", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{
+			"prebuild":  "echo pre >> out.txt",
+			"build":     "echo main >> out.txt",
+			"postbuild": "echo post >> out.txt",
+		})
+
+		err := env.Execute([]string{"run", "build", "-C", dir})
+		assert.NoError(env.T, err)
+
+		out, err := os.ReadFile(path.Join(dir, "out.txt"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "pre\nmain\npost\n", string(out))
+	})
+
+	testutil.RunMockIntegrationTest("runs ardi commands in-process", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{
+			"setup": "ardi add build -n blink -f " + testutil.ArduinoMegaFQBN() + " -s " + testutil.BlinkProjectDir() + " && echo done > out.txt",
+		})
+
+		err := env.Execute([]string{"run", "setup", "-C", dir})
+		assert.NoError(env.T, err)
+
+		config, err := util.ReadArdiConfig(path.Join(dir, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, config.Builds, "blink")
+		assert.FileExists(env.T, path.Join(dir, "out.txt"))
+	})
+
+	testutil.RunMockIntegrationTest("returns error naming failed script", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{
+			"prebuild": "exit 1",
+			"build":    "echo main > out.txt",
+		})
+
+		err := env.Execute([]string{"run", "build", "-C", dir})
+		assert.Error(env.T, err)
+		assert.Contains(env.T, err.Error(), "script prebuild failed")
+		assert.NoFileExists(env.T, path.Join(dir, "out.txt"))
+	})
+
+	testutil.RunMockIntegrationTest("detects script cycles", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{
+			"a": "ardi run b",
+			"b": "ardi run a",
+		})
+
+		err := env.Execute([]string{"run", "a", "-C", dir})
+		assert.Error(env.T, err)
+		assert.Contains(env.T, err.Error(), "script cycle detected: a -> b -> a")
+	})
+
+	testutil.RunMockIntegrationTest("rejects shell operators in ardi steps", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{
+			"build": "echo start > out.txt && ardi build release | tee build.log",
+		})
+
+		err := env.Execute([]string{"run", "build", "-C", dir})
+		assert.ErrorContains(env.T, err, `| in step "ardi build release | tee build.log" is not supported`)
+		assert.NoFileExists(env.T, path.Join(dir, "out.txt"))
+	})
+
+	testutil.RunMockIntegrationTest("detects scripts run by their own pre and post scripts", t, func(env *testutil.MockIntegrationTestEnv) {
+		for _, stage := range []string{"prebuild", "postbuild"} {
+			dir := setup(env, map[string]string{
+				"build": "echo main >> out.txt",
+				stage:   "ardi run build",
+			})
+
+			err := env.Execute([]string{"run", "build", "-C", dir})
+			assert.Error(env.T, err)
+			assert.Contains(env.T, err.Error(), "script cycle detected: build -> build")
+		}
+	})
+
+	testutil.RunMockIntegrationTest("errors on unknown script", t, func(env *testutil.MockIntegrationTestEnv) {
+		dir := setup(env, map[string]string{})
+
+		err := env.Execute([]string{"run", "noop", "-C", dir})
+		assert.Error(env.T, err)
+	})
+}
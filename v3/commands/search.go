@@ -1,6 +1,9 @@
 package commands
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/spf13/cobra"
+)
 
 func newSearchPlatformCmd(env *CommandEnv) *cobra.Command {
 	searchCmd := &cobra.Command{
@@ -9,14 +12,17 @@ func newSearchPlatformCmd(env *CommandEnv) *cobra.Command {
 		Short:   "Search all available platforms",
 		Aliases: []string{"platform"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
-			env.Logger.Info("Available platforms")
-			if err := env.ArdiCore.Platform.ListAll(); err != nil {
+			platforms, err := env.ArdiCore.Platform.ListAll()
+			if err != nil {
 				return err
 			}
-			return nil
+			table := platformTable(platforms, "Latest", func(p types.Platform) string {
+				return p.Latest
+			})
+			return render(cmd, env, platforms, table)
 		},
 	}
 	return searchCmd
@@ -29,17 +35,18 @@ func newSearchLibCmd(env *CommandEnv) *cobra.Command {
 		Short:   "Searches for availables libraries with optional search filter",
 		Aliases: []string{"lib", "libs", "library"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := requireProjectInit(); err != nil {
+			if err := requireProjectInit(env); err != nil {
 				return err
 			}
 			searchArg := ""
 			if len(args) > 0 {
 				searchArg = args[0]
 			}
-			if err := env.ArdiCore.Lib.Search(searchArg); err != nil {
+			libraries, err := env.ArdiCore.Lib.Search(searchArg)
+			if err != nil {
 				return err
 			}
-			return nil
+			return render(cmd, env, libraries, searchedLibrariesTable(libraries))
 		},
 	}
 	return searchCmd
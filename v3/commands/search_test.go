@@ -1,12 +1,14 @@
 package commands_test
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	"github.com/golang/mock/gomock"
 	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -146,6 +148,40 @@ func TestSearchPlatformCommand(t *testing.T) {
 		assert.Contains(env.T, env.Stdout.String(), platform)
 	})
 
+	testutil.RunMockIntegrationTest("searches platforms with json output", t, func(env *testutil.MockIntegrationTestEnv) {
+		env.RunProjectInit()
+
+		searchReq := &rpc.PlatformSearchRequest{
+			Instance:    instance,
+			AllVersions: false,
+		}
+
+		searchResp := &rpc.PlatformSearchResponse{
+			SearchOutput: []*rpc.Platform{
+				{
+					Id:     "some:platform",
+					Name:   "Some Platform",
+					Latest: "1.0.0",
+				},
+			},
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance)
+		env.ArduinoCli.EXPECT().UpdateIndex(gomock.Any(), indexReq, gomock.Any()).MaxTimes(2)
+		env.ArduinoCli.EXPECT().PlatformSearch(searchReq).Return(searchResp, nil)
+
+		env.ClearStdout()
+		args := []string{"search", "platforms", "--output", "json"}
+		err := env.Execute(args)
+		assert.NoError(env.T, err)
+
+		var platforms []types.Platform
+		err = json.Unmarshal(env.Stdout.Bytes(), &platforms)
+		assert.NoError(env.T, err)
+		expected := []types.Platform{{ID: "some:platform", Name: "Some Platform", Latest: "1.0.0"}}
+		assert.Equal(env.T, expected, platforms)
+	})
+
 	testutil.RunMockIntegrationTest("return platform search error", t, func(env *testutil.MockIntegrationTestEnv) {
 		env.RunProjectInit()
 		searchReq := &rpc.PlatformSearchRequest{
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/spf13/cobra"
+)
+
+func newTestNativeCmd(env *CommandEnv) *cobra.Command {
+	var sources []string
+	var tests []string
+	var compiler string
+	var flags []string
+	var format string
+	var reportFile string
+
+	testCmd := &cobra.Command{
+		Use:   "native [sketch]",
+		Short: "Run sketch unit tests natively with the host compiler",
+		Long: "\nCompiles sketch sources and test files with the host compiler " +
+			"against a minimal Arduino API shim, runs the resulting binary, and " +
+			"reports results as TAP or JUnit. By default all .c/.cpp files in the " +
+			"sketch's \"src\" directory are compiled with all test files in the " +
+			"sketch's \"test\" directory. Tests are written with the bundled " +
+			"ArdiTest.h harness.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+
+			if format != "tap" && format != "junit" {
+				return fmt.Errorf("unsupported report format: %s", format)
+			}
+
+			sketch := "."
+			if len(args) > 0 {
+				sketch = args[0]
+			}
+
+			opts := core.NativeTestOpts{
+				Sketch:   sketch,
+				Sources:  sources,
+				Tests:    tests,
+				Compiler: compiler,
+				Flags:    flags,
+			}
+
+			report, err := env.ArdiCore.NativeTest.Run(opts)
+			if err != nil {
+				return err
+			}
+
+			var out io.Writer = cmd.OutOrStdout()
+			if reportFile != "" {
+				f, err := os.Create(reportFile)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+
+			if format == "junit" {
+				err = report.WriteJUnit(out)
+			} else {
+				err = report.WriteTAP(out)
+			}
+			if err != nil {
+				return err
+			}
+
+			if failed := report.Failed(); failed > 0 {
+				return fmt.Errorf("%d of %d native tests failed", failed, len(report.Results))
+			}
+
+			env.Logger.Infof("All %d native tests passed", len(report.Results))
+			return nil
+		},
+	}
+
+	testCmd.Flags().StringArrayVarP(&sources, "source", "s", []string{}, "Source file to compile (defaults to all files in sketch src directory)")
+	testCmd.Flags().StringArrayVarP(&tests, "test", "t", []string{}, "Test file to compile (defaults to all files in sketch test directory)")
+	testCmd.Flags().StringVarP(&compiler, "compiler", "c", "g++", "Host C++ compiler")
+	testCmd.Flags().StringArrayVar(&flags, "flag", []string{}, "Additional flag to pass to the host compiler")
+	testCmd.Flags().StringVarP(&format, "format", "f", "tap", "Report format (tap|junit)")
+	testCmd.Flags().StringVarP(&reportFile, "report", "r", "", "Write report to file instead of stdout")
+
+	return testCmd
+}
+
+func newTestCmd(env *CommandEnv) *cobra.Command {
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run sketch unit tests",
+		Long:  "\nRun sketch unit tests",
+	}
+	testCmd.AddCommand(newTestNativeCmd(env))
+	return testCmd
+}
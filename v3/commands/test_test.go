@@ -0,0 +1,51 @@
+package commands_test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestNativeCommand(t *testing.T) {
+	testutil.RunMockIntegrationTest("errors if project not initialized", t, func(env *testutil.MockIntegrationTestEnv) {
+		args := []string{"test", "native", testutil.NativeProjectDir()}
+		err := env.Execute(args)
+		assert.Error(env.T, err)
+	})
+
+	testutil.RunMockIntegrationTest("errors on unsupported format", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		args := []string{"test", "native", testutil.NativeProjectDir(), "--format", "xml"}
+		err = env.Execute(args)
+		assert.Error(env.T, err)
+	})
+
+	if _, err := exec.LookPath("g++"); err != nil {
+		t.Skip("g++ not available")
+	}
+
+	testutil.RunMockIntegrationTest("prints tap report", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		args := []string{"test", "native", testutil.NativeProjectDir()}
+		err = env.Execute(args)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "TAP version 13")
+		assert.Contains(env.T, env.Stdout.String(), "ok 2 - formats_label")
+	})
+
+	testutil.RunMockIntegrationTest("prints junit report", t, func(env *testutil.MockIntegrationTestEnv) {
+		err := env.RunProjectInit()
+		assert.NoError(env.T, err)
+
+		args := []string{"test", "native", testutil.NativeProjectDir(), "--format", "junit"}
+		err = env.Execute(args)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), `<testsuite name="ardi-native" tests="3" failures="0"`)
+	})
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"github.com/robgonnella/ardi/v3/ui"
+	"github.com/spf13/cobra"
+)
+
+func newUICmd(env *CommandEnv) *cobra.Command {
+	uiCmd := &cobra.Command{
+		Use:   "ui",
+		Short: "Interactive terminal dashboard",
+		Long: "\nOpens an interactive terminal dashboard listing the builds in " +
+			"ardi.json with their last status, project platforms and libraries " +
+			"with outdated markers, and a live log pane. Use the arrow keys to " +
+			"select a build, then \"b\" to build, \"u\" to upload, \"m\" to " +
+			"monitor serial output, \"s\" to stop monitoring, \"r\" to refresh, " +
+			"and \"q\" to quit.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireProjectInit(env); err != nil {
+				return err
+			}
+
+			project := ui.NewCoreProject(env.ArdiCore)
+			dashboard := ui.NewDashboard(project, env.Logger)
+
+			out := env.Logger.Out
+			env.Logger.SetOutput(dashboard.Log())
+			defer env.Logger.SetOutput(out)
+
+			return ui.Run(dashboard)
+		},
+	}
+
+	return uiCmd
+}
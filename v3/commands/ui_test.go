@@ -0,0 +1,16 @@
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUICommand(t *testing.T) {
+	testutil.RunMockIntegrationTest("errors if project not initialized", t, func(env *testutil.MockIntegrationTestEnv) {
+		args := []string{"ui"}
+		err := env.Execute(args)
+		assert.Error(env.T, err)
+	})
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"github.com/robgonnella/ardi/v3/types"
 	"github.com/robgonnella/ardi/v3/version"
 
 	"github.com/spf13/cobra"
@@ -11,13 +12,12 @@ func newVersionCmd(env *CommandEnv) *cobra.Command {
 		Use:   "version",
 		Long:  "\nPrints current version of ardi",
 		Short: "Prints current version of ardi",
-		Run: func(cmd *cobra.Command, args []string) {
-			ardiVersion := version.VERSION
-			arduinoCliVersion := env.ArdiCore.Cli.ClientVersion()
-			env.Logger.Infoln("")
-			env.Logger.Infof("ardi: v%s", ardiVersion)
-			env.Logger.Infof("arduino-cli: %s", arduinoCliVersion)
-			env.Logger.Infoln("")
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := types.VersionInfo{
+				Ardi:       version.VERSION,
+				ArduinoCli: env.ArdiCore.Cli.ClientVersion(),
+			}
+			return render(cmd, env, info, versionTable(info))
 		},
 	}
 }
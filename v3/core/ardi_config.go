@@ -3,35 +3,56 @@ package core
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/schema"
 	"github.com/robgonnella/ardi/v3/types"
 	"github.com/robgonnella/ardi/v3/util"
 	log "github.com/sirupsen/logrus"
 )
 
+// extraFlagsProp build property used to pass additional compiler flags
+const extraFlagsProp = "build.extra_flags"
+
 // ArdiConfig represents core module for ardi.json manipulation
 type ArdiConfig struct {
-	config   types.ArdiConfig
-	confPath string
-	logger   *log.Logger
-	mux      sync.Mutex
+	config       types.ArdiConfig
+	confPath     string
+	root         string
+	varOverrides map[string]string
+	// validateBoard checks the board options of an fqbn against those the
+	// board offers, set when the config is part of an ArdiCore
+	validateBoard func(fqbn string) error
+	logger        *log.Logger
+	mux           sync.Mutex
+	// saved config as last read from or written to disk, used to merge
+	// changes made to ardi.json by other processes
+	saved json.RawMessage
 }
 
 // NewArdiConfig returns core json module for handling ardi.json config
 func NewArdiConfig(confPath string, initialConfig types.ArdiConfig, logger *log.Logger) *ArdiConfig {
-	return &ArdiConfig{
+	saved, _ := json.Marshal(initialConfig)
+	a := &ArdiConfig{
 		config:   initialConfig,
 		confPath: confPath,
+		root:     filepath.Dir(confPath),
 		logger:   logger,
 		mux:      sync.Mutex{},
+		saved:    saved,
 	}
+	a.warnAllLiteralSecrets()
+	return a
 }
 
 // AddBuild to ardi.json
-func (a *ArdiConfig) AddBuild(name, sketch, fqbn string, baud int, buildProps []string) error {
+func (a *ArdiConfig) AddBuild(name, sketch, fqbn string, baud int, buildProps []string, boardOptions map[string]string) error {
 	project, err := util.ProcessSketch(sketch)
 	if err != nil {
 		return err
@@ -43,8 +64,8 @@ func (a *ArdiConfig) AddBuild(name, sketch, fqbn string, baud int, buildProps []
 	}
 
 	newBuild := types.ArdiBuild{
-		Directory: project.Directory,
-		Sketch:    project.Sketch,
+		Directory: a.projectRelative(project.Directory),
+		Sketch:    a.projectRelative(project.Sketch),
 		Baud:      project.Baud,
 		FQBN:      fqbn,
 	}
@@ -52,26 +73,66 @@ func (a *ArdiConfig) AddBuild(name, sketch, fqbn string, baud int, buildProps []
 	props := util.GeneratePropsMap(buildProps)
 	newBuild.Props = props
 
+	if len(boardOptions) > 0 {
+		newBuild.BoardOptions = boardOptions
+	}
+
+	secrets, err := a.loadSecrets()
+	if err != nil {
+		return err
+	}
+	a.warnLiteralSecrets(name, newBuild, secrets)
+
 	a.logger.Infof("Addding build: %s", name)
-	a.printBuild(name, newBuild)
+	a.printBuild(name, redactBuild(newBuild, secrets))
 	a.config.Builds[name] = newBuild
 	return a.write()
 }
 
 // GetCompileOpts returns appropriate compile options for an ardi build
 func (a *ArdiConfig) GetCompileOpts(buildName string) (*cli.CompileOpts, error) {
-	build, ok := a.config.Builds[buildName]
-	if !ok {
-		return nil, fmt.Errorf("no builds found for %s", buildName)
+	build, err := a.ResolveBuild(buildName)
+	if err != nil {
+		return nil, err
 	}
 
-	buildProps := util.GeneratePropsArray(build.Props)
+	secrets, err := a.loadSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	build, err = a.interpolateBuild(build, secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpolate build %s: %w", buildName, err)
+	}
+
+	sketchDir := a.resolvePath(build.Directory)
+
+	props := build.Props
+	if build.VersionInjected() {
+		defines, err := versionDefines(buildName, sketchDir)
+		if err != nil {
+			return nil, err
+		}
+		props = util.AppendBuildProp(props, extraFlagsProp, defines...)
+	}
+
+	buildProps := util.GeneratePropsArray(props)
 
 	compileOpts := &cli.CompileOpts{
-		FQBN:       build.FQBN,
-		SketchDir:  build.Directory,
-		SketchPath: build.Sketch,
+		FQBN:       util.FQBNWithOptions(build.FQBN, build.BoardOptions),
+		SketchDir:  sketchDir,
+		SketchPath: a.resolvePath(build.Sketch),
 		BuildProps: buildProps,
+		BuildName:  buildName,
+		ProjectDir: a.root,
+		PreBuild:   build.PreBuild,
+		PostBuild:  build.PostBuild,
+		Secrets:    secrets.Values(),
+	}
+
+	if len(build.BoardOptions) > 0 {
+		compileOpts.BoardOptions = build.BoardOptions
 	}
 
 	return compileOpts, nil
@@ -83,27 +144,108 @@ func (a *ArdiConfig) RemoveBuild(build string) error {
 	return a.write()
 }
 
-// ListBuilds lists build specifications in ardi.json
-func (a *ArdiConfig) ListBuilds(builds []string) {
-	if len(a.GetBuilds()) == 0 {
-		return
+// ListBuilds returns the named build specifications in ardi.json or all
+// builds if no names are specified. Secret values are redacted.
+func (a *ArdiConfig) ListBuilds(builds []string) map[string]types.ArdiBuild {
+	secrets, err := a.loadSecrets()
+	if err != nil {
+		a.logger.WithError(err).Warn("secret values will not be redacted")
+		secrets = &Secrets{}
 	}
 
-	a.logger.Println("")
+	if len(builds) == 0 {
+		for name := range a.config.Builds {
+			builds = append(builds, name)
+		}
+	}
 
-	if len(builds) > 0 {
-		for _, name := range builds {
-			b, ok := a.config.Builds[name]
-			if ok {
-				a.printBuild(name, b)
-			}
+	found := make(map[string]types.ArdiBuild)
+	for _, name := range builds {
+		if b, ok := a.config.Builds[name]; ok {
+			found[name] = redactBuild(b, secrets)
 		}
-		return
+	}
+	return found
+}
+
+// ListResolvedBuilds returns the named builds, or all builds if no names are
+// specified, with the builds they extend merged in. Secret values are
+// redacted.
+func (a *ArdiConfig) ListResolvedBuilds(builds []string) (map[string]types.ArdiBuild, error) {
+	listed := a.ListBuilds(builds)
+
+	secrets, err := a.loadSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]types.ArdiBuild)
+	for name := range listed {
+		build, err := a.ResolveBuild(name)
+		if err != nil {
+			return nil, err
+		}
+		resolved[name] = redactBuild(build, secrets)
+	}
+	return resolved, nil
+}
+
+// ResolveBuild returns the named build merged over the chain of builds it
+// extends. Fields set on a build take precedence over those it extends and
+// props are merged individually.
+func (a *ArdiConfig) ResolveBuild(name string) (types.ArdiBuild, error) {
+	build, ok := a.config.Builds[name]
+	if !ok {
+		return types.ArdiBuild{}, fmt.Errorf("no builds found for %s", name)
+	}
+
+	chain := []string{name}
+	for build.Extends != "" {
+		parentName := build.Extends
+		if util.ArrayContains(chain, parentName) {
+			chain = append(chain, parentName)
+			return types.ArdiBuild{}, fmt.Errorf("build inheritance cycle detected: %s", strings.Join(chain, " -> "))
+		}
+		parent, ok := a.config.Builds[parentName]
+		if !ok {
+			return types.ArdiBuild{}, fmt.Errorf("build %s extends unknown build %s", chain[len(chain)-1], parentName)
+		}
+		chain = append(chain, parentName)
+		build = mergeBuild(parent, build)
+	}
+
+	return build, nil
+}
+
+// InterpolatedBuild returns the named build with inherited fields merged and
+// variable references resolved, as used when compiling it
+func (a *ArdiConfig) InterpolatedBuild(name string) (types.ArdiBuild, error) {
+	build, err := a.ResolveBuild(name)
+	if err != nil {
+		return types.ArdiBuild{}, err
 	}
 
-	for name, build := range a.config.Builds {
-		a.printBuild(name, build)
+	secrets, err := a.loadSecrets()
+	if err != nil {
+		return types.ArdiBuild{}, err
+	}
+
+	build, err = a.interpolateBuild(build, secrets)
+	if err != nil {
+		return types.ArdiBuild{}, fmt.Errorf("failed to interpolate build %s: %w", name, err)
 	}
+
+	return build, nil
+}
+
+// BuildSketchPath returns the resolved and interpolated path of the named
+// build's sketch
+func (a *ArdiConfig) BuildSketchPath(name string) (string, error) {
+	build, err := a.InterpolatedBuild(name)
+	if err != nil {
+		return "", err
+	}
+	return a.resolvePath(build.Sketch), nil
 }
 
 // GetBuilds returns builds specified in config
@@ -123,15 +265,6 @@ func (a *ArdiConfig) RemoveLibrary(name string) error {
 	return a.write()
 }
 
-// ListLibraries lists installed libraries
-func (a *ArdiConfig) ListLibraries() {
-	a.logger.Println("")
-	for library, version := range a.config.Libraries {
-		a.logger.Printf("%s: %s\n", library, version)
-	}
-	a.logger.Println("")
-}
-
 // GetLibraries returns libraries specired in config
 func (a *ArdiConfig) GetLibraries() map[string]string {
 	return a.config.Libraries
@@ -149,15 +282,6 @@ func (a *ArdiConfig) RemovePlatform(platform string) error {
 	return a.write()
 }
 
-// ListPlatforms lists all board urls in config
-func (a *ArdiConfig) ListPlatforms() {
-	a.logger.Println("")
-	for platform, vers := range a.config.Platforms {
-		a.logger.Infof("%s: %s", platform, vers)
-	}
-	a.logger.Println("")
-}
-
 // GetPlatforms returns platforms specified in config
 func (a *ArdiConfig) GetPlatforms() map[string]string {
 	return a.config.Platforms
@@ -190,36 +314,278 @@ func (a *ArdiConfig) RemoveBoardURL(url string) error {
 	return nil
 }
 
-// ListBoardURLS lists all board urls in config
-func (a *ArdiConfig) ListBoardURLS() {
-	a.logger.Info("Board URLS")
-	for _, url := range a.config.BoardURLS {
-		a.logger.Info(url)
-	}
-}
-
 // GetBoardURLS returns board urls specified in config
 func (a *ArdiConfig) GetBoardURLS() []string {
 	return a.config.BoardURLS
 }
 
+// GetVars returns vars specified in config
+func (a *ArdiConfig) GetVars() map[string]string {
+	return a.config.Vars
+}
+
+// OverrideVars sets var values that take precedence over the vars specified
+// in config when interpolating builds
+func (a *ArdiConfig) OverrideVars(vars map[string]string) {
+	a.varOverrides = vars
+}
+
+// GetScripts returns scripts specified in config
+func (a *ArdiConfig) GetScripts() map[string]string {
+	return a.config.Scripts
+}
+
+// HasSetting returns whether the dotted path addresses a field of ardi.json
+func (a *ArdiConfig) HasSetting(path string) bool {
+	return util.HasSetting(&a.config, "json", path)
+}
+
+// GetSetting returns the value at the dotted path in ardi.json
+func (a *ArdiConfig) GetSetting(path string) (interface{}, error) {
+	return util.GetSetting(&a.config, "json", path)
+}
+
+// SetSetting sets the value at the dotted path in ardi.json. The change is
+// rejected if the resulting config doesn't pass schema validation, leaves a
+// build that can't be resolved, or changes a build's fqbn to a malformed one
+// or one with board options the board doesn't offer.
+func (a *ArdiConfig) SetSetting(path, value string) error {
+	// settings are set on a copy so a rejected change leaves config untouched
+	var config types.ArdiConfig
+	data, err := json.Marshal(a.config)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+
+	if err := util.SetSetting(&config, "json", path, value); err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if errs := schema.ValidateArdiConfig(data); len(errs) > 0 {
+		problems := []string{}
+		for _, e := range errs {
+			problems = append(problems, fmt.Sprintf("%s: %s", e.Path, e.Message))
+		}
+		return fmt.Errorf("invalid value for %s: %s", path, strings.Join(problems, ", "))
+	}
+
+	previous := a.config
+	a.config = config
+	for name := range config.Builds {
+		if _, err := a.ResolveBuild(name); err != nil {
+			a.config = previous
+			return fmt.Errorf("invalid value for %s: %w", path, err)
+		}
+	}
+	if err := a.validateChangedBoards(previous); err != nil {
+		a.config = previous
+		return fmt.Errorf("invalid value for %s: %w", path, err)
+	}
+
+	a.logger.Infof("Setting %s to %s", path, value)
+	return a.write()
+}
+
+// write merges the changes made since ardi.json was last read or written
+// into the current file and atomically updates it while holding a lock so
+// concurrent ardi processes don't lose each other's updates. The file's
+// formatting and comments are preserved.
 func (a *ArdiConfig) write() error {
 	a.mux.Lock()
 	defer a.mux.Unlock()
 
-	newData, err := json.MarshalIndent(a.config, "", "\t")
+	config, err := util.UpdateArdiConfig(a.confPath, func(disk *types.ArdiConfig) (*types.ArdiConfig, error) {
+		merged := a.config
+		if disk == nil {
+			return &merged, nil
+		}
+		if err := util.MergeChanges(a.saved, a.config, disk, &merged); err != nil {
+			return nil, err
+		}
+		return &merged, nil
+	})
 	if err != nil {
 		return err
 	}
 
-	if err := ioutil.WriteFile(a.confPath, newData, 0644); err != nil {
+	saved, err := json.Marshal(config)
+	if err != nil {
 		return err
 	}
 
+	a.config = *config
+	a.saved = saved
+
 	return nil
 }
 
 // private
+// projectRelative converts a path, absolute or relative to the working
+// directory, into a path relative to the project root. Absolute paths
+// outside the project are kept absolute.
+func (a *ArdiConfig) projectRelative(p string) string {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	rel, err := filepath.Rel(a.root, abs)
+	if err != nil {
+		return abs
+	}
+	outside := rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	if filepath.IsAbs(p) && outside {
+		return abs
+	}
+	return rel
+}
+
+// validateChangedBoards validates the fqbn, including board options, of
+// every build whose fqbn differs from the one it had in previous. Builds
+// whose values can't be interpolated yet are validated when compiled.
+func (a *ArdiConfig) validateChangedBoards(previous types.ArdiConfig) error {
+	fqbns := func() map[string]string {
+		result := make(map[string]string)
+		for name := range a.config.Builds {
+			if build, err := a.InterpolatedBuild(name); err == nil {
+				result[name] = util.FQBNWithOptions(build.FQBN, build.BoardOptions)
+			}
+		}
+		return result
+	}
+
+	current := a.config
+	after := fqbns()
+	a.config = previous
+	before := fqbns()
+	a.config = current
+
+	names := []string{}
+	for name, fqbn := range after {
+		if existing, ok := before[name]; fqbn != "" && (!ok || existing != fqbn) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fqbn := after[name]
+		if base, _, _ := util.SplitFQBN(fqbn); len(strings.Split(base, ":")) != 3 || strings.Contains(base+":", "::") {
+			return fmt.Errorf("build %s: invalid fqbn %s, expected vendor:arch:board", name, fqbn)
+		}
+		if a.validateBoard == nil {
+			continue
+		}
+		if err := a.validateBoard(fqbn); err != nil {
+			return fmt.Errorf("build %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// loadSecrets loads the secrets stored alongside ardi.json
+func (a *ArdiConfig) loadSecrets() (*Secrets, error) {
+	return LoadSecrets(paths.NewProjectPaths(a.root))
+}
+
+// warnAllLiteralSecrets warns about literal secrets in the props of every
+// build once when the config is loaded rather than on every compile
+func (a *ArdiConfig) warnAllLiteralSecrets() {
+	secrets, err := a.loadSecrets()
+	if err != nil {
+		// reported when the secrets are needed
+		return
+	}
+	for _, name := range sortedBuildNames(a.config.Builds) {
+		a.warnLiteralSecrets(name, a.config.Builds[name], secrets)
+	}
+}
+
+// warnLiteralSecrets warns about build props that appear to contain secret
+// values committed directly to ardi.json
+func (a *ArdiConfig) warnLiteralSecrets(name string, build types.ArdiBuild, secrets *Secrets) {
+	for _, prop := range util.SortedKeys(build.Props) {
+		value := build.Props[prop]
+		literals := LiteralSecrets(value)
+		if len(literals) == 0 && secrets.Redact(value) != value {
+			literals = append(literals, prop)
+		}
+		for _, literal := range literals {
+			a.logger.WithField("build", name).Warnf(
+				"%s looks like a secret committed to ardi.json, move it to "+
+					"ardi.secrets.json or .env and reference it with ${secret:NAME}",
+				literal,
+			)
+		}
+	}
+}
+
+// interpolator returns an Interpolator for the project's vars and secrets
+func (a *ArdiConfig) interpolator(secrets *Secrets) *Interpolator {
+	vars := make(map[string]string)
+	for k, v := range a.config.Vars {
+		vars[k] = v
+	}
+	for k, v := range a.varOverrides {
+		vars[k] = v
+	}
+	return NewInterpolator(a.root, vars).WithResolver("secret", secrets.Lookup)
+}
+
+// interpolateBuild returns a copy of build with variable references in its
+// sketch paths, fqbn, props, and board options resolved
+func (a *ArdiConfig) interpolateBuild(build types.ArdiBuild, secrets *Secrets) (types.ArdiBuild, error) {
+	interpolator := a.interpolator(secrets)
+
+	fields := []*string{&build.Directory, &build.Sketch, &build.FQBN}
+	for _, field := range fields {
+		value, err := interpolator.Interpolate(*field)
+		if err != nil {
+			return build, err
+		}
+		*field = value
+	}
+
+	props := make(map[string]string)
+	for prop, value := range build.Props {
+		interpolated, err := interpolator.Interpolate(value)
+		if err != nil {
+			return build, err
+		}
+		props[prop] = interpolated
+	}
+	build.Props = props
+
+	if len(build.BoardOptions) > 0 {
+		options := make(map[string]string)
+		for option, value := range build.BoardOptions {
+			interpolated, err := interpolator.Interpolate(value)
+			if err != nil {
+				return build, err
+			}
+			options[option] = interpolated
+		}
+		build.BoardOptions = options
+	}
+
+	return build, nil
+}
+
+// resolvePath resolves a path stored in ardi.json against the project root
+func (a *ArdiConfig) resolvePath(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(a.root, p)
+}
+
 func (a *ArdiConfig) printBuild(name string, b types.ArdiBuild) {
 	a.logger.Println("")
 	a.logger.Printf("%s:\n", name)
@@ -228,8 +594,110 @@ func (a *ArdiConfig) printBuild(name string, b types.ArdiBuild) {
 	a.logger.Printf("  Baud: %d\n", b.Baud)
 	a.logger.Printf("  FQBN: %s\n", b.FQBN)
 	a.logger.Printf("  Props:\n")
-	for prop, instruction := range b.Props {
-		a.logger.Printf("    %s: %s\n", prop, instruction)
+	for _, prop := range util.SortedKeys(b.Props) {
+		a.logger.Printf("    %s: %s\n", prop, b.Props[prop])
+	}
+	if len(b.BoardOptions) > 0 {
+		a.logger.Printf("  BoardOptions:\n")
+		for _, option := range util.SortedKeys(b.BoardOptions) {
+			a.logger.Printf("    %s: %s\n", option, b.BoardOptions[option])
+		}
 	}
 	a.logger.Println("")
 }
+
+// private helpers
+// mergeBuild returns child merged over parent, inheriting parent's extends
+func mergeBuild(parent, child types.ArdiBuild) types.ArdiBuild {
+	merged := parent
+
+	if child.Directory != "" {
+		merged.Directory = child.Directory
+	}
+	if child.Sketch != "" {
+		merged.Sketch = child.Sketch
+	}
+	if child.Baud != 0 {
+		merged.Baud = child.Baud
+	}
+	if child.FQBN != "" {
+		merged.FQBN = child.FQBN
+	}
+	if child.PreBuild != nil {
+		merged.PreBuild = child.PreBuild
+	}
+	if child.PostBuild != nil {
+		merged.PostBuild = child.PostBuild
+	}
+	if child.InjectVersion != nil {
+		merged.InjectVersion = child.InjectVersion
+	}
+
+	merged.Props = make(map[string]string)
+	for prop, value := range parent.Props {
+		merged.Props[prop] = value
+	}
+	for prop, value := range child.Props {
+		merged.Props[prop] = value
+	}
+
+	if len(parent.BoardOptions) > 0 || len(child.BoardOptions) > 0 {
+		merged.BoardOptions = make(map[string]string)
+		for option, value := range parent.BoardOptions {
+			merged.BoardOptions[option] = value
+		}
+		for option, value := range child.BoardOptions {
+			merged.BoardOptions[option] = value
+		}
+	}
+
+	return merged
+}
+
+// redactBuild returns a copy of build with secret values in its props
+// redacted
+func redactBuild(build types.ArdiBuild, secrets *Secrets) types.ArdiBuild {
+	props := make(map[string]string)
+	for prop, value := range build.Props {
+		props[prop] = secrets.Redact(value)
+	}
+	build.Props = props
+	return build
+}
+
+// versionDefines returns compiler defines describing the build version read
+// from the local git repository containing dir
+func versionDefines(buildName, dir string) ([]string, error) {
+	buildTime, err := util.BuildTime()
+	if err != nil {
+		return nil, err
+	}
+
+	info := util.ReadGitInfo(dir)
+
+	defines := []string{}
+	for _, define := range [][2]string{
+		{"ARDI_VERSION", info.Version},
+		{"ARDI_GIT_SHA", info.SHA},
+		{"ARDI_BUILD_TIME", buildTime.Format(time.RFC3339)},
+		{"ARDI_BUILD_NAME", buildName},
+	} {
+		d, err := stringDefine(define[0], define[1])
+		if err != nil {
+			return nil, err
+		}
+		defines = append(defines, d)
+	}
+
+	return defines, nil
+}
+
+// stringDefine returns a define of a string literal. arduino-cli passes the
+// define to the compiler without a shell, so the quotes aren't escaped and
+// values that would need escaping are rejected.
+func stringDefine(name, value string) (string, error) {
+	if strings.ContainsAny(value, " \t\"'\\") {
+		return "", fmt.Errorf("can't inject %s %q, it contains spaces, quotes or backslashes", name, value)
+	}
+	return fmt.Sprintf(`-D%s="%s"`, name, value), nil
+}
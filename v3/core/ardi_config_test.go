@@ -1,18 +1,23 @@
 package core_test
 
 import (
+	"encoding/json"
+	"os"
 	"path"
+	"path/filepath"
 	"testing"
 
 	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/core"
 	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
 	"github.com/robgonnella/ardi/v3/util"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestArdiConfigBuilds(t *testing.T) {
 	testutil.RunUnitTest("adds, lists, and removes builds", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		name1 := "somename"
 		dir1 := testutil.BlinkProjectDir()
 		name2 := "anothername"
@@ -20,10 +25,10 @@ func TestArdiConfigBuilds(t *testing.T) {
 		fqbn := "somefqbn"
 		buildProps := []string{"someprop=somevalue"}
 
-		err := env.ArdiCore.Config.AddBuild(name1, dir1, fqbn, 0, buildProps)
+		err := env.ArdiCore.Config.AddBuild(name1, dir1, fqbn, 0, buildProps, nil)
 		assert.NoError(env.T, err)
 
-		err = env.ArdiCore.Config.AddBuild(name2, dir2, fqbn, 0, buildProps)
+		err = env.ArdiCore.Config.AddBuild(name2, dir2, fqbn, 0, buildProps, nil)
 		assert.NoError(env.T, err)
 
 		builds := env.ArdiCore.Config.GetBuilds()
@@ -44,27 +49,15 @@ func TestArdiConfigBuilds(t *testing.T) {
 		assert.Contains(env.T, build.Props, "someprop")
 		assert.Equal(env.T, build.Props["someprop"], "somevalue")
 
-		env.ClearStdout()
-		env.ArdiCore.Config.ListBuilds([]string{})
-		out := env.Stdout.String()
-		assert.Contains(env.T, out, name1)
-		assert.Contains(env.T, out, name2)
-		assert.Contains(env.T, out, dir1)
-		assert.Contains(env.T, out, dir2)
-		assert.Contains(env.T, out, fqbn)
-		assert.Contains(env.T, out, "someprop")
-		assert.Contains(env.T, out, "somevalue")
+		listed := env.ArdiCore.Config.ListBuilds([]string{})
+		assert.Contains(env.T, listed, name1)
+		assert.Contains(env.T, listed, name2)
 
-		env.ClearStdout()
-		env.ArdiCore.Config.ListBuilds([]string{name1})
-		out = env.Stdout.String()
-		assert.Contains(env.T, out, name1)
-		assert.NotContains(env.T, out, name2)
-		assert.Contains(env.T, out, dir1)
-		assert.NotContains(env.T, out, dir2)
-		assert.Contains(env.T, out, fqbn)
-		assert.Contains(env.T, out, "someprop")
-		assert.Contains(env.T, out, "somevalue")
+		listed = env.ArdiCore.Config.ListBuilds([]string{name1})
+		assert.Contains(env.T, listed, name1)
+		assert.NotContains(env.T, listed, name2)
+		assert.Equal(env.T, dir1, listed[name1].Directory)
+		assert.Equal(env.T, "somevalue", listed[name1].Props["someprop"])
 
 		err = env.ArdiCore.Config.RemoveBuild(name2)
 		assert.NoError(env.T, err)
@@ -74,14 +67,14 @@ func TestArdiConfigBuilds(t *testing.T) {
 	})
 
 	testutil.RunUnitTest("overrides baud", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		name := "somename"
 		dir := testutil.Blink14400ProjectDir()
 		fqbn := "somefqbn"
 		buildProps := []string{}
 		baud := 9600
 
-		err := env.ArdiCore.Config.AddBuild(name, dir, fqbn, baud, buildProps)
+		err := env.ArdiCore.Config.AddBuild(name, dir, fqbn, baud, buildProps, nil)
 		assert.NoError(env.T, err)
 
 		builds := env.ArdiCore.Config.GetBuilds()
@@ -93,25 +86,25 @@ func TestArdiConfigBuilds(t *testing.T) {
 	})
 
 	testutil.RunUnitTest("errors if sketch not found", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		name := "somename"
 		dir := "noop"
 		fqbn := "somefqbn"
 		buildProps := []string{"someprop=somevalue"}
 
-		err := env.ArdiCore.Config.AddBuild(name, dir, fqbn, 0, buildProps)
+		err := env.ArdiCore.Config.AddBuild(name, dir, fqbn, 0, buildProps, nil)
 		assert.Error(env.T, err)
 	})
 
 	testutil.RunUnitTest("allows relative paths", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		name := "somename"
 		dir := "../test_projects/pixie"
 		fqbn := "somefqbn"
 		buildProps := []string{}
 		baud := 9600
 
-		err := env.ArdiCore.Config.AddBuild(name, dir, fqbn, baud, buildProps)
+		err := env.ArdiCore.Config.AddBuild(name, dir, fqbn, baud, buildProps, nil)
 		assert.NoError(env.T, err)
 
 		builds := env.ArdiCore.Config.GetBuilds()
@@ -125,7 +118,7 @@ func TestArdiConfigBuilds(t *testing.T) {
 
 func TestArdiConfigBoardURLS(t *testing.T) {
 	testutil.RunUnitTest("adds, lists, and removes board urls", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		url := "https://someboardurl.com"
 
 		err := env.ArdiCore.Config.AddBoardURL(url)
@@ -134,10 +127,6 @@ func TestArdiConfigBoardURLS(t *testing.T) {
 		urls := env.ArdiCore.Config.GetBoardURLS()
 		assert.Contains(env.T, urls, url)
 
-		env.ClearStdout()
-		env.ArdiCore.Config.ListBoardURLS()
-		assert.Contains(env.T, env.Stdout.String(), url)
-
 		err = env.ArdiCore.Config.RemoveBoardURL(url)
 		assert.NoError(env.T, err)
 		urls = env.ArdiCore.Config.GetBoardURLS()
@@ -147,7 +136,7 @@ func TestArdiConfigBoardURLS(t *testing.T) {
 
 func TestArdiConfigPlatform(t *testing.T) {
 	testutil.RunUnitTest("adds, lists, and removes platforms", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		platform := "someplatform"
 		vers := "1.4.3"
 
@@ -158,12 +147,6 @@ func TestArdiConfigPlatform(t *testing.T) {
 		assert.Contains(env.T, platforms, platform)
 		assert.Equal(env.T, platforms[platform], vers)
 
-		env.ClearStdout()
-		env.ArdiCore.Config.ListPlatforms()
-		out := env.Stdout.String()
-		assert.Contains(env.T, out, platform)
-		assert.Contains(env.T, out, vers)
-
 		err = env.ArdiCore.Config.RemovePlatform(platform)
 		assert.NoError(env.T, err)
 		platforms = env.ArdiCore.Config.GetPlatforms()
@@ -173,7 +156,7 @@ func TestArdiConfigPlatform(t *testing.T) {
 
 func TestArdiConfigLibraries(t *testing.T) {
 	testutil.RunUnitTest("adds, lists, and removes libraries", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		lib := "somelibrary"
 		vers := "1.2.3"
 
@@ -184,12 +167,6 @@ func TestArdiConfigLibraries(t *testing.T) {
 		assert.Contains(env.T, libraries, lib)
 		assert.Equal(env.T, libraries[lib], vers)
 
-		env.ClearStdout()
-		env.ArdiCore.Config.ListLibraries()
-		out := env.Stdout.String()
-		assert.Contains(env.T, out, lib)
-		assert.Contains(env.T, out, vers)
-
 		err = env.ArdiCore.Config.RemoveLibrary(lib)
 		assert.NoError(env.T, err)
 		libraries = env.ArdiCore.Config.GetLibraries()
@@ -199,7 +176,7 @@ func TestArdiConfigLibraries(t *testing.T) {
 
 func TestArdiConfigCompileOpts(t *testing.T) {
 	testutil.RunUnitTest("returns compile options for build", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		name := "somename"
 		dir := testutil.BlinkProjectDir()
 		fqbn := "somefqbn"
@@ -209,9 +186,11 @@ func TestArdiConfigCompileOpts(t *testing.T) {
 			SketchPath: path.Join(dir, "blink.ino"),
 			FQBN:       fqbn,
 			BuildProps: buildProps,
+			BuildName:  name,
+			ProjectDir: env.ArdiCore.Paths.Root,
 		}
 
-		err := env.ArdiCore.Config.AddBuild(name, dir, fqbn, 0, buildProps)
+		err := env.ArdiCore.Config.AddBuild(name, dir, fqbn, 0, buildProps, nil)
 		assert.NoError(env.T, err)
 
 		compileOpts, err := env.ArdiCore.Config.GetCompileOpts(name)
@@ -220,10 +199,392 @@ func TestArdiConfigCompileOpts(t *testing.T) {
 	})
 
 	testutil.RunUnitTest("errors if build not found", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 		buildName := "noop"
 		compileOpts, err := env.ArdiCore.Config.GetCompileOpts(buildName)
 		assert.Error(env.T, err)
 		assert.Nil(env.T, compileOpts)
 	})
 }
+
+func TestArdiConfigProjectRelativePaths(t *testing.T) {
+	testutil.RunUnitTest("stores relative sketch paths relative to project root", t, func(env *testutil.UnitTestEnv) {
+		root := env.T.TempDir()
+		config := core.NewArdiConfig(path.Join(root, "ardi.json"), *util.GenArdiConfig(), env.Logger)
+
+		blinkDir := testutil.BlinkProjectDir()
+		cwd, err := os.Getwd()
+		assert.NoError(env.T, err)
+		relFromCwd, err := filepath.Rel(cwd, blinkDir)
+		assert.NoError(env.T, err)
+
+		err = config.AddBuild("blink", relFromCwd, "some:fqbn", 0, []string{}, nil)
+		assert.NoError(env.T, err)
+
+		expectedDir, err := filepath.Rel(root, blinkDir)
+		assert.NoError(env.T, err)
+
+		build := config.GetBuilds()["blink"]
+		assert.Equal(env.T, expectedDir, build.Directory)
+		assert.Equal(env.T, path.Join(expectedDir, "blink.ino"), build.Sketch)
+
+		opts, err := config.GetCompileOpts("blink")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, blinkDir, opts.SketchDir)
+		assert.Equal(env.T, path.Join(blinkDir, "blink.ino"), opts.SketchPath)
+	})
+}
+
+func TestArdiConfigInjectVersion(t *testing.T) {
+	enabled := true
+
+	testutil.RunUnitTest("injects version defines merged with extra flags", t, func(env *testutil.UnitTestEnv) {
+		env.T.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+		sketchDir := testutil.BlinkProjectDir()
+		initial := util.GenArdiConfig()
+		initial.Builds["release"] = types.ArdiBuild{
+			Directory:     sketchDir,
+			Sketch:        path.Join(sketchDir, "blink.ino"),
+			FQBN:          "arduino:avr:mega",
+			Props:         map[string]string{"build.extra_flags": "-DUSER_FLAG", "other": "value"},
+			InjectVersion: &enabled,
+		}
+		config := core.NewArdiConfig(path.Join(env.T.TempDir(), "ardi.json"), *initial, env.Logger)
+
+		opts, err := config.GetCompileOpts("release")
+		assert.NoError(env.T, err)
+
+		info := util.ReadGitInfo(sketchDir)
+		expectedFlags := "build.extra_flags=-DUSER_FLAG" +
+			` -DARDI_VERSION="` + info.Version + `"` +
+			` -DARDI_GIT_SHA="` + info.SHA + `"` +
+			` -DARDI_BUILD_TIME="2023-11-14T22:13:20Z"` +
+			` -DARDI_BUILD_NAME="release"`
+		assert.ElementsMatch(env.T, []string{expectedFlags, "other=value"}, opts.BuildProps)
+
+		// stored props are left untouched
+		assert.Equal(env.T, "-DUSER_FLAG", config.GetBuilds()["release"].Props["build.extra_flags"])
+	})
+
+	testutil.RunUnitTest("does not inject version unless enabled", t, func(env *testutil.UnitTestEnv) {
+		sketchDir := testutil.BlinkProjectDir()
+		initial := util.GenArdiConfig()
+		initial.Builds["release"] = types.ArdiBuild{
+			Directory: sketchDir,
+			Sketch:    path.Join(sketchDir, "blink.ino"),
+			FQBN:      "arduino:avr:mega",
+			Props:     map[string]string{"build.extra_flags": "-DUSER_FLAG"},
+		}
+		config := core.NewArdiConfig(path.Join(env.T.TempDir(), "ardi.json"), *initial, env.Logger)
+
+		opts, err := config.GetCompileOpts("release")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []string{"build.extra_flags=-DUSER_FLAG"}, opts.BuildProps)
+	})
+
+	testutil.RunUnitTest("rejects build names that can't be injected", t, func(env *testutil.UnitTestEnv) {
+		sketchDir := testutil.BlinkProjectDir()
+		initial := util.GenArdiConfig()
+		initial.Builds["my release"] = types.ArdiBuild{
+			Directory:     sketchDir,
+			Sketch:        path.Join(sketchDir, "blink.ino"),
+			FQBN:          "arduino:avr:mega",
+			InjectVersion: &enabled,
+		}
+		config := core.NewArdiConfig(path.Join(env.T.TempDir(), "ardi.json"), *initial, env.Logger)
+
+		_, err := config.GetCompileOpts("my release")
+		assert.EqualError(env.T, err, `can't inject ARDI_BUILD_NAME "my release", it contains spaces, quotes or backslashes`)
+	})
+}
+
+func TestArdiConfigInterpolation(t *testing.T) {
+	newConfig := func(env *testutil.UnitTestEnv, build types.ArdiBuild) (*core.ArdiConfig, string) {
+		root := env.T.TempDir()
+		initial := util.GenArdiConfig()
+		initial.Vars = map[string]string{"region": "eu", "board": "mega"}
+		initial.Builds["release"] = build
+		return core.NewArdiConfig(path.Join(root, "ardi.json"), *initial, env.Logger), root
+	}
+
+	testutil.RunUnitTest("interpolates sketch paths, fqbn, and props", t, func(env *testutil.UnitTestEnv) {
+		env.T.Setenv("ARDI_TEST_SSID", "office")
+
+		config, root := newConfig(env, types.ArdiBuild{
+			Directory: "${project.dir}/blink",
+			Sketch:    "${project.dir}/blink/blink.ino",
+			FQBN:      "arduino:avr:${var:board}",
+			Props:     map[string]string{"build.extra_flags": "-DSSID=${env:ARDI_TEST_SSID} -DREGION=${var:region}"},
+		})
+
+		opts, err := config.GetCompileOpts("release")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, path.Join(root, "blink"), opts.SketchDir)
+		assert.Equal(env.T, path.Join(root, "blink", "blink.ino"), opts.SketchPath)
+		assert.Equal(env.T, "arduino:avr:mega", opts.FQBN)
+		assert.Equal(env.T, []string{"build.extra_flags=-DSSID=office -DREGION=eu"}, opts.BuildProps)
+
+		// stored build is left untouched
+		assert.Equal(env.T, "arduino:avr:${var:board}", config.GetBuilds()["release"].FQBN)
+	})
+
+	testutil.RunUnitTest("overrides vars", t, func(env *testutil.UnitTestEnv) {
+		config, _ := newConfig(env, types.ArdiBuild{
+			FQBN:  "arduino:avr:mega",
+			Props: map[string]string{"build.extra_flags": "-DREGION=${var:region}"},
+		})
+
+		config.OverrideVars(map[string]string{"region": "us"})
+		opts, err := config.GetCompileOpts("release")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []string{"build.extra_flags=-DREGION=us"}, opts.BuildProps)
+	})
+
+	testutil.RunUnitTest("errors on unresolved variable", t, func(env *testutil.UnitTestEnv) {
+		config, _ := newConfig(env, types.ArdiBuild{
+			FQBN:  "arduino:avr:mega",
+			Props: map[string]string{"build.extra_flags": "-DKEY=${var:apiKey}"},
+		})
+
+		opts, err := config.GetCompileOpts("release")
+		assert.Error(env.T, err)
+		assert.Contains(env.T, err.Error(), "unresolved variable ${var:apiKey}")
+		assert.Nil(env.T, opts)
+	})
+}
+
+func TestArdiConfigSecrets(t *testing.T) {
+	newConfig := func(env *testutil.UnitTestEnv, props map[string]string) (*core.ArdiConfig, string) {
+		root := env.T.TempDir()
+		initial := util.GenArdiConfig()
+		initial.Builds["release"] = types.ArdiBuild{FQBN: "arduino:avr:mega", Props: props}
+		return core.NewArdiConfig(path.Join(root, "ardi.json"), *initial, env.Logger), root
+	}
+
+	testutil.RunUnitTest("resolves secrets from secrets file and .env", t, func(env *testutil.UnitTestEnv) {
+		config, root := newConfig(env, map[string]string{
+			"build.extra_flags": "-DWIFI_PASS=${secret:WIFI_PASS} -DAPI_KEY=${secret:API_KEY}",
+		})
+		err := os.WriteFile(path.Join(root, "ardi.secrets.json"), []byte(`{"WIFI_PASS": "hunter22"}`), 0644)
+		assert.NoError(env.T, err)
+		err = os.WriteFile(path.Join(root, ".env"), []byte("WIFI_PASS=ignored\nAPI_KEY=abcd1234\n"), 0644)
+		assert.NoError(env.T, err)
+
+		opts, err := config.GetCompileOpts("release")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []string{"build.extra_flags=-DWIFI_PASS=hunter22 -DAPI_KEY=abcd1234"}, opts.BuildProps)
+		assert.ElementsMatch(env.T, []string{"abcd1234", "hunter22"}, opts.Secrets)
+		assert.NotContains(env.T, env.Stdout.String(), "looks like a secret")
+	})
+
+	testutil.RunUnitTest("errors on missing secret", t, func(env *testutil.UnitTestEnv) {
+		config, _ := newConfig(env, map[string]string{
+			"build.extra_flags": "-DWIFI_PASS=${secret:WIFI_PASS}",
+		})
+
+		opts, err := config.GetCompileOpts("release")
+		assert.Error(env.T, err)
+		assert.Contains(env.T, err.Error(), "unresolved variable ${secret:WIFI_PASS}")
+		assert.Nil(env.T, opts)
+	})
+
+	testutil.RunUnitTest("warns on literal secrets once when loaded", t, func(env *testutil.UnitTestEnv) {
+		config, _ := newConfig(env, map[string]string{
+			"build.extra_flags": "-DWIFI_PASSWORD=hunter22 -DLED=13",
+		})
+		assert.Contains(env.T, env.Stdout.String(), "WIFI_PASSWORD looks like a secret committed to ardi.json")
+
+		env.ClearStdout()
+		_, err := config.GetCompileOpts("release")
+		assert.NoError(env.T, err)
+		_, err = config.GetCompileOpts("release")
+		assert.NoError(env.T, err)
+		assert.NotContains(env.T, env.Stdout.String(), "looks like a secret")
+	})
+
+	testutil.RunUnitTest("warns on literal secrets when adding builds", t, func(env *testutil.UnitTestEnv) {
+		config, _ := newConfig(env, nil)
+		env.ClearStdout()
+
+		err := config.AddBuild("debug", testutil.BlinkProjectDir(), "arduino:avr:mega", 0, []string{"build.extra_flags=-DAPI_TOKEN=abcd1234"}, nil)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, env.Stdout.String(), "API_TOKEN looks like a secret committed to ardi.json")
+	})
+
+	testutil.RunUnitTest("redacts secret values from listed builds", t, func(env *testutil.UnitTestEnv) {
+		config, root := newConfig(env, map[string]string{
+			"build.extra_flags": "-DSSID=hunter22",
+		})
+		err := os.WriteFile(path.Join(root, "ardi.secrets.json"), []byte(`{"SSID": "hunter22"}`), 0644)
+		assert.NoError(env.T, err)
+
+		builds := config.ListBuilds([]string{})
+		assert.Equal(env.T, "-DSSID=********", builds["release"].Props["build.extra_flags"])
+		assert.Equal(env.T, "-DSSID=hunter22", config.GetBuilds()["release"].Props["build.extra_flags"])
+	})
+}
+
+func TestArdiConfigExtends(t *testing.T) {
+	newConfig := func(env *testutil.UnitTestEnv, builds map[string]types.ArdiBuild) *core.ArdiConfig {
+		initial := util.GenArdiConfig()
+		initial.Builds = builds
+		return core.NewArdiConfig(path.Join(env.T.TempDir(), "ardi.json"), *initial, env.Logger)
+	}
+
+	base := types.ArdiBuild{
+		Directory: "blink",
+		Sketch:    "blink/blink.ino",
+		Baud:      9600,
+		FQBN:      "arduino:avr:mega",
+		Props: map[string]string{
+			"build.extra_flags":           "-DLED=13",
+			"compiler.optimization_flags": "-Os",
+		},
+		PreBuild: []string{"./gen.sh"},
+	}
+
+	testutil.RunUnitTest("merges builds over the builds they extend", t, func(env *testutil.UnitTestEnv) {
+		config := newConfig(env, map[string]types.ArdiBuild{
+			"base": base,
+			"release": {
+				Extends: "base",
+				Baud:    115200,
+			},
+			"debug": {
+				Extends: "release",
+				Props:   map[string]string{"compiler.optimization_flags": "-Og"},
+			},
+		})
+
+		build, err := config.ResolveBuild("debug")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, types.ArdiBuild{
+			Directory: "blink",
+			Sketch:    "blink/blink.ino",
+			Baud:      115200,
+			FQBN:      "arduino:avr:mega",
+			Props: map[string]string{
+				"build.extra_flags":           "-DLED=13",
+				"compiler.optimization_flags": "-Og",
+			},
+			PreBuild: []string{"./gen.sh"},
+		}, build)
+
+		opts, err := config.GetCompileOpts("debug")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "arduino:avr:mega", opts.FQBN)
+		assert.ElementsMatch(env.T, []string{
+			"build.extra_flags=-DLED=13",
+			"compiler.optimization_flags=-Og",
+		}, opts.BuildProps)
+
+		// stored builds are left untouched
+		assert.Equal(env.T, "-Os", config.GetBuilds()["base"].Props["compiler.optimization_flags"])
+		assert.Equal(env.T, "", config.GetBuilds()["debug"].FQBN)
+	})
+
+	testutil.RunUnitTest("lets builds turn off inherited version injection", t, func(env *testutil.UnitTestEnv) {
+		enabled, disabled := true, false
+		release := base
+		release.InjectVersion = &enabled
+		config := newConfig(env, map[string]types.ArdiBuild{
+			"release": release,
+			"debug":   {Extends: "release", InjectVersion: &disabled},
+			"staging": {Extends: "release"},
+		})
+
+		debug, err := config.ResolveBuild("debug")
+		assert.NoError(env.T, err)
+		assert.False(env.T, debug.VersionInjected())
+
+		staging, err := config.ResolveBuild("staging")
+		assert.NoError(env.T, err)
+		assert.True(env.T, staging.VersionInjected())
+	})
+
+	testutil.RunUnitTest("errors on inheritance cycles", t, func(env *testutil.UnitTestEnv) {
+		config := newConfig(env, map[string]types.ArdiBuild{
+			"a": {Extends: "b"},
+			"b": {Extends: "c"},
+			"c": {Extends: "a"},
+		})
+
+		_, err := config.ResolveBuild("a")
+		assert.EqualError(env.T, err, "build inheritance cycle detected: a -> b -> c -> a")
+
+		opts, err := config.GetCompileOpts("b")
+		assert.EqualError(env.T, err, "build inheritance cycle detected: b -> c -> a -> b")
+		assert.Nil(env.T, opts)
+	})
+
+	testutil.RunUnitTest("errors on unknown parent build", t, func(env *testutil.UnitTestEnv) {
+		config := newConfig(env, map[string]types.ArdiBuild{
+			"debug": {Extends: "base"},
+		})
+
+		_, err := config.ResolveBuild("debug")
+		assert.EqualError(env.T, err, "build debug extends unknown build base")
+	})
+}
+
+func TestArdiConfigWrite(t *testing.T) {
+	writeConfig := func(env *testutil.UnitTestEnv, conf string, config *types.ArdiConfig) {
+		data, err := json.Marshal(config)
+		assert.NoError(env.T, err)
+		assert.NoError(env.T, os.WriteFile(conf, data, 0644))
+	}
+
+	testutil.RunUnitTest("keeps changes made by other processes", t, func(env *testutil.UnitTestEnv) {
+		conf := path.Join(env.T.TempDir(), "ardi.json")
+		writeConfig(env, conf, util.GenArdiConfig())
+
+		initial, err := util.ReadArdiConfig(conf)
+		assert.NoError(env.T, err)
+		config := core.NewArdiConfig(conf, *initial, env.Logger)
+
+		external := util.GenArdiConfig()
+		external.Libraries["Servo"] = "1.1.8"
+		writeConfig(env, conf, external)
+
+		err = config.AddPlatform("arduino:avr", "1.8.6")
+		assert.NoError(env.T, err)
+
+		written, err := util.ReadArdiConfig(conf)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"arduino:avr": "1.8.6"}, written.Platforms)
+		assert.Equal(env.T, map[string]string{"Servo": "1.1.8"}, written.Libraries)
+		assert.Equal(env.T, written.Libraries, config.GetLibraries())
+	})
+
+	testutil.RunUnitTest("preserves formatting and comments", t, func(env *testutil.UnitTestEnv) {
+		conf := path.Join(env.T.TempDir(), "ardi.json")
+		original := "{\n  \"libraries\": {\n    // newer versions break the display\n    \"Servo\": \"1.1.8\"\n  },\n  \"platforms\": {}\n}\n"
+		assert.NoError(env.T, os.WriteFile(conf, []byte(original), 0644))
+
+		initial, err := util.ReadArdiConfig(conf)
+		assert.NoError(env.T, err)
+		config := core.NewArdiConfig(conf, *initial, env.Logger)
+
+		err = config.AddLibrary("Bounce2", "2.71.0")
+		assert.NoError(env.T, err)
+
+		data, err := os.ReadFile(conf)
+		assert.NoError(env.T, err)
+		expected := "{\n  \"libraries\": {\n    \"Bounce2\": \"2.71.0\",\n    // newer versions break the display\n    \"Servo\": \"1.1.8\"\n  },\n  \"platforms\": {}\n}\n"
+		assert.Equal(env.T, expected, string(data))
+	})
+
+	testutil.RunUnitTest("refuses to overwrite an invalid ardi.json", t, func(env *testutil.UnitTestEnv) {
+		conf := path.Join(env.T.TempDir(), "ardi.json")
+		config := core.NewArdiConfig(conf, *util.GenArdiConfig(), env.Logger)
+		assert.NoError(env.T, os.WriteFile(conf, []byte("{"), 0644))
+
+		err := config.AddPlatform("arduino:avr", "1.8.6")
+		assert.Error(env.T, err)
+
+		data, err := os.ReadFile(conf)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "{", string(data))
+	})
+}
@@ -1,7 +1,12 @@
 package core
 
 import (
-	"io/ioutil"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/robgonnella/ardi/v3/types"
@@ -14,14 +19,19 @@ type ArdiYAML struct {
 	Config   types.ArduinoCliSettings
 	confPath string
 	mux      sync.Mutex
+	// saved config as last read from or written to disk, used to merge
+	// changes made to the config file by other processes
+	saved json.RawMessage
 }
 
 // NewArdiYAML returns core yaml module for handling data config file
 func NewArdiYAML(confPath string, initalConfig types.ArduinoCliSettings) *ArdiYAML {
+	saved, _ := json.Marshal(initalConfig)
 	return &ArdiYAML{
 		Config:   initalConfig,
 		confPath: confPath,
 		mux:      sync.Mutex{},
+		saved:    saved,
 	}
 }
 
@@ -49,19 +59,94 @@ func (a *ArdiYAML) RemoveBoardURL(url string) error {
 	return nil
 }
 
+// HasSetting returns whether the dotted path addresses a field of
+// arduino-cli.yaml
+func (a *ArdiYAML) HasSetting(path string) bool {
+	return util.HasSetting(&a.Config, "yaml", path)
+}
+
+// GetSetting returns the value at the dotted path in arduino-cli.yaml
+func (a *ArdiYAML) GetSetting(path string) (interface{}, error) {
+	return util.GetSetting(&a.Config, "yaml", path)
+}
+
+// SetSetting sets the value at the dotted path in arduino-cli.yaml. The
+// change is rejected if the resulting settings aren't valid.
+func (a *ArdiYAML) SetSetting(path, value string) error {
+	config := a.Config
+	config.BoardManager.AdditionalUrls = append([]string{}, a.Config.BoardManager.AdditionalUrls...)
+
+	if err := util.SetSetting(&config, "yaml", path, value); err != nil {
+		return err
+	}
+	if err := validateCliSettings(config); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", path, err)
+	}
+
+	a.Config = config
+	return a.write()
+}
+
 // private methods
 func (a *ArdiYAML) write() error {
 	a.mux.Lock()
 	defer a.mux.Unlock()
 
-	newData, err := yaml.Marshal(a.Config)
+	unlock, err := util.LockFile(a.confPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	config := a.Config
+	if _, err := os.Stat(a.confPath); err == nil {
+		disk, err := util.ReadArduinoCliSettings(a.confPath)
+		if err != nil {
+			return err
+		}
+		if err := util.MergeChanges(a.saved, a.Config, disk, &config); err != nil {
+			return err
+		}
+	}
+
+	newData, err := yaml.Marshal(config)
 	if err != nil {
 		return err
 	}
 
-	if err := ioutil.WriteFile(a.confPath, newData, 0644); err != nil {
+	if err := util.WriteFileAtomic(a.confPath, newData, 0644); err != nil {
 		return err
 	}
 
+	saved, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	a.Config = config
+	a.saved = saved
+
+	return nil
+}
+
+// validateCliSettings checks the settings arduino-cli only accepts a fixed
+// set of values for
+func validateCliSettings(settings types.ArduinoCliSettings) error {
+	levels := []string{"trace", "debug", "info", "warn", "warning", "error", "fatal", "panic"}
+	if !util.ArrayContains(levels, settings.Logging.Level) {
+		return fmt.Errorf("logging.level must be one of %s", strings.Join(levels, ", "))
+	}
+
+	formats := []string{"text", "json"}
+	if !util.ArrayContains(formats, settings.Logging.Format) {
+		return fmt.Errorf("logging.format must be one of %s", strings.Join(formats, ", "))
+	}
+
+	if settings.Daemon.Port != "" {
+		if port, err := strconv.Atoi(settings.Daemon.Port); err != nil || port < 1 || port > 65535 {
+			return errors.New("daemon.port must be a port number")
+		}
+	}
+
 	return nil
 }
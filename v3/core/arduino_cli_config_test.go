@@ -1,17 +1,20 @@
 package core_test
 
 import (
+	"os"
+	"path"
 	"testing"
 
-	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/core"
 	"github.com/robgonnella/ardi/v3/testutil"
 	"github.com/robgonnella/ardi/v3/util"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 func TestArduinoCliConfig(t *testing.T) {
 	testutil.RunUnitTest("adds and removes board urls", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 
 		boardURL1 := "https://somefakeboardurl.com"
 		boardURL2 := "https://anotherfakeboardurl.com"
@@ -22,7 +25,7 @@ func TestArduinoCliConfig(t *testing.T) {
 		err = env.ArdiCore.CliConfig.AddBoardURL(boardURL2)
 		assert.NoError(env.T, err)
 
-		settings, err := util.ReadArduinoCliSettings(paths.ArduinoCliProjectConfig)
+		settings, err := util.ReadArduinoCliSettings(env.ArdiCore.Paths.ArduinoCliConfig)
 		assert.NoError(env.T, err)
 
 		assert.Contains(env.T, settings.BoardManager.AdditionalUrls, boardURL1)
@@ -31,14 +34,14 @@ func TestArduinoCliConfig(t *testing.T) {
 		err = env.ArdiCore.CliConfig.RemoveBoardURL(boardURL1)
 		assert.NoError(env.T, err)
 
-		settings, err = util.ReadArduinoCliSettings(paths.ArduinoCliProjectConfig)
+		settings, err = util.ReadArduinoCliSettings(env.ArdiCore.Paths.ArduinoCliConfig)
 		assert.NoError(env.T, err)
 		assert.NotContains(env.T, settings.BoardManager.AdditionalUrls, boardURL1)
 		assert.Contains(env.T, settings.BoardManager.AdditionalUrls, boardURL2)
 	})
 
 	testutil.RunUnitTest("doesnt error adding same url twice", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 
 		boardURL1 := "https://somefakeboardurl.com"
 
@@ -48,7 +51,7 @@ func TestArduinoCliConfig(t *testing.T) {
 		err = env.ArdiCore.CliConfig.AddBoardURL(boardURL1)
 		assert.NoError(env.T, err)
 
-		settings, err := util.ReadArduinoCliSettings(paths.ArduinoCliProjectConfig)
+		settings, err := util.ReadArduinoCliSettings(env.ArdiCore.Paths.ArduinoCliConfig)
 		assert.NoError(env.T, err)
 
 		assert.Contains(env.T, settings.BoardManager.AdditionalUrls, boardURL1)
@@ -56,11 +59,32 @@ func TestArduinoCliConfig(t *testing.T) {
 	})
 
 	testutil.RunUnitTest("doesnt error removing non-exiting url", t, func(env *testutil.UnitTestEnv) {
-		util.InitProjectDirectory()
+		util.InitProjectDirectory(env.ArdiCore.Paths)
 
 		boardURL1 := "https://somefakeboardurl.com"
 
 		err := env.ArdiCore.CliConfig.RemoveBoardURL(boardURL1)
 		assert.NoError(env.T, err)
 	})
+
+	testutil.RunUnitTest("keeps changes made by other processes", t, func(env *testutil.UnitTestEnv) {
+		dir := env.T.TempDir()
+		conf := path.Join(dir, "arduino-cli.yaml")
+		cliConfig := core.NewArdiYAML(conf, *util.GenArduinoCliSettings(dir))
+		assert.NoError(env.T, cliConfig.AddBoardURL("https://somefakeboardurl.com"))
+
+		external, err := util.ReadArduinoCliSettings(conf)
+		assert.NoError(env.T, err)
+		external.Logging.Level = "debug"
+		data, err := yaml.Marshal(external)
+		assert.NoError(env.T, err)
+		assert.NoError(env.T, os.WriteFile(conf, data, 0644))
+
+		assert.NoError(env.T, cliConfig.RemoveBoardURL("https://somefakeboardurl.com"))
+
+		settings, err := util.ReadArduinoCliSettings(conf)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "debug", settings.Logging.Level)
+		assert.Empty(env.T, settings.BoardManager.AdditionalUrls)
+	})
 }
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+// BoardCore module for board commands
+type BoardCore struct {
+	logger *log.Logger
+	cli    *cli.Wrapper
+}
+
+// BoardCoreOption represents options for BoardCore
+type BoardCoreOption = func(c *BoardCore)
+
+// NewBoardCore board module instance
+func NewBoardCore(logger *log.Logger, options ...BoardCoreOption) *BoardCore {
+	c := &BoardCore{
+		logger: logger,
+	}
+
+	for _, o := range options {
+		o(c)
+	}
+
+	return c
+}
+
+// WithBoardCoreCliWrapper allows an injectable cli wrapper
+func WithBoardCoreCliWrapper(wrapper *cli.Wrapper) BoardCoreOption {
+	return func(c *BoardCore) {
+		c.cli = wrapper
+	}
+}
+
+// List returns the boards of all installed platforms sorted by name
+func (c *BoardCore) List() []types.Board {
+	return sortBoards(toBoards(c.cli.AllBoards()))
+}
+
+// Search returns the boards of all indexed platforms matching query sorted
+// by name
+func (c *BoardCore) Search(query string) ([]types.Board, error) {
+	items, err := c.cli.SearchBoards(query)
+	if err != nil {
+		return nil, err
+	}
+
+	boards := []types.Board{}
+	for _, item := range items {
+		boards = append(boards, types.Board{
+			Name:     item.GetName(),
+			FQBN:     item.GetFqbn(),
+			Platform: item.GetPlatform().GetId(),
+		})
+	}
+
+	return sortBoards(boards), nil
+}
+
+// Attached returns the boards detected on connected ports
+func (c *BoardCore) Attached() []types.Board {
+	return toBoards(c.cli.ConnectedBoards())
+}
+
+// Options returns the menu options available for a board from its installed
+// platform
+func (c *BoardCore) Options(fqbn string) ([]types.BoardOption, error) {
+	base, _, _ := util.SplitFQBN(fqbn)
+
+	configOptions, err := c.cli.BoardOptions(base)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []types.BoardOption{}
+	for _, o := range configOptions {
+		option := types.BoardOption{
+			Option: o.GetOption(),
+			Label:  o.GetOptionLabel(),
+			Values: []types.BoardOptionValue{},
+		}
+		for _, v := range o.GetValues() {
+			option.Values = append(option.Values, types.BoardOptionValue{
+				Value:   v.GetValue(),
+				Label:   v.GetValueLabel(),
+				Default: v.GetSelected(),
+			})
+		}
+		options = append(options, option)
+	}
+
+	return options, nil
+}
+
+// ValidateOptions returns an error if any board option specified in fqbn is
+// not available for the board or is set to an unavailable value
+func (c *BoardCore) ValidateOptions(fqbn string) error {
+	return validateBoardOptions(c.cli, fqbn)
+}
+
+// private helpers
+func toBoards(boardsWithPorts []*cli.BoardWithPort) []types.Board {
+	boards := []types.Board{}
+	for _, b := range boardsWithPorts {
+		boards = append(boards, types.Board{
+			Name: b.Name,
+			FQBN: b.FQBN,
+			Port: b.Port,
+		})
+	}
+	return boards
+}
+
+func sortBoards(boards []types.Board) []types.Board {
+	sort.SliceStable(boards, func(i, j int) bool {
+		return boards[i].Name < boards[j].Name
+	})
+	return boards
+}
+
+func validateBoardOptions(wrapper *cli.Wrapper, fqbn string) error {
+	base, names, values := util.SplitFQBN(fqbn)
+	if len(names) == 0 {
+		return nil
+	}
+
+	configOptions, err := wrapper.BoardOptions(base)
+	if err != nil {
+		return fmt.Errorf("failed to get board options for %s: %w", base, err)
+	}
+
+	available := make(map[string][]string)
+	optionNames := []string{}
+	for _, o := range configOptions {
+		optionNames = append(optionNames, o.GetOption())
+		for _, v := range o.GetValues() {
+			available[o.GetOption()] = append(available[o.GetOption()], v.GetValue())
+		}
+	}
+
+	for _, name := range names {
+		choices, ok := available[name]
+		if !ok {
+			return fmt.Errorf(
+				"invalid board option %s for %s, expected one of: %s",
+				name, base, strings.Join(optionNames, ", "),
+			)
+		}
+		if !util.ArrayContains(choices, values[name]) {
+			return fmt.Errorf(
+				"invalid value %s for board option %s on %s, expected one of: %s",
+				values[name], name, base, strings.Join(choices, ", "),
+			)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package core_test
+
+import (
+	"testing"
+
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoardCore(t *testing.T) {
+	instance := &rpc.Instance{Id: int32(1)}
+	fqbn := "esp32:esp32:esp32"
+
+	detailsResp := &rpc.BoardDetailsResponse{
+		ConfigOptions: []*rpc.ConfigOption{
+			{
+				Option:      "FlashFreq",
+				OptionLabel: "Flash Frequency",
+				Values: []*rpc.ConfigValue{
+					{Value: "80", ValueLabel: "80MHz", Selected: true},
+					{Value: "40", ValueLabel: "40MHz"},
+				},
+			},
+		},
+	}
+
+	expectDetails := func(env *testutil.UnitTestEnv) {
+		req := &rpc.BoardDetailsRequest{Instance: instance, Fqbn: fqbn}
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), req).Return(detailsResp, nil)
+	}
+
+	testutil.RunUnitTest("returns board options for base fqbn", t, func(env *testutil.UnitTestEnv) {
+		expectDetails(env)
+
+		options, err := env.ArdiCore.Board.Options(fqbn + ":FlashFreq=40")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, 1, len(options))
+		assert.Equal(env.T, "FlashFreq", options[0].Option)
+		assert.Equal(env.T, "Flash Frequency", options[0].Label)
+		assert.Equal(env.T, 2, len(options[0].Values))
+		assert.True(env.T, options[0].Values[0].Default)
+		assert.False(env.T, options[0].Values[1].Default)
+	})
+
+	testutil.RunUnitTest("validates board options", t, func(env *testutil.UnitTestEnv) {
+		expectDetails(env)
+
+		err := env.ArdiCore.Board.ValidateOptions(fqbn + ":FlashFreq=40")
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunUnitTest("errors on invalid board option value", t, func(env *testutil.UnitTestEnv) {
+		expectDetails(env)
+
+		err := env.ArdiCore.Board.ValidateOptions(fqbn + ":FlashFreq=20")
+		assert.EqualError(env.T, err, "invalid value 20 for board option FlashFreq on esp32:esp32:esp32, expected one of: 80, 40")
+	})
+
+	testutil.RunUnitTest("skips validation without options", t, func(env *testutil.UnitTestEnv) {
+		err := env.ArdiCore.Board.ValidateOptions(fqbn)
+		assert.NoError(env.T, err)
+	})
+}
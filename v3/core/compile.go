@@ -1,11 +1,27 @@
 package core
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
 	log "github.com/sirupsen/logrus"
 
 	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+// build hook stages
+const (
+	preBuildHook  = "preBuild"
+	postBuildHook = "postBuild"
 )
 
+// artifactExtensions compiled artifact extensions in order of preference
+var artifactExtensions = []string{".bin", ".hex", ".uf2", ".elf"}
+
 // CompileCore represents core module for compile commands
 type CompileCore struct {
 	logger *log.Logger
@@ -35,18 +51,155 @@ func WithCompileCoreCliWrapper(cliWrapper *cli.Wrapper) CompileCoreOption {
 	}
 }
 
-// Compile compiles a given project sketch
+// Compile compiles a given project sketch running any pre and post build
+// hooks. Hooks are skipped when only showing build properties.
 func (c *CompileCore) Compile(opts cli.CompileOpts) error {
 	fields := log.Fields{
 		"sketch": opts.SketchPath,
 		"fqbn":   opts.FQBN,
 	}
+	if opts.BuildName != "" {
+		fields["build"] = opts.BuildName
+	}
 	fieldsLogger := c.logger.WithFields(fields)
+
+	// options may be embedded in the fqbn as well as given separately
+	if err := validateBoardOptions(c.cli, opts.FQBN); err != nil {
+		fieldsLogger.WithError(err).Error("Invalid board options")
+		return err
+	}
+
+	if len(opts.Secrets) > 0 {
+		stdout, stderr := redactOutput(&opts)
+		defer stdout.Flush()
+		defer stderr.Flush()
+	}
+
+	if !opts.ShowProps {
+		if err := c.runHooks(preBuildHook, opts.PreBuild, opts, fieldsLogger); err != nil {
+			return err
+		}
+	}
+
 	fieldsLogger.Info("Compiling...")
 	if err := c.cli.Compile(opts); err != nil {
 		fieldsLogger.WithError(err).Error("Compilation failed")
 		return err
 	}
 	fieldsLogger.Info("Compilation successful")
+
+	if !opts.ShowProps {
+		if err := c.runHooks(postBuildHook, opts.PostBuild, opts, fieldsLogger); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// private
+func (c *CompileCore) runHooks(stage string, hooks []string, opts cli.CompileOpts, logger *log.Entry) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	hookEnv, err := c.hookEnv(stage, opts)
+	if err != nil {
+		return err
+	}
+
+	var stdout io.Writer = os.Stdout
+	if opts.Stdout != nil {
+		stdout = opts.Stdout
+	}
+
+	var stderr io.Writer = os.Stderr
+	if opts.Stderr != nil {
+		stderr = opts.Stderr
+	}
+
+	for _, hook := range hooks {
+		logger.WithField("hook", stage).Infof("> %s", hook)
+		// hooks are cancelled along with the compilation
+		err := util.RunShell(c.cli.Context(), util.ShellOpts{
+			Command: hook,
+			Dir:     opts.ProjectDir,
+			Env:     hookEnv,
+			Stdout:  stdout,
+			Stderr:  stderr,
+		})
+		if err != nil {
+			hookErr := fmt.Errorf("%s hook %q failed for build %s: %w", stage, hook, opts.BuildName, err)
+			logger.WithError(hookErr).Error("Build hook failed")
+			return hookErr
+		}
+	}
+
+	return nil
+}
+
+func (c *CompileCore) hookEnv(stage string, opts cli.CompileOpts) ([]string, error) {
+	sketchDir, err := filepath.Abs(opts.SketchDir)
+	if err != nil {
+		return nil, err
+	}
+
+	outputDir := cli.ExportDir(sketchDir)
+
+	// artifacts from previous builds are not exposed to preBuild hooks
+	artifact := ""
+	if stage == postBuildHook {
+		artifact = findArtifact(outputDir, opts.SketchPath)
+	}
+
+	hookEnv := []string{
+		"ARDI_HOOK=" + stage,
+		"ARDI_BUILD_NAME=" + opts.BuildName,
+		"ARDI_FQBN=" + opts.FQBN,
+		"ARDI_SKETCH=" + opts.SketchPath,
+		"ARDI_SKETCH_DIR=" + sketchDir,
+		"ARDI_OUTPUT_DIR=" + outputDir,
+		"ARDI_ARTIFACT=" + artifact,
+	}
+
+	if opts.ProjectDir != "" {
+		hookEnv = append(hookEnv, paths.ProjectDirEnv+"="+opts.ProjectDir)
+	}
+
+	return hookEnv, nil
+}
+
+// private helpers
+// redactOutput replaces the output writers in opts with writers that redact
+// the secret values in opts
+func redactOutput(opts *cli.CompileOpts) (*RedactWriter, *RedactWriter) {
+	var stdout io.Writer = os.Stdout
+	if opts.Stdout != nil {
+		stdout = opts.Stdout
+	}
+
+	var stderr io.Writer = os.Stderr
+	if opts.Stderr != nil {
+		stderr = opts.Stderr
+	}
+
+	redactStdout := NewRedactWriter(stdout, opts.Secrets)
+	redactStderr := NewRedactWriter(stderr, opts.Secrets)
+	opts.Stdout = redactStdout
+	opts.Stderr = redactStderr
+
+	return redactStdout, redactStderr
+}
+
+// findArtifact returns the path of the compiled artifact for a sketch in
+// the output directory or an empty string if not found
+func findArtifact(outputDir, sketchPath string) string {
+	base := filepath.Base(sketchPath)
+	for _, ext := range artifactExtensions {
+		artifact := filepath.Join(outputDir, base+ext)
+		if stat, err := os.Stat(artifact); err == nil && stat.Mode().IsRegular() {
+			return artifact
+		}
+	}
+	return ""
+}
@@ -1,15 +1,22 @@
 package core_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"io"
+	"os"
 	"path"
 	"testing"
+	"time"
 
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
 	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/paths"
 	"github.com/robgonnella/ardi/v3/testutil"
 )
 
@@ -87,4 +94,153 @@ func TestCompileCore(t *testing.T) {
 		assert.EqualError(env.T, err, errString)
 	})
 
+	testutil.RunUnitTest("rejects unavailable options embedded in fqbn", t, func(env *testutil.UnitTestEnv) {
+		projectDir := testutil.BlinkProjectDir()
+		fqbn := "arduino:avr:mega"
+
+		instance := &rpc.Instance{Id: int32(1)}
+		detailsResp := &rpc.BoardDetailsResponse{
+			ConfigOptions: []*rpc.ConfigOption{
+				{Option: "cpu", Values: []*rpc.ConfigValue{{Value: "atmega2560"}, {Value: "atmega1280"}}},
+			},
+		}
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().BoardDetails(gomock.Any(), &rpc.BoardDetailsRequest{Instance: instance, Fqbn: fqbn}).Return(detailsResp, nil)
+
+		err := env.ArdiCore.Compiler.Compile(cli.CompileOpts{
+			FQBN:       fqbn + ":cpu=atmega328",
+			SketchDir:  projectDir,
+			SketchPath: path.Join(projectDir, "blink.ino"),
+		})
+		assert.ErrorContains(env.T, err, "invalid value atmega328 for board option cpu")
+	})
+}
+
+func TestCompileCoreHooks(t *testing.T) {
+	instance := &rpc.Instance{Id: int32(1)}
+	fqbn := "arduino:avr:mega"
+
+	// setup creates a sketch in a temporary project directory
+	setup := func(env *testutil.UnitTestEnv) (string, cli.CompileOpts) {
+		projectDir := env.T.TempDir()
+		sketchDir := path.Join(projectDir, "blink")
+		assert.NoError(env.T, os.MkdirAll(sketchDir, 0755))
+		sketch := path.Join(sketchDir, "blink.ino")
+		assert.NoError(env.T, os.WriteFile(sketch, []byte("void setup() {}\nvoid loop() {}\n"), 0644))
+
+		opts := cli.CompileOpts{
+			FQBN:       fqbn,
+			SketchDir:  sketchDir,
+			SketchPath: sketch,
+			BuildName:  "release",
+			ProjectDir: projectDir,
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		return projectDir, opts
+	}
+
+	writeArtifact := func(ctx interface{}, req *rpc.CompileRequest, out, err io.Writer, progress interface{}, verbose bool) (*rpc.CompileResponse, error) {
+		os.MkdirAll(req.ExportDir, 0755)
+		artifact := path.Join(req.ExportDir, "blink.ino.bin")
+		return &rpc.CompileResponse{}, os.WriteFile(artifact, []byte("bin"), 0644)
+	}
+
+	testutil.RunUnitTest("runs hooks with build context", t, func(env *testutil.UnitTestEnv) {
+		projectDir, opts := setup(env)
+		opts.PreBuild = []string{"echo \"$ARDI_BUILD_NAME $ARDI_FQBN [$ARDI_ARTIFACT]\" > pre.txt"}
+		opts.PostBuild = []string{"echo \"$ARDI_OUTPUT_DIR $ARDI_ARTIFACT\" > post.txt"}
+
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(writeArtifact)
+
+		err := env.ArdiCore.Compiler.Compile(opts)
+		assert.NoError(env.T, err)
+
+		outputDir := path.Join(opts.SketchDir, "build")
+
+		pre, err := os.ReadFile(path.Join(projectDir, "pre.txt"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "release "+fqbn+" []\n", string(pre))
+
+		post, err := os.ReadFile(path.Join(projectDir, "post.txt"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, outputDir+" "+path.Join(outputDir, "blink.ino.bin")+"\n", string(post))
+	})
+
+	testutil.RunUnitTest("fails before compiling if preBuild hook fails", t, func(env *testutil.UnitTestEnv) {
+		_, opts := setup(env)
+		opts.PreBuild = []string{"exit 2"}
+
+		err := env.ArdiCore.Compiler.Compile(opts)
+		assert.EqualError(env.T, err, "preBuild hook \"exit 2\" failed for build release: exit status 2")
+	})
+
+	testutil.RunUnitTest("cancels hooks with the compile context", t, func(env *testutil.UnitTestEnv) {
+		_, opts := setup(env)
+		opts.PreBuild = []string{"sleep 5"}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		wrapper := cli.NewCli(ctx, paths.NewProjectPaths(".").ArduinoCliConfig, env.Logger, cli.WithArduinoCli(env.ArduinoCli))
+		compiler := core.NewCompileCore(env.Logger, core.WithCompileCoreCliWrapper(wrapper))
+
+		start := time.Now()
+		err := compiler.Compile(opts)
+		assert.ErrorContains(env.T, err, "preBuild hook \"sleep 5\" failed for build release")
+		assert.Less(env.T, time.Since(start), 5*time.Second)
+	})
+
+	testutil.RunUnitTest("returns error naming failed postBuild hook", t, func(env *testutil.UnitTestEnv) {
+		_, opts := setup(env)
+		opts.PostBuild = []string{"true", "./sign.sh"}
+
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+		err := env.ArdiCore.Compiler.Compile(opts)
+		assert.Error(env.T, err)
+		assert.Contains(env.T, err.Error(), "postBuild hook \"./sign.sh\" failed for build release")
+	})
+
+	testutil.RunUnitTest("skips hooks when showing build properties", t, func(env *testutil.UnitTestEnv) {
+		_, opts := setup(env)
+		opts.ShowProps = true
+		opts.PreBuild = []string{"exit 1"}
+		opts.PostBuild = []string{"exit 1"}
+
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+		err := env.ArdiCore.Compiler.Compile(opts)
+		assert.NoError(env.T, err)
+	})
+}
+
+func TestCompileCoreRedactsSecrets(t *testing.T) {
+	testutil.RunUnitTest("redacts secrets from compile and hook output", t, func(env *testutil.UnitTestEnv) {
+		projectDir := testutil.BlinkProjectDir()
+		var out bytes.Buffer
+
+		opts := cli.CompileOpts{
+			FQBN:       "arduino:avr:mega",
+			SketchDir:  projectDir,
+			SketchPath: path.Join(projectDir, "blink.ino"),
+			BuildProps: []string{"build.extra_flags=-DWIFI_PASS=hunter22"},
+			ProjectDir: env.T.TempDir(),
+			PreBuild:   []string{"echo pre hunter22"},
+			Secrets:    []string{"hunter22"},
+			Stdout:     &out,
+			Stderr:     &out,
+		}
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(&rpc.Instance{Id: int32(1)}).AnyTimes()
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx interface{}, req *rpc.CompileRequest, stdout, stderr io.Writer, progress interface{}, verbose bool) (*rpc.CompileResponse, error) {
+				io.WriteString(stdout, "avr-g++ -DWIFI_PASS=hunter22 blink.ino")
+				return &rpc.CompileResponse{}, nil
+			},
+		)
+
+		err := env.ArdiCore.Compiler.Compile(opts)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "pre ********\navr-g++ -DWIFI_PASS=******** blink.ino", out.String())
+	})
 }
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"path"
 
 	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
 	"github.com/robgonnella/ardi/v3/paths"
@@ -11,15 +12,19 @@ import (
 
 // ArdiCore represents the core package of ardi
 type ArdiCore struct {
-	Cli             *cli.Wrapper
-	Config          *ArdiConfig
-	CliConfig       *ArdiYAML
-	Lib             *LibCore
-	Platform        *PlatformCore
-	Compiler        *CompileCore
-	ctx             context.Context
-	cliSettingsPath string
-	logger          *log.Logger
+	Cli        *cli.Wrapper
+	Config     *ArdiConfig
+	CliConfig  *ArdiYAML
+	Lib        *LibCore
+	Platform   *PlatformCore
+	Board      *BoardCore
+	Compiler   *CompileCore
+	Uploader   *UploadCore
+	SerialPort SerialPort
+	NativeTest *NativeTestCore
+	Paths      paths.ProjectPaths
+	ctx        context.Context
+	logger     *log.Logger
 }
 
 // ArdiCoreOption represents options for ArdiCore
@@ -29,22 +34,30 @@ type ArdiCoreOption = func(c *ArdiCore)
 type NewArdiCoreOpts struct {
 	ArdiConfig         types.ArdiConfig
 	ArduinoCliSettings types.ArduinoCliSettings
-	CliSettingsPath    string
+	Paths              paths.ProjectPaths
 	Logger             *log.Logger
 	Ctx                context.Context
 }
 
 // NewArdiCore returns a new ardi core
 func NewArdiCore(opts NewArdiCoreOpts, options ...ArdiCoreOption) *ArdiCore {
-	ardiConf := paths.ArdiProjectConfig
-	cliConf := paths.ArduinoCliProjectConfig
+	ardiConf := opts.Paths.ArdiConfig
+	cliConf := opts.Paths.ArduinoCliConfig
+
+	nativeTestOpts := []NativeTestCoreOption{}
+	if userDir := opts.ArduinoCliSettings.Directories.User; userDir != "" {
+		librariesDir := path.Join(userDir, "libraries")
+		nativeTestOpts = append(nativeTestOpts, WithNativeTestLibrariesDir(librariesDir))
+	}
 
 	core := &ArdiCore{
-		ctx:             opts.Ctx,
-		cliSettingsPath: opts.CliSettingsPath,
-		Config:          NewArdiConfig(ardiConf, opts.ArdiConfig, opts.Logger),
-		CliConfig:       NewArdiYAML(cliConf, opts.ArduinoCliSettings),
-		logger:          opts.Logger,
+		ctx:        opts.Ctx,
+		Paths:      opts.Paths,
+		Config:     NewArdiConfig(ardiConf, opts.ArdiConfig, opts.Logger),
+		CliConfig:  NewArdiYAML(cliConf, opts.ArduinoCliSettings),
+		NativeTest: NewNativeTestCore(opts.Logger, nativeTestOpts...),
+		SerialPort: NewArdiSerialPort(opts.Logger),
+		logger:     opts.Logger,
 	}
 
 	for _, o := range options {
@@ -58,7 +71,7 @@ func NewArdiCore(opts NewArdiCoreOpts, options ...ArdiCoreOption) *ArdiCore {
 func WithArduinoCli(arduinoCli cli.Cli) func(c *ArdiCore) {
 	return func(c *ArdiCore) {
 		withArduinoCli := cli.WithArduinoCli(arduinoCli)
-		c.Cli = cli.NewCli(c.ctx, c.cliSettingsPath, c.logger, withArduinoCli)
+		c.Cli = cli.NewCli(c.ctx, c.Paths.ArduinoCliConfig, c.logger, withArduinoCli)
 
 		withLibCliWrapper := WithLibCliWrapper(c.Cli)
 		c.Lib = NewLibCore(c.logger, withLibCliWrapper)
@@ -66,7 +79,21 @@ func WithArduinoCli(arduinoCli cli.Cli) func(c *ArdiCore) {
 		withPlatformCliWrapper := WithPlatformCliWrapper(c.Cli)
 		c.Platform = NewPlatformCore(c.logger, withPlatformCliWrapper)
 
+		withBoardCliWrapper := WithBoardCoreCliWrapper(c.Cli)
+		c.Board = NewBoardCore(c.logger, withBoardCliWrapper)
+		c.Config.validateBoard = c.Board.ValidateOptions
+
 		withCompileCliWrapper := WithCompileCoreCliWrapper(c.Cli)
 		c.Compiler = NewCompileCore(c.logger, withCompileCliWrapper)
+
+		withUploadCliWrapper := WithUploadCoreCliWrapper(c.Cli)
+		c.Uploader = NewUploadCore(c.logger, withUploadCliWrapper)
+	}
+}
+
+// WithSerialPort allows an injectable serial port
+func WithSerialPort(port SerialPort) func(c *ArdiCore) {
+	return func(c *ArdiCore) {
+		c.SerialPort = port
 	}
 }
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// interpolationRgx matches ${...} references and the $${ escape
+var interpolationRgx = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+
+// referenceRgx matches the body of a reference in the v3 grammar
+var referenceRgx = regexp.MustCompile(`^\s*(project\.dir|(env|var|secret):[^\s:]+)\s*$`)
+
+// projectDirRef reference replaced with the project root directory
+const projectDirRef = "project.dir"
+
+// VarResolver resolves the name of a variable reference for a source such
+// as env or var returning whether the variable is defined
+type VarResolver = func(name string) (string, bool)
+
+// Interpolator resolves ${env:NAME}, ${var:name}, and ${project.dir}
+// references in ardi.json values
+type Interpolator struct {
+	projectDir string
+	resolvers  map[string]VarResolver
+}
+
+// NewInterpolator returns an Interpolator for a project using vars to
+// resolve ${var:name} references
+func NewInterpolator(projectDir string, vars map[string]string) *Interpolator {
+	return &Interpolator{
+		projectDir: projectDir,
+		resolvers: map[string]VarResolver{
+			"env": os.LookupEnv,
+			"var": func(name string) (string, bool) {
+				v, ok := vars[name]
+				return v, ok
+			},
+		},
+	}
+}
+
+// WithResolver registers an additional reference source
+func (i *Interpolator) WithResolver(source string, resolver VarResolver) *Interpolator {
+	i.resolvers[source] = resolver
+	return i
+}
+
+// Interpolate replaces all references in s. An error is returned for any
+// reference that cannot be resolved. Use $${ for a literal ${.
+func (i *Interpolator) Interpolate(s string) (string, error) {
+	var resolveErr error
+
+	result := interpolationRgx.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		if resolveErr != nil {
+			return match
+		}
+
+		value, err := i.resolve(strings.TrimSpace(match[2 : len(match)-1]))
+		if err != nil {
+			resolveErr = fmt.Errorf("%w in %q", err, s)
+			return match
+		}
+		return value
+	})
+
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+
+	return result, nil
+}
+
+// escapeLiterals escapes every ${...} in s that isn't a v3 reference so it
+// is kept literally, returning whether anything was escaped. References are
+// matched by grammar only, whether they resolve is not considered.
+func escapeLiterals(s string) (string, bool) {
+	escaped := false
+	result := interpolationRgx.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" || referenceRgx.MatchString(match[2:len(match)-1]) {
+			return match
+		}
+		escaped = true
+		return "$" + match
+	})
+	return result, escaped
+}
+
+// private
+func (i *Interpolator) resolve(ref string) (string, error) {
+	if ref == projectDirRef {
+		return i.projectDir, nil
+	}
+
+	parts := strings.SplitN(ref, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+	}
+
+	resolver, ok := i.resolvers[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown variable source ${%s}", ref)
+	}
+
+	value, ok := resolver(parts[1])
+	if !ok {
+		return "", fmt.Errorf("unresolved variable ${%s}", ref)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,61 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/core"
+)
+
+func TestInterpolator(t *testing.T) {
+	vars := map[string]string{"region": "eu", "ssid": "home"}
+
+	t.Run("resolves env, var, and project references", func(st *testing.T) {
+		st.Setenv("ARDI_TEST_SSID", "office")
+		i := core.NewInterpolator("/projects/weather", vars)
+
+		value, err := i.Interpolate(`-DSSID="${env:ARDI_TEST_SSID}" -DREGION=${var:region} -I${project.dir}/include`)
+		assert.NoError(st, err)
+		assert.Equal(st, `-DSSID="office" -DREGION=eu -I/projects/weather/include`, value)
+	})
+
+	t.Run("leaves strings without references untouched", func(st *testing.T) {
+		i := core.NewInterpolator("/projects/weather", vars)
+		value, err := i.Interpolate("arduino:avr:mega")
+		assert.NoError(st, err)
+		assert.Equal(st, "arduino:avr:mega", value)
+	})
+
+	t.Run("supports escaping references", func(st *testing.T) {
+		i := core.NewInterpolator("/projects/weather", vars)
+		value, err := i.Interpolate("$${var:region}")
+		assert.NoError(st, err)
+		assert.Equal(st, "${var:region}", value)
+	})
+
+	t.Run("errors on unresolved references", func(st *testing.T) {
+		i := core.NewInterpolator("/projects/weather", vars)
+
+		_, err := i.Interpolate("${var:noop}")
+		assert.EqualError(st, err, `unresolved variable ${var:noop} in "${var:noop}"`)
+
+		_, err = i.Interpolate("${env:ARDI_TEST_UNSET_VARIABLE}")
+		assert.Error(st, err)
+
+		_, err = i.Interpolate("${noop:region}")
+		assert.Error(st, err)
+
+		_, err = i.Interpolate("${region}")
+		assert.Error(st, err)
+	})
+
+	t.Run("resolves registered sources", func(st *testing.T) {
+		i := core.NewInterpolator("/projects/weather", vars).WithResolver("custom", func(name string) (string, bool) {
+			return "custom-" + name, true
+		})
+		value, err := i.Interpolate("${custom:value}")
+		assert.NoError(st, err)
+		assert.Equal(st, "custom-value", value)
+	})
+}
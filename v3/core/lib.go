@@ -2,13 +2,15 @@ package core
 
 import (
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
-	"text/tabwriter"
 
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	log "github.com/sirupsen/logrus"
 
 	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/types"
 )
 
 // LibCore core module for lib commands
@@ -43,25 +45,22 @@ func WithLibCliWrapper(wrapper *cli.Wrapper) LibCoreOption {
 }
 
 // Search all available libraries with optional search filter
-func (c *LibCore) Search(searchArg string) error {
+func (c *LibCore) Search(searchArg string) ([]types.SearchedLibrary, error) {
 	c.init()
 
 	libraries, err := c.cli.SearchLibraries(searchArg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(libraries) == 0 {
-		return fmt.Errorf("no libraries found for %s", searchArg)
+		return nil, fmt.Errorf("no libraries found for %s", searchArg)
 	}
 
 	sort.Slice(libraries, func(i, j int) bool {
 		return libraries[i].GetName() < libraries[j].GetName()
 	})
 
-	w := tabwriter.NewWriter(c.logger.Out, 0, 0, 8, ' ', 0)
-	defer w.Flush()
-
-	w.Write([]byte("Library\tLatest\tOther Releases\n"))
+	results := []types.SearchedLibrary{}
 	for _, lib := range libraries {
 		releases := []string{}
 		for _, rel := range lib.GetReleases() {
@@ -70,18 +69,52 @@ func (c *LibCore) Search(searchArg string) error {
 		sort.Slice(releases, func(i, j int) bool {
 			return releases[i] > releases[j]
 		})
-		if len(releases) > 1 {
-			releases = releases[1:]
-		} else {
-			releases = []string{}
+		results = append(results, types.SearchedLibrary{
+			Name:     lib.GetName(),
+			Latest:   lib.GetLatest().GetVersion(),
+			Releases: releases,
+		})
+	}
+	return results, nil
+}
+
+// ResolveIncludes maps each header to the library in the library index that
+// provides it. When several libraries provide a header the library named
+// after the header is used, if none is the header is ambiguous and, like
+// headers not provided by any library, is returned without a library.
+func (c *LibCore) ResolveIncludes(headers []string) ([]types.SketchInclude, error) {
+	c.init()
+
+	libraries, err := c.cli.SearchLibraries("")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(libraries, func(i, j int) bool {
+		return libraries[i].GetName() < libraries[j].GetName()
+	})
+
+	providers := make(map[string][]*rpc.SearchedLibrary)
+	for _, lib := range libraries {
+		for _, include := range lib.GetLatest().GetProvidesIncludes() {
+			providers[include] = append(providers[include], lib)
 		}
-		if len(releases) > 4 {
-			releases = releases[:4]
-			releases = append(releases, "...")
+	}
+
+	results := []types.SketchInclude{}
+	for _, header := range headers {
+		result := types.SketchInclude{Include: header}
+		lib, ambiguous := providingLibrary(header, providers[header], libraries)
+		if lib != nil {
+			result.Library = lib.GetName()
+			result.Version = lib.GetLatest().GetVersion()
+		} else if len(ambiguous) > 0 {
+			c.logger.WithField("include", header).Warnf("Include is provided by several libraries, add the intended one with 'ardi add lib': %s", strings.Join(ambiguous, ", "))
 		}
-		w.Write([]byte(fmt.Sprintf("%s\t%s\t%s\n", lib.GetName(), lib.GetLatest().GetVersion(), strings.Join(releases, ", "))))
+		results = append(results, result)
 	}
-	return nil
+
+	return results, nil
 }
 
 // Add library for project
@@ -114,30 +147,71 @@ func (c *LibCore) Remove(library string) error {
 	return nil
 }
 
-// ListInstalled lists all installed libraries
-func (c *LibCore) ListInstalled() error {
+// ListInstalled returns all installed libraries
+func (c *LibCore) ListInstalled() ([]types.InstalledLibrary, error) {
 	libs, err := c.cli.GetInstalledLibs()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	w := tabwriter.NewWriter(c.logger.Out, 0, 0, 8, ' ', 0)
-	defer w.Flush()
+	updatable, err := c.cli.GetUpdatableLibs()
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]string)
+	for _, l := range updatable {
+		latest[l.GetLibrary().GetName()] = l.GetRelease().GetVersion()
+	}
 
-	w.Write([]byte("Library\tVersion\tDescription\n"))
+	installed := []types.InstalledLibrary{}
 	for _, l := range libs {
 		library := l.GetLibrary()
-		name := library.GetName()
-		version := library.Version
-		desc := library.GetSentence()
-		fields := fmt.Sprintf("%s\t%s\t%s\n", name, version, desc)
-		w.Write([]byte(fields))
+		installed = append(installed, types.InstalledLibrary{
+			Name:        library.GetName(),
+			Version:     library.GetVersion(),
+			Description: library.GetSentence(),
+			Latest:      latest[library.GetName()],
+		})
 	}
 
-	return nil
+	return installed, nil
 }
 
 // private
+// providingLibrary returns the library providing header, or the names of
+// the candidates if several provide it and none is named after it
+func providingLibrary(header string, candidates, libraries []*rpc.SearchedLibrary) (*rpc.SearchedLibrary, []string) {
+	base := strings.TrimSuffix(header, filepath.Ext(header))
+	namedAfter := func(lib *rpc.SearchedLibrary) bool {
+		return strings.EqualFold(strings.ReplaceAll(lib.GetName(), " ", "_"), base)
+	}
+
+	for _, lib := range candidates {
+		if namedAfter(lib) {
+			return lib, nil
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	if len(candidates) > 1 {
+		names := []string{}
+		for _, lib := range candidates {
+			names = append(names, lib.GetName())
+		}
+		return nil, names
+	}
+
+	// not every library in the index lists the headers it provides
+	for _, lib := range libraries {
+		if namedAfter(lib) {
+			return lib, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (c *LibCore) init() error {
 	if !c.initialized {
 		if err := c.cli.UpdateLibraryIndex(); err != nil {
@@ -8,6 +8,7 @@ import (
 	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
 	"github.com/golang/mock/gomock"
 	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -97,7 +98,7 @@ func TestLibCore(t *testing.T) {
 		assert.EqualError(env.T, err, errString)
 	})
 
-	testutil.RunUnitTest("prints library searches to stdout", t, func(env *testutil.UnitTestEnv) {
+	testutil.RunUnitTest("returns library search results", t, func(env *testutil.UnitTestEnv) {
 		searchQuery := "wifi101"
 
 		latest := rpc.LibraryRelease{Version: "1.2.1"}
@@ -125,13 +126,64 @@ func TestLibCore(t *testing.T) {
 		env.ArduinoCli.EXPECT().UpdateLibrariesIndex(gomock.Any(), gomock.Any(), gomock.Any())
 		env.ArduinoCli.EXPECT().LibrarySearch(gomock.Any(), req).Return(resp, nil)
 
-		err := env.ArdiCore.Lib.Search(searchQuery)
+		results, err := env.ArdiCore.Lib.Search(searchQuery)
 		assert.NoError(env.T, err)
 
-		assert.Contains(env.T, env.Stdout.String(), lib.Name)
+		assert.Equal(env.T, 1, len(results))
+		assert.Equal(env.T, lib.Name, results[0].Name)
+		assert.Equal(env.T, latest.Version, results[0].Latest)
+		assert.Equal(env.T, []string{latest.Version}, results[0].Releases)
 	})
 
-	testutil.RunUnitTest("prints installed libraries to stdout", t, func(env *testutil.UnitTestEnv) {
+	testutil.RunUnitTest("resolves includes to providing libraries", t, func(env *testutil.UnitTestEnv) {
+		instance := &rpc.Instance{Id: int32(1)}
+		req := &rpc.LibrarySearchRequest{
+			Instance: instance,
+		}
+		resp := &rpc.LibrarySearchResponse{
+			Libraries: []*rpc.SearchedLibrary{
+				{
+					Name:   "BME280 Fork",
+					Latest: &rpc.LibraryRelease{Version: "1.0.0", ProvidesIncludes: []string{"Adafruit_BME280.h"}},
+				},
+				{
+					Name:   "Adafruit BME280",
+					Latest: &rpc.LibraryRelease{Version: "2.2.2", ProvidesIncludes: []string{"Adafruit_BME280.h"}},
+				},
+				{
+					Name:   "Display Kit",
+					Latest: &rpc.LibraryRelease{Version: "0.3.0", ProvidesIncludes: []string{"Display.h"}},
+				},
+				{
+					Name:   "U8g2lib",
+					Latest: &rpc.LibraryRelease{Version: "2.34.4"},
+				},
+				{
+					Name:   "Sensor Kit",
+					Latest: &rpc.LibraryRelease{Version: "1.1.0", ProvidesIncludes: []string{"Wire.h"}},
+				},
+				{
+					Name:   "Wire Helpers",
+					Latest: &rpc.LibraryRelease{Version: "0.1.0", ProvidesIncludes: []string{"Wire.h"}},
+				},
+			},
+		}
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().UpdateLibrariesIndex(gomock.Any(), gomock.Any(), gomock.Any())
+		env.ArduinoCli.EXPECT().LibrarySearch(gomock.Any(), req).Return(resp, nil)
+
+		includes, err := env.ArdiCore.Lib.ResolveIncludes([]string{"Adafruit_BME280.h", "Display.h", "U8g2lib.h", "Wire.h", "Arduino.h"})
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []types.SketchInclude{
+			{Include: "Adafruit_BME280.h", Library: "Adafruit BME280", Version: "2.2.2"},
+			{Include: "Display.h", Library: "Display Kit", Version: "0.3.0"},
+			{Include: "U8g2lib.h", Library: "U8g2lib", Version: "2.34.4"},
+			{Include: "Wire.h"},
+			{Include: "Arduino.h"},
+		}, includes)
+	})
+
+	testutil.RunUnitTest("returns installed libraries", t, func(env *testutil.UnitTestEnv) {
 		installedLib := rpc.InstalledLibrary{
 			Library: &rpc.Library{
 				Name:     "My favorite library",
@@ -155,13 +207,29 @@ func TestLibCore(t *testing.T) {
 				},
 			},
 		}
+		// arduino-cli only sets the release when listing updatable libraries
+		updatableReq := &rpc.LibraryListRequest{
+			Instance:  instance,
+			Updatable: true,
+		}
+		updatableResp := &rpc.LibraryListResponse{
+			InstalledLibraries: []*rpc.InstalledLibrary{
+				{
+					Library: &rpc.Library{Name: installedLib.Library.Name, Version: installedLib.Library.Version},
+					Release: &rpc.LibraryRelease{Version: "1.3.0"},
+				},
+			},
+		}
 		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
 		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), req).Return(resp, nil)
+		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), updatableReq).Return(updatableResp, nil)
 
-		env.ArdiCore.Lib.ListInstalled()
-		stdout := env.Stdout.String()
-		assert.Contains(env.T, stdout, installedLib.Library.Name)
-		assert.Contains(env.T, stdout, installedLib.Library.Version)
-		assert.Contains(env.T, stdout, installedLib.Library.Sentence)
+		libs, err := env.ArdiCore.Lib.ListInstalled()
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, 1, len(libs))
+		assert.Equal(env.T, installedLib.Library.Name, libs[0].Name)
+		assert.Equal(env.T, installedLib.Library.Version, libs[0].Version)
+		assert.Equal(env.T, installedLib.Library.Sentence, libs[0].Description)
+		assert.Equal(env.T, "1.3.0", libs[0].Latest)
 	})
 }
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LogStream is an io.Writer that logs each line written to it as an
+// individual log entry, preserving the entry's fields
+type LogStream struct {
+	entry *log.Entry
+	level log.Level
+	buf   bytes.Buffer
+	mux   sync.Mutex
+}
+
+// NewLogStream returns a LogStream that logs lines at the given level
+func NewLogStream(entry *log.Entry, level log.Level) *LogStream {
+	return &LogStream{
+		entry: entry,
+		level: level,
+	}
+}
+
+// Write buffers p and logs every complete line
+func (s *LogStream) Write(p []byte) (int, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.buf.Write(p)
+
+	for {
+		idx := bytes.IndexByte(s.buf.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(s.buf.Next(idx + 1))
+		s.log(line)
+	}
+
+	return len(p), nil
+}
+
+// Close logs any remaining partial line
+func (s *LogStream) Close() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.buf.Len() > 0 {
+		s.log(s.buf.String())
+		s.buf.Reset()
+	}
+
+	return nil
+}
+
+// private
+func (s *LogStream) log(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	s.entry.Log(s.level, line)
+}
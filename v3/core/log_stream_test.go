@@ -0,0 +1,39 @@
+package core_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/core"
+)
+
+func TestLogStream(t *testing.T) {
+	t.Run("logs each line with entry fields", func(st *testing.T) {
+		var b bytes.Buffer
+		logger := log.New()
+		logger.Out = &b
+		logger.SetFormatter(&log.JSONFormatter{})
+
+		entry := logger.WithField("build", "release")
+		stream := core.NewLogStream(entry, log.WarnLevel)
+
+		io.WriteString(stream, "first li")
+		io.WriteString(stream, "ne\n\nsecond line\nthird")
+		assert.NotContains(st, b.String(), "third")
+
+		err := stream.Close()
+		assert.NoError(st, err)
+
+		out := b.String()
+		assert.Equal(st, 3, bytes.Count(b.Bytes(), []byte("\n")))
+		assert.Contains(st, out, `"msg":"first line"`)
+		assert.Contains(st, out, `"msg":"second line"`)
+		assert.Contains(st, out, `"msg":"third"`)
+		assert.Contains(st, out, `"build":"release"`)
+		assert.Contains(st, out, `"level":"warning"`)
+	})
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+const (
+	// MigrationChanged kind of migration change that modified the project
+	MigrationChanged = "changed"
+	// MigrationWarning kind of migration change that requires attention
+	MigrationWarning = "warning"
+)
+
+// V2Markers returns the parts of ardi.json that only ardi v2 writes, such as
+// null collections, builds without a directory, absolute build paths, and
+// ${ that isn't a v3 reference. An empty result means the config is
+// already in v3 shape.
+func (a *ArdiConfig) V2Markers() []string {
+	markers := []string{}
+
+	if a.config.Platforms == nil {
+		markers = append(markers, "null platforms")
+	}
+	if a.config.BoardURLS == nil {
+		markers = append(markers, "null boardUrls")
+	}
+	if a.config.Libraries == nil {
+		markers = append(markers, "null libraries")
+	}
+	if a.config.Builds == nil {
+		markers = append(markers, "null builds")
+	}
+
+	for _, name := range sortedBuildNames(a.config.Builds) {
+		build := a.config.Builds[name]
+		target := "build " + name
+
+		if build.Directory == "" && build.Extends == "" {
+			markers = append(markers, target+" has no directory")
+		}
+		if filepath.IsAbs(build.Directory) && a.projectRelative(build.Directory) != build.Directory {
+			markers = append(markers, target+" has an absolute directory path")
+		}
+		if filepath.IsAbs(build.Sketch) && a.projectRelative(build.Sketch) != build.Sketch {
+			markers = append(markers, target+" has an absolute sketch path")
+		}
+
+		fields := map[string]string{"fqbn": build.FQBN}
+		for prop, value := range build.Props {
+			fields[prop] = value
+		}
+		for _, field := range util.SortedKeys(fields) {
+			if _, ok := escapeLiterals(fields[field]); ok {
+				markers = append(markers, fmt.Sprintf("%s has a literal ${ in %s", target, field))
+			}
+		}
+	}
+
+	return markers
+}
+
+// MigrateV2 updates an ardi.json written by ardi v2 to v3 semantics and
+// returns the changes made along with any issues that need attention.
+// Unpinned platforms and libraries are pinned to the versions installed by
+// v2 so the project compiles identically after dependencies are reinstalled.
+func (a *ArdiConfig) MigrateV2(installedPlatforms, installedLibraries map[string]string) ([]types.MigrationChange, error) {
+	changes := []types.MigrationChange{}
+	record := func(kind, target, change string, args ...interface{}) {
+		changes = append(changes, types.MigrationChange{
+			Target: target,
+			Kind:   kind,
+			Change: fmt.Sprintf(change, args...),
+		})
+	}
+
+	if a.config.Platforms == nil {
+		a.config.Platforms = make(map[string]string)
+		record(MigrationChanged, "ardi.json", "replaced null platforms with an empty map")
+	}
+	if a.config.BoardURLS == nil {
+		a.config.BoardURLS = []string{}
+		record(MigrationChanged, "ardi.json", "replaced null boardUrls with an empty list")
+	}
+	if a.config.Libraries == nil {
+		a.config.Libraries = make(map[string]string)
+		record(MigrationChanged, "ardi.json", "replaced null libraries with an empty map")
+	}
+	if a.config.Builds == nil {
+		a.config.Builds = make(map[string]types.ArdiBuild)
+		record(MigrationChanged, "ardi.json", "replaced null builds with an empty map")
+	}
+
+	pin := func(kind string, deps, installed map[string]string) {
+		for _, name := range util.SortedKeys(deps) {
+			if deps[name] != "" {
+				continue
+			}
+			if version, ok := installed[name]; ok && version != "" {
+				deps[name] = version
+				record(MigrationChanged, kind+" "+name, "pinned to installed version %s", version)
+				continue
+			}
+			record(MigrationWarning, kind+" "+name, "not pinned and no installed version found, the latest version will be installed")
+		}
+	}
+	pin("platform", a.config.Platforms, installedPlatforms)
+	pin("library", a.config.Libraries, installedLibraries)
+
+	// v2 never interpolated values so anything that isn't a v3 reference
+	// was meant literally and is escaped
+	escape := func(target, field string, value *string) {
+		if escaped, ok := escapeLiterals(*value); ok {
+			*value = escaped
+			record(MigrationChanged, target, "escaped literal ${ in %s", field)
+		}
+	}
+
+	for _, name := range sortedBuildNames(a.config.Builds) {
+		build := a.config.Builds[name]
+		target := "build " + name
+
+		for _, field := range []struct {
+			name  string
+			value *string
+		}{
+			{"directory", &build.Directory},
+			{"sketch", &build.Sketch},
+		} {
+			if !filepath.IsAbs(*field.value) {
+				continue
+			}
+			if rel := a.projectRelative(*field.value); rel != *field.value {
+				record(MigrationChanged, target, "made %s relative to the project root", field.name)
+				*field.value = rel
+			}
+		}
+
+		if build.Directory == "" && build.Sketch != "" {
+			build.Directory = filepath.Dir(build.Sketch)
+			record(MigrationChanged, target, "set directory to the sketch directory %s", build.Directory)
+		}
+
+		escape(target, "directory", &build.Directory)
+		escape(target, "sketch", &build.Sketch)
+		escape(target, "fqbn", &build.FQBN)
+		props := make(map[string]string)
+		for _, prop := range util.SortedKeys(build.Props) {
+			value := build.Props[prop]
+			escape(target, prop, &value)
+			props[prop] = value
+		}
+		build.Props = props
+
+		if build.FQBN == "" {
+			record(MigrationWarning, target, "no fqbn set")
+		}
+		if build.Sketch == "" {
+			record(MigrationWarning, target, "no sketch set")
+		} else if _, err := os.Stat(a.resolvePath(build.Sketch)); err != nil {
+			record(MigrationWarning, target, "sketch %s not found", build.Sketch)
+		}
+
+		a.config.Builds[name] = build
+	}
+
+	return changes, a.write()
+}
+
+// private helpers
+func sortedBuildNames(builds map[string]types.ArdiBuild) []string {
+	names := []string{}
+	for name := range builds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
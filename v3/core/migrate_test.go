@@ -0,0 +1,126 @@
+package core_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArdiConfigMigrateV2(t *testing.T) {
+	newProject := func(env *testutil.UnitTestEnv, newConfig func(root string) types.ArdiConfig) (string, *core.ArdiConfig) {
+		root := env.T.TempDir()
+		sketch := path.Join(root, "blink", "blink.ino")
+		assert.NoError(env.T, os.MkdirAll(path.Dir(sketch), 0755))
+		assert.NoError(env.T, os.WriteFile(sketch, []byte("void setup() {}\n"), 0644))
+		return root, core.NewArdiConfig(path.Join(root, "ardi.json"), newConfig(root), env.Logger)
+	}
+
+	testutil.RunUnitTest("pins dependencies to installed versions", t, func(env *testutil.UnitTestEnv) {
+		root, config := newProject(env, func(root string) types.ArdiConfig {
+			return types.ArdiConfig{
+				Platforms: map[string]string{"arduino:avr": "", "esp32:esp32": "2.0.9"},
+				Libraries: map[string]string{"Adafruit Pixie": "", "Servo": ""},
+			}
+		})
+
+		changes, err := config.MigrateV2(
+			map[string]string{"arduino:avr": "1.8.5", "esp32:esp32": "2.0.5"},
+			map[string]string{"Adafruit Pixie": "1.0.3"},
+		)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []types.MigrationChange{
+			{Target: "ardi.json", Kind: core.MigrationChanged, Change: "replaced null boardUrls with an empty list"},
+			{Target: "ardi.json", Kind: core.MigrationChanged, Change: "replaced null builds with an empty map"},
+			{Target: "platform arduino:avr", Kind: core.MigrationChanged, Change: "pinned to installed version 1.8.5"},
+			{Target: "library Adafruit Pixie", Kind: core.MigrationChanged, Change: "pinned to installed version 1.0.3"},
+			{Target: "library Servo", Kind: core.MigrationWarning, Change: "not pinned and no installed version found, the latest version will be installed"},
+		}, changes)
+
+		written, err := util.ReadArdiConfig(path.Join(root, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"arduino:avr": "1.8.5", "esp32:esp32": "2.0.9"}, written.Platforms)
+		assert.Equal(env.T, map[string]string{"Adafruit Pixie": "1.0.3", "Servo": ""}, written.Libraries)
+	})
+
+	testutil.RunUnitTest("updates builds", t, func(env *testutil.UnitTestEnv) {
+		_, config := newProject(env, func(root string) types.ArdiConfig {
+			config := util.GenArdiConfig()
+			config.Builds = map[string]types.ArdiBuild{
+				"blink": {
+					Sketch: path.Join(root, "blink", "blink.ino"),
+					FQBN:   "arduino:avr:uno",
+					Props: map[string]string{
+						"build.extra_flags":        "-DNAME=${NAME}",
+						"compiler.cpp.extra_flags": "-I${project.dir}/include",
+						"compiler.c.extra_flags":   "-DKEY=${env:ARDI_MIGRATE_UNSET} -DTOKEN=${secret:token}",
+					},
+				},
+				"missing": {Directory: "missing", Sketch: "missing/missing.ino"},
+			}
+			return *config
+		})
+
+		changes, err := config.MigrateV2(nil, nil)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []types.MigrationChange{
+			{Target: "build blink", Kind: core.MigrationChanged, Change: "made sketch relative to the project root"},
+			{Target: "build blink", Kind: core.MigrationChanged, Change: "set directory to the sketch directory blink"},
+			{Target: "build blink", Kind: core.MigrationChanged, Change: "escaped literal ${ in build.extra_flags"},
+			{Target: "build missing", Kind: core.MigrationWarning, Change: "no fqbn set"},
+			{Target: "build missing", Kind: core.MigrationWarning, Change: "sketch missing/missing.ino not found"},
+		}, changes)
+
+		build := config.GetBuilds()["blink"]
+		assert.Equal(env.T, "blink", build.Directory)
+		assert.Equal(env.T, "blink/blink.ino", build.Sketch)
+		assert.Equal(env.T, "-DNAME=$${NAME}", build.Props["build.extra_flags"])
+		assert.Equal(env.T, "-I${project.dir}/include", build.Props["compiler.cpp.extra_flags"])
+		assert.Equal(env.T, "-DKEY=${env:ARDI_MIGRATE_UNSET} -DTOKEN=${secret:token}", build.Props["compiler.c.extra_flags"])
+	})
+
+	testutil.RunUnitTest("reports v2 markers", t, func(env *testutil.UnitTestEnv) {
+		_, config := newProject(env, func(root string) types.ArdiConfig {
+			config := util.GenArdiConfig()
+			config.BoardURLS = nil
+			config.Builds = map[string]types.ArdiBuild{
+				"blink": {
+					Sketch: path.Join(root, "blink", "blink.ino"),
+					FQBN:   "arduino:avr:uno",
+					Props:  map[string]string{"build.extra_flags": "-DNAME=${NAME}"},
+				},
+			}
+			return *config
+		})
+
+		assert.Equal(env.T, []string{
+			"null boardUrls",
+			"build blink has no directory",
+			"build blink has an absolute sketch path",
+			"build blink has a literal ${ in build.extra_flags",
+		}, config.V2Markers())
+	})
+
+	testutil.RunUnitTest("reports no v2 markers for a v3 config", t, func(env *testutil.UnitTestEnv) {
+		_, config := newProject(env, func(root string) types.ArdiConfig {
+			config := util.GenArdiConfig()
+			config.Builds = map[string]types.ArdiBuild{
+				"blink": {
+					Directory: "blink",
+					Sketch:    "blink/blink.ino",
+					FQBN:      "arduino:avr:uno",
+					Props:     map[string]string{"build.extra_flags": "-DNAME=${env:NAME}"},
+				},
+				"debug": {Extends: "blink"},
+			}
+			return *config
+		})
+
+		assert.Empty(env.T, config.V2Markers())
+	})
+}
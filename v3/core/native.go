@@ -0,0 +1,358 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+//go:embed native/*
+var nativeShim embed.FS
+
+const nativeResultMarker = "##ardi-test##|"
+
+// NativeTestOpts represents the options for running host-native tests
+type NativeTestOpts struct {
+	Sketch   string
+	Sources  []string
+	Tests    []string
+	Compiler string
+	Flags    []string
+}
+
+// NativeTestResult represents the result of a single native test case
+type NativeTestResult struct {
+	Name     string
+	File     string
+	Passed   bool
+	Location string
+	Message  string
+}
+
+// NativeTestReport represents the results of a native test run
+type NativeTestReport struct {
+	Results  []NativeTestResult
+	Output   string
+	Duration time.Duration
+}
+
+// Failed returns the number of failed tests in the report
+func (r *NativeTestReport) Failed() int {
+	failed := 0
+	for _, res := range r.Results {
+		if !res.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// NativeTestCore represents core module for host-native unit tests
+type NativeTestCore struct {
+	logger       *log.Logger
+	librariesDir string
+}
+
+// NativeTestCoreOption represents options for the NativeTestCore
+type NativeTestCoreOption = func(c *NativeTestCore)
+
+// NewNativeTestCore instance of core module for host-native unit tests
+func NewNativeTestCore(logger *log.Logger, options ...NativeTestCoreOption) *NativeTestCore {
+	c := &NativeTestCore{
+		logger: logger,
+	}
+
+	for _, o := range options {
+		o(c)
+	}
+
+	return c
+}
+
+// WithNativeTestLibrariesDir sets the directory containing project libraries
+func WithNativeTestLibrariesDir(dir string) NativeTestCoreOption {
+	return func(c *NativeTestCore) {
+		c.librariesDir = dir
+	}
+}
+
+// Run compiles sketch sources and tests with the host compiler, runs the
+// resulting binary, and returns the parsed results
+func (c *NativeTestCore) Run(opts NativeTestOpts) (*NativeTestReport, error) {
+	project, err := util.ProcessSketch(opts.Sketch)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := opts.Sources
+	if len(sources) == 0 {
+		if sources, err = findNativeSources(path.Join(project.Directory, "src")); err != nil {
+			return nil, err
+		}
+	}
+
+	tests := opts.Tests
+	if len(tests) == 0 {
+		if tests, err = findNativeSources(path.Join(project.Directory, "test")); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tests) == 0 {
+		return nil, fmt.Errorf("no native test files found for sketch %s", project.Sketch)
+	}
+
+	compiler := opts.Compiler
+	if compiler == "" {
+		compiler = "g++"
+	}
+
+	workDir, err := ioutil.TempDir("", "ardi-native-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	shimDir := path.Join(workDir, "shim")
+	if err := writeNativeShim(shimDir); err != nil {
+		return nil, err
+	}
+
+	binary := path.Join(workDir, "ardi-native-tests")
+
+	args := []string{"-std=c++11", "-DARDI_NATIVE", "-o", binary}
+	for _, inc := range c.includeDirs(shimDir, project.Directory) {
+		args = append(args, "-I", inc)
+	}
+	args = append(args, opts.Flags...)
+	args = append(args, path.Join(shimDir, "ardi_native.cpp"))
+	args = append(args, sources...)
+	args = append(args, tests...)
+
+	fields := log.Fields{"sketch": project.Sketch, "compiler": compiler}
+	c.logger.WithFields(fields).Info("Compiling native tests...")
+	c.logger.Debugf("%s %s", compiler, strings.Join(args, " "))
+
+	var compileOut bytes.Buffer
+	compile := exec.Command(compiler, args...)
+	compile.Stdout = &compileOut
+	compile.Stderr = &compileOut
+	if err := compile.Run(); err != nil {
+		c.logger.WithFields(fields).Error(compileOut.String())
+		return nil, fmt.Errorf("failed to compile native tests: %w", err)
+	}
+
+	c.logger.WithFields(fields).Info("Running native tests...")
+
+	var runOut bytes.Buffer
+	start := time.Now()
+	run := exec.Command(binary)
+	run.Dir = project.Directory
+	run.Stdout = &runOut
+	run.Stderr = &runOut
+	runErr := run.Run()
+
+	report := parseNativeOutput(&runOut)
+	report.Duration = time.Since(start)
+
+	// the test runner exits with 1 when tests fail, any other exit such as
+	// a crash after a failed test is an error
+	var exitErr *exec.ExitError
+	failedExit := errors.As(runErr, &exitErr) && exitErr.ExitCode() == 1 && report.Failed() > 0
+	if runErr != nil && !failedExit {
+		return report, fmt.Errorf("failed to run native tests: %w", runErr)
+	}
+
+	return report, nil
+}
+
+// WriteTAP writes the report in Test Anything Protocol format
+func (r *NativeTestReport) WriteTAP(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	fmt.Fprintln(buf, "TAP version 13")
+	fmt.Fprintf(buf, "1..%d\n", len(r.Results))
+	for i, res := range r.Results {
+		if res.Passed {
+			fmt.Fprintf(buf, "ok %d - %s\n", i+1, res.Name)
+			continue
+		}
+		fmt.Fprintf(buf, "not ok %d - %s\n", i+1, res.Name)
+		fmt.Fprintln(buf, "  ---")
+		fmt.Fprintf(buf, "  message: %q\n", res.Message)
+		fmt.Fprintf(buf, "  at: %q\n", res.Location)
+		fmt.Fprintln(buf, "  ...")
+	}
+	return buf.Flush()
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+// WriteJUnit writes the report as a JUnit XML test suite
+func (r *NativeTestReport) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{
+		Name:      "ardi-native",
+		Tests:     len(r.Results),
+		Failures:  r.Failed(),
+		Time:      fmt.Sprintf("%.3f", r.Duration.Seconds()),
+		SystemOut: r.Output,
+	}
+	for _, res := range r.Results {
+		tc := junitTestCase{
+			Name:      res.Name,
+			ClassName: strings.TrimSuffix(filepath.Base(res.File), filepath.Ext(res.File)),
+		}
+		if !res.Passed {
+			tc.Failure = &junitFailure{
+				Message: res.Message,
+				Body:    res.Location,
+			}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// private
+func (c *NativeTestCore) includeDirs(shimDir, sketchDir string) []string {
+	dirs := []string{shimDir, sketchDir}
+	if stat, err := os.Stat(path.Join(sketchDir, "src")); err == nil && stat.IsDir() {
+		dirs = append(dirs, path.Join(sketchDir, "src"))
+	}
+
+	if c.librariesDir == "" {
+		return dirs
+	}
+
+	libs, err := ioutil.ReadDir(c.librariesDir)
+	if err != nil {
+		return dirs
+	}
+
+	for _, lib := range libs {
+		if !lib.IsDir() {
+			continue
+		}
+		libDir := path.Join(c.librariesDir, lib.Name())
+		if stat, err := os.Stat(path.Join(libDir, "src")); err == nil && stat.IsDir() {
+			dirs = append(dirs, path.Join(libDir, "src"))
+		} else {
+			dirs = append(dirs, libDir)
+		}
+	}
+
+	return dirs
+}
+
+// private helpers
+func writeNativeShim(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return fs.WalkDir(nativeShim, "native", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := nativeShim.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path.Join(dir, d.Name()), data, 0644)
+	})
+}
+
+func findNativeSources(dir string) ([]string, error) {
+	sources := []string{}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return sources, nil
+	}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch filepath.Ext(p) {
+		case ".c", ".cc", ".cpp":
+			if info.Mode().IsRegular() {
+				sources = append(sources, p)
+			}
+		}
+		return nil
+	})
+	return sources, err
+}
+
+func parseNativeOutput(r io.Reader) *NativeTestReport {
+	report := &NativeTestReport{}
+	output := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, nativeResultMarker) {
+			output = append(output, line)
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(line, nativeResultMarker), "|", 4)
+		if len(parts) < 4 {
+			output = append(output, line)
+			continue
+		}
+		res := NativeTestResult{
+			Name:    parts[1],
+			Passed:  parts[0] == "pass",
+			Message: strings.TrimSuffix(parts[3], "|"),
+		}
+		if res.Passed {
+			res.File = parts[2]
+		} else {
+			res.Location = parts[2]
+			res.File = strings.Split(parts[2], ":")[0]
+		}
+		report.Results = append(report.Results, res)
+	}
+	report.Output = strings.Join(output, "\n")
+	return report
+}
@@ -0,0 +1,102 @@
+/*
+  Minimal unit test harness for ardi host-native tests.
+
+  Usage:
+
+    #include <ArdiTest.h>
+    #include "calc.h"
+
+    TEST(adds_numbers) {
+      ASSERT_EQ(4, add(2, 2));
+    }
+
+  Every TEST registers itself and is run by the main() that ardi links into
+  the test binary. Results are printed as marker lines which ardi parses and
+  renders as TAP or JUnit.
+*/
+#ifndef ARDI_NATIVE_ARDI_TEST_H
+#define ARDI_NATIVE_ARDI_TEST_H
+
+#include <sstream>
+#include <string>
+
+#include "Arduino.h"
+
+namespace ardi {
+namespace test {
+
+typedef void (*TestFn)();
+
+struct Failure {
+  std::string file;
+  int line;
+  std::string message;
+};
+
+struct Registrar {
+  Registrar(const char *name, const char *file, TestFn fn);
+};
+
+template <typename T>
+std::string describe(const T &v) {
+  std::ostringstream ss;
+  ss << v;
+  return ss.str();
+}
+
+inline std::string describe(const String &v) { return "\"" + v.str() + "\""; }
+inline std::string describe(const char *v) { return v ? "\"" + std::string(v) + "\"" : "NULL"; }
+inline std::string describe(bool v) { return v ? "true" : "false"; }
+
+} // namespace test
+} // namespace ardi
+
+#define ARDI_TEST_FAIL(msg) \
+  throw ardi::test::Failure{__FILE__, __LINE__, (msg)}
+
+#define TEST(name)                                                      \
+  static void ardi_test_##name();                                       \
+  static ardi::test::Registrar ardi_test_registrar_##name(              \
+      #name, __FILE__, ardi_test_##name);                               \
+  static void ardi_test_##name()
+
+#define ASSERT_TRUE(cond)                                               \
+  do {                                                                  \
+    if (!(cond)) ARDI_TEST_FAIL("expected true: " #cond);               \
+  } while (0)
+
+#define ASSERT_FALSE(cond)                                              \
+  do {                                                                  \
+    if (cond) ARDI_TEST_FAIL("expected false: " #cond);                 \
+  } while (0)
+
+#define ASSERT_EQ(expected, actual)                                     \
+  do {                                                                  \
+    const auto &ardi_e = (expected);                                    \
+    const auto &ardi_a = (actual);                                      \
+    if (!(ardi_e == ardi_a))                                            \
+      ARDI_TEST_FAIL("expected " + ardi::test::describe(ardi_e) +       \
+                     " but got " + ardi::test::describe(ardi_a) +       \
+                     ": " #actual);                                     \
+  } while (0)
+
+#define ASSERT_NE(unexpected, actual)                                   \
+  do {                                                                  \
+    const auto &ardi_u = (unexpected);                                  \
+    const auto &ardi_a = (actual);                                      \
+    if (ardi_u == ardi_a)                                               \
+      ARDI_TEST_FAIL("did not expect " + ardi::test::describe(ardi_a) + \
+                     ": " #actual);                                     \
+  } while (0)
+
+#define ASSERT_NEAR(expected, actual, epsilon)                          \
+  do {                                                                  \
+    double ardi_d = (double)(expected) - (double)(actual);              \
+    if (ardi_d < 0) ardi_d = -ardi_d;                                   \
+    if (ardi_d > (double)(epsilon))                                     \
+      ARDI_TEST_FAIL("expected " + ardi::test::describe(expected) +     \
+                     " +/- " + ardi::test::describe(epsilon) +          \
+                     " but got " + ardi::test::describe(actual));       \
+  } while (0)
+
+#endif
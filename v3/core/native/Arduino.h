@@ -0,0 +1,174 @@
+/*
+  Minimal host-native Arduino API shim bundled with ardi.
+
+  Provides just enough of the Arduino core (timing, pins, String and Serial)
+  to compile and unit test pure-logic sketch code with the host compiler. It
+  is not an emulator: pins hold the last written value and time only moves
+  when delay() or ardi::native::advanceMillis() is called.
+*/
+#ifndef ARDI_NATIVE_ARDUINO_H
+#define ARDI_NATIVE_ARDUINO_H
+
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+#include <math.h>
+#include <string>
+
+typedef uint8_t byte;
+typedef bool boolean;
+typedef uint16_t word;
+
+#define HIGH 0x1
+#define LOW 0x0
+
+#define INPUT 0x0
+#define OUTPUT 0x1
+#define INPUT_PULLUP 0x2
+
+#ifndef LED_BUILTIN
+#define LED_BUILTIN 13
+#endif
+
+#define DEC 10
+#define HEX 16
+#define OCT 8
+#define BIN 2
+
+#define ARDI_NATIVE_MAX_PINS 64
+
+namespace ardi {
+namespace native {
+void setMillis(unsigned long ms);
+void advanceMillis(unsigned long ms);
+void setPin(uint8_t pin, int value);
+int getPin(uint8_t pin);
+int getPinMode(uint8_t pin);
+void reset();
+} // namespace native
+} // namespace ardi
+
+unsigned long millis();
+unsigned long micros();
+void delay(unsigned long ms);
+void delayMicroseconds(unsigned int us);
+
+void pinMode(uint8_t pin, uint8_t mode);
+void digitalWrite(uint8_t pin, uint8_t value);
+int digitalRead(uint8_t pin);
+int analogRead(uint8_t pin);
+void analogWrite(uint8_t pin, int value);
+
+long random(long max);
+long random(long min, long max);
+void randomSeed(unsigned long seed);
+
+long map(long x, long inMin, long inMax, long outMin, long outMax);
+
+template <typename T>
+T constrain(T x, T low, T high) { return x < low ? low : (x > high ? high : x); }
+
+template <typename A, typename B>
+auto min(A a, B b) -> decltype(a < b ? a : b) { return a < b ? a : b; }
+
+template <typename A, typename B>
+auto max(A a, B b) -> decltype(a > b ? a : b) { return a > b ? a : b; }
+
+class String {
+public:
+  String() {}
+  String(const char *s) : value(s ? s : "") {}
+  String(const std::string &s) : value(s) {}
+  String(char c) : value(1, c) {}
+  String(int n, unsigned char base = DEC);
+  String(unsigned int n, unsigned char base = DEC);
+  String(long n, unsigned char base = DEC);
+  String(unsigned long n, unsigned char base = DEC);
+  String(double n, unsigned char decimals = 2);
+
+  unsigned int length() const { return value.length(); }
+  const char *c_str() const { return value.c_str(); }
+  char charAt(unsigned int i) const { return i < value.length() ? value[i] : 0; }
+  char operator[](unsigned int i) const { return charAt(i); }
+
+  bool concat(const String &s) { value += s.value; return true; }
+  String &operator+=(const String &s) { value += s.value; return *this; }
+  String &operator+=(const char *s) { value += s; return *this; }
+  String &operator+=(char c) { value += c; return *this; }
+
+  bool equals(const String &s) const { return value == s.value; }
+  bool equalsIgnoreCase(const String &s) const;
+  bool operator==(const String &s) const { return value == s.value; }
+  bool operator==(const char *s) const { return value == s; }
+  bool operator!=(const String &s) const { return value != s.value; }
+  bool operator!=(const char *s) const { return value != s; }
+  bool operator<(const String &s) const { return value < s.value; }
+
+  bool startsWith(const String &s) const { return value.compare(0, s.value.length(), s.value) == 0; }
+  bool endsWith(const String &s) const;
+  int indexOf(char c, unsigned int from = 0) const;
+  int indexOf(const String &s, unsigned int from = 0) const;
+  int lastIndexOf(char c) const;
+  String substring(unsigned int from) const;
+  String substring(unsigned int from, unsigned int to) const;
+
+  void toUpperCase();
+  void toLowerCase();
+  void trim();
+  void replace(const String &find, const String &with);
+
+  long toInt() const { return atol(value.c_str()); }
+  float toFloat() const { return (float)atof(value.c_str()); }
+
+  const std::string &str() const { return value; }
+
+private:
+  std::string value;
+};
+
+String operator+(const String &lhs, const String &rhs);
+String operator+(const String &lhs, const char *rhs);
+String operator+(const char *lhs, const String &rhs);
+
+class HardwareSerial {
+public:
+  void begin(unsigned long baud) { this->baud = baud; }
+  void end() {}
+  operator bool() const { return true; }
+
+  int available() const { return (int)(input.length() - readPos); }
+  int read();
+  int peek() const;
+  void flush() {}
+
+  size_t write(uint8_t c) { output += (char)c; return 1; }
+  size_t print(const String &s) { output += s.str(); return s.length(); }
+  size_t print(const char *s) { return print(String(s)); }
+  size_t print(char c) { return write((uint8_t)c); }
+  size_t print(int n, int base = DEC) { return print(String(n, base)); }
+  size_t print(unsigned int n, int base = DEC) { return print(String(n, base)); }
+  size_t print(long n, int base = DEC) { return print(String(n, base)); }
+  size_t print(unsigned long n, int base = DEC) { return print(String(n, base)); }
+  size_t print(double n, int decimals = 2) { return print(String(n, decimals)); }
+  size_t println() { return print("\r\n"); }
+  template <typename T>
+  size_t println(T v) { size_t n = print(v); return n + println(); }
+  template <typename T>
+  size_t println(T v, int fmt) { size_t n = print(v, fmt); return n + println(); }
+
+  // Test helpers: inspect what the code under test printed and feed it input
+  const std::string &ardiOutput() const { return output; }
+  void ardiClearOutput() { output.clear(); }
+  void ardiSetInput(const std::string &s) { input = s; readPos = 0; }
+  unsigned long ardiBaud() const { return baud; }
+
+private:
+  unsigned long baud = 0;
+  std::string output;
+  std::string input;
+  size_t readPos = 0;
+};
+
+extern HardwareSerial Serial;
+
+#endif
@@ -0,0 +1,202 @@
+/*
+  Implementation of the ardi host-native Arduino shim and test runner main().
+*/
+#include <stdio.h>
+#include <algorithm>
+#include <cctype>
+#include <vector>
+
+#include "ArdiTest.h"
+
+HardwareSerial Serial;
+
+namespace {
+unsigned long nowMicros = 0;
+int pinValues[ARDI_NATIVE_MAX_PINS];
+int pinModes[ARDI_NATIVE_MAX_PINS];
+
+std::string toBase(unsigned long n, unsigned char base) {
+  if (base < 2 || base > 16) base = DEC;
+  if (n == 0) return "0";
+  std::string out;
+  while (n > 0) {
+    out += "0123456789ABCDEF"[n % base];
+    n /= base;
+  }
+  std::reverse(out.begin(), out.end());
+  return out;
+}
+
+std::string signedToBase(long n, unsigned char base) {
+  if (n < 0 && base == DEC) return "-" + toBase((unsigned long)(-n), base);
+  return toBase((unsigned long)n, base);
+}
+
+struct TestCase {
+  const char *name;
+  const char *file;
+  ardi::test::TestFn fn;
+};
+
+std::vector<TestCase> &registry() {
+  static std::vector<TestCase> tests;
+  return tests;
+}
+
+// Replaces newlines so every result stays on a single marker line
+std::string oneLine(std::string s) {
+  std::replace(s.begin(), s.end(), '\n', ' ');
+  std::replace(s.begin(), s.end(), '\r', ' ');
+  return s;
+}
+} // namespace
+
+namespace ardi {
+namespace native {
+void setMillis(unsigned long ms) { nowMicros = ms * 1000UL; }
+void advanceMillis(unsigned long ms) { nowMicros += ms * 1000UL; }
+void setPin(uint8_t pin, int value) {
+  if (pin < ARDI_NATIVE_MAX_PINS) pinValues[pin] = value;
+}
+int getPin(uint8_t pin) { return pin < ARDI_NATIVE_MAX_PINS ? pinValues[pin] : 0; }
+int getPinMode(uint8_t pin) { return pin < ARDI_NATIVE_MAX_PINS ? pinModes[pin] : 0; }
+void reset() {
+  nowMicros = 0;
+  memset(pinValues, 0, sizeof(pinValues));
+  memset(pinModes, 0, sizeof(pinModes));
+  Serial.ardiClearOutput();
+  Serial.ardiSetInput("");
+}
+} // namespace native
+
+namespace test {
+Registrar::Registrar(const char *name, const char *file, TestFn fn) {
+  registry().push_back(TestCase{name, file, fn});
+}
+} // namespace test
+} // namespace ardi
+
+unsigned long millis() { return nowMicros / 1000UL; }
+unsigned long micros() { return nowMicros; }
+void delay(unsigned long ms) { ardi::native::advanceMillis(ms); }
+void delayMicroseconds(unsigned int us) { nowMicros += us; }
+
+void pinMode(uint8_t pin, uint8_t mode) {
+  if (pin < ARDI_NATIVE_MAX_PINS) pinModes[pin] = mode;
+}
+void digitalWrite(uint8_t pin, uint8_t value) { ardi::native::setPin(pin, value ? HIGH : LOW); }
+int digitalRead(uint8_t pin) { return ardi::native::getPin(pin) ? HIGH : LOW; }
+int analogRead(uint8_t pin) { return ardi::native::getPin(pin); }
+void analogWrite(uint8_t pin, int value) { ardi::native::setPin(pin, value); }
+
+long random(long max) { return max > 0 ? rand() % max : 0; }
+long random(long min, long max) { return min >= max ? min : min + random(max - min); }
+void randomSeed(unsigned long seed) { srand((unsigned int)seed); }
+
+long map(long x, long inMin, long inMax, long outMin, long outMax) {
+  return (x - inMin) * (outMax - outMin) / (inMax - inMin) + outMin;
+}
+
+String::String(int n, unsigned char base) : value(signedToBase(n, base)) {}
+String::String(unsigned int n, unsigned char base) : value(toBase(n, base)) {}
+String::String(long n, unsigned char base) : value(signedToBase(n, base)) {}
+String::String(unsigned long n, unsigned char base) : value(toBase(n, base)) {}
+String::String(double n, unsigned char decimals) {
+  char buf[64];
+  snprintf(buf, sizeof(buf), "%.*f", (int)decimals, n);
+  value = buf;
+}
+
+bool String::equalsIgnoreCase(const String &s) const {
+  if (value.length() != s.value.length()) return false;
+  for (size_t i = 0; i < value.length(); i++) {
+    if (tolower(value[i]) != tolower(s.value[i])) return false;
+  }
+  return true;
+}
+
+bool String::endsWith(const String &s) const {
+  if (s.value.length() > value.length()) return false;
+  return value.compare(value.length() - s.value.length(), s.value.length(), s.value) == 0;
+}
+
+int String::indexOf(char c, unsigned int from) const {
+  size_t i = value.find(c, from);
+  return i == std::string::npos ? -1 : (int)i;
+}
+
+int String::indexOf(const String &s, unsigned int from) const {
+  size_t i = value.find(s.value, from);
+  return i == std::string::npos ? -1 : (int)i;
+}
+
+int String::lastIndexOf(char c) const {
+  size_t i = value.rfind(c);
+  return i == std::string::npos ? -1 : (int)i;
+}
+
+String String::substring(unsigned int from) const {
+  return from >= value.length() ? String() : String(value.substr(from));
+}
+
+String String::substring(unsigned int from, unsigned int to) const {
+  if (from > to) std::swap(from, to);
+  if (from >= value.length()) return String();
+  return String(value.substr(from, to - from));
+}
+
+void String::toUpperCase() {
+  for (size_t i = 0; i < value.length(); i++) value[i] = (char)toupper(value[i]);
+}
+
+void String::toLowerCase() {
+  for (size_t i = 0; i < value.length(); i++) value[i] = (char)tolower(value[i]);
+}
+
+void String::trim() {
+  size_t start = value.find_first_not_of(" \t\r\n");
+  size_t end = value.find_last_not_of(" \t\r\n");
+  value = start == std::string::npos ? "" : value.substr(start, end - start + 1);
+}
+
+void String::replace(const String &find, const String &with) {
+  if (find.value.empty()) return;
+  size_t pos = 0;
+  while ((pos = value.find(find.value, pos)) != std::string::npos) {
+    value.replace(pos, find.value.length(), with.value);
+    pos += with.value.length();
+  }
+}
+
+String operator+(const String &lhs, const String &rhs) { return String(lhs.str() + rhs.str()); }
+String operator+(const String &lhs, const char *rhs) { return String(lhs.str() + rhs); }
+String operator+(const char *lhs, const String &rhs) { return String(lhs + rhs.str()); }
+
+int HardwareSerial::read() {
+  if (readPos >= input.length()) return -1;
+  return (unsigned char)input[readPos++];
+}
+
+int HardwareSerial::peek() const {
+  if (readPos >= input.length()) return -1;
+  return (unsigned char)input[readPos];
+}
+
+int main() {
+  int failed = 0;
+  for (const TestCase &t : registry()) {
+    ardi::native::reset();
+    try {
+      t.fn();
+      printf("##ardi-test##|pass|%s|%s||\n", t.name, t.file);
+    } catch (const ardi::test::Failure &f) {
+      failed++;
+      printf("##ardi-test##|fail|%s|%s:%d|%s|\n", t.name, f.file.c_str(), f.line, oneLine(f.message).c_str());
+    } catch (...) {
+      failed++;
+      printf("##ardi-test##|fail|%s|%s|uncaught exception|\n", t.name, t.file);
+    }
+    fflush(stdout);
+  }
+  return failed > 0 ? 1 : 0;
+}
@@ -0,0 +1,96 @@
+package core_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/testutil"
+)
+
+func TestNativeTestCore(t *testing.T) {
+	if _, err := exec.LookPath("g++"); err != nil {
+		t.Skip("g++ not available")
+	}
+
+	testutil.RunUnitTest("runs passing native tests", t, func(env *testutil.UnitTestEnv) {
+		opts := core.NativeTestOpts{Sketch: testutil.NativeProjectDir()}
+
+		report, err := env.ArdiCore.NativeTest.Run(opts)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, 3, len(report.Results))
+		assert.Equal(env.T, 0, report.Failed())
+
+		var tap bytes.Buffer
+		err = report.WriteTAP(&tap)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, tap.String(), "1..3")
+		assert.Contains(env.T, tap.String(), "ok 1 - fires_every_third_tick")
+	})
+
+	testutil.RunUnitTest("reports failing native tests", t, func(env *testutil.UnitTestEnv) {
+		testFile, err := ioutil.TempFile("", "failing_test*.cpp")
+		assert.NoError(env.T, err)
+		defer os.Remove(testFile.Name())
+
+		testFile.WriteString("#include <ArdiTest.h>\n#include \"counter.h\"\n\nTEST(wrong_count) {\n  Counter c(2);\n  ASSERT_EQ(5u, c.count());\n}\n")
+		testFile.Close()
+
+		opts := core.NativeTestOpts{
+			Sketch: testutil.NativeProjectDir(),
+			Tests:  []string{testFile.Name()},
+		}
+
+		report, err := env.ArdiCore.NativeTest.Run(opts)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, 1, report.Failed())
+		assert.Contains(env.T, report.Results[0].Message, "expected 5 but got 0")
+
+		var junit bytes.Buffer
+		err = report.WriteJUnit(&junit)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, junit.String(), `<testsuite name="ardi-native" tests="1" failures="1"`)
+		assert.Contains(env.T, junit.String(), `<testcase name="wrong_count"`)
+	})
+
+	testutil.RunUnitTest("returns error when tests crash after a failure", t, func(env *testutil.UnitTestEnv) {
+		testFile, err := ioutil.TempFile("", "crashing_test*.cpp")
+		assert.NoError(env.T, err)
+		defer os.Remove(testFile.Name())
+
+		testFile.WriteString("#include <cstdlib>\n#include <ArdiTest.h>\n#include \"counter.h\"\n\nTEST(wrong_count) {\n  Counter c(2);\n  ASSERT_EQ(5u, c.count());\n}\n\nTEST(crashes) {\n  abort();\n}\n")
+		testFile.Close()
+
+		opts := core.NativeTestOpts{
+			Sketch: testutil.NativeProjectDir(),
+			Tests:  []string{testFile.Name()},
+		}
+
+		report, err := env.ArdiCore.NativeTest.Run(opts)
+		assert.ErrorContains(env.T, err, "failed to run native tests")
+		assert.Equal(env.T, 1, report.Failed())
+	})
+
+	testutil.RunUnitTest("returns compile errors", t, func(env *testutil.UnitTestEnv) {
+		opts := core.NativeTestOpts{
+			Sketch: testutil.NativeProjectDir(),
+			Tests:  []string{path.Join(testutil.NativeProjectDir(), "does-not-exist.cpp")},
+		}
+
+		_, err := env.ArdiCore.NativeTest.Run(opts)
+		assert.Error(env.T, err)
+	})
+
+	testutil.RunUnitTest("errors if no tests found", t, func(env *testutil.UnitTestEnv) {
+		opts := core.NativeTestOpts{Sketch: testutil.BlinkProjectDir()}
+
+		_, err := env.ArdiCore.NativeTest.Run(opts)
+		assert.Error(env.T, err)
+	})
+}
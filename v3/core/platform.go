@@ -2,13 +2,12 @@ package core
 
 import (
 	"errors"
-	"fmt"
 	"sort"
-	"text/tabwriter"
 
 	log "github.com/sirupsen/logrus"
 
 	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/types"
 )
 
 // PlatformCore module for platform commands
@@ -42,47 +41,51 @@ func WithPlatformCliWrapper(wrapper *cli.Wrapper) PlatformCoreOption {
 	}
 }
 
-// ListInstalled lists only installed platforms
-func (c *PlatformCore) ListInstalled() error {
+// ListInstalled returns only installed platforms
+func (c *PlatformCore) ListInstalled() ([]types.Platform, error) {
 	platforms, err := c.cli.GetInstalledPlatforms()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	sort.Slice(platforms, func(i, j int) bool {
 		return platforms[i].GetName() < platforms[j].GetName()
 	})
 
-	w := tabwriter.NewWriter(c.logger.Out, 0, 0, 8, ' ', 0)
-	defer w.Flush()
-	w.Write([]byte("Platform\tID\tInstalled\n"))
+	installed := []types.Platform{}
 	for _, plat := range platforms {
-		w.Write([]byte(fmt.Sprintf("%s\t%s\t%s\n", plat.GetName(), plat.GetId(), plat.GetInstalled())))
+		installed = append(installed, types.Platform{
+			ID:        plat.GetId(),
+			Name:      plat.GetName(),
+			Installed: plat.GetInstalled(),
+			Latest:    plat.GetLatest(),
+		})
 	}
-	return nil
+	return installed, nil
 }
 
-// ListAll lists all available platforms
-func (c *PlatformCore) ListAll() error {
+// ListAll returns all available platforms
+func (c *PlatformCore) ListAll() ([]types.Platform, error) {
 	c.init()
 
 	platforms, err := c.cli.SearchPlatforms()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	sort.Slice(platforms, func(i, j int) bool {
 		return platforms[i].GetName() < platforms[j].GetName()
 	})
 
-	c.logger.Info("------AVAILABLE PLATFORMS------")
-	w := tabwriter.NewWriter(c.logger.Out, 0, 0, 8, ' ', 0)
-	defer w.Flush()
-	w.Write([]byte("Platform\tID\tLatest\n"))
+	available := []types.Platform{}
 	for _, plat := range platforms {
-		w.Write([]byte(fmt.Sprintf("%s\t%s\t%s\n", plat.GetName(), plat.GetId(), plat.GetLatest())))
+		available = append(available, types.Platform{
+			ID:     plat.GetId(),
+			Name:   plat.GetName(),
+			Latest: plat.GetLatest(),
+		})
 	}
-	return nil
+	return available, nil
 }
 
 // Add installs specified platforms
@@ -10,9 +10,8 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// @todo: check that list is actually sorted
 func TestPlatformCore(t *testing.T) {
-	testutil.RunUnitTest("prints sorted list of all installed platforms to stdout", t, func(env *testutil.UnitTestEnv) {
+	testutil.RunUnitTest("returns sorted list of all installed platforms", t, func(env *testutil.UnitTestEnv) {
 		platform1 := rpc.Platform{
 			Name: "test-platform-1",
 		}
@@ -32,15 +31,15 @@ func TestPlatformCore(t *testing.T) {
 		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
 		env.ArduinoCli.EXPECT().GetPlatforms(req).Return(platforms, nil)
 
-		err := env.ArdiCore.Platform.ListInstalled()
+		installed, err := env.ArdiCore.Platform.ListInstalled()
 		assert.NoError(env.T, err)
 
-		assert.Contains(env.T, env.Stdout.String(), platform1.Name)
-		assert.Contains(env.T, env.Stdout.String(), platform2.Name)
+		assert.Equal(env.T, 2, len(installed))
+		assert.Equal(env.T, platform1.Name, installed[0].Name)
+		assert.Equal(env.T, platform2.Name, installed[1].Name)
 	})
 
-	// @todo: check that list is actually sorted
-	testutil.RunUnitTest("prints sorted list of all available platforms to stdout", t, func(env *testutil.UnitTestEnv) {
+	testutil.RunUnitTest("returns sorted list of all available platforms", t, func(env *testutil.UnitTestEnv) {
 		platform1 := rpc.Platform{
 			Name: "test-platform-1",
 		}
@@ -62,11 +61,12 @@ func TestPlatformCore(t *testing.T) {
 		env.ArduinoCli.EXPECT().UpdateIndex(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 		env.ArduinoCli.EXPECT().PlatformSearch(req).Return(resp, nil)
 
-		err := env.ArdiCore.Platform.ListAll()
+		available, err := env.ArdiCore.Platform.ListAll()
 		assert.NoError(env.T, err)
 
-		assert.Contains(env.T, env.Stdout.String(), platform1.Name)
-		assert.Contains(env.T, env.Stdout.String(), platform2.Name)
+		assert.Equal(env.T, 2, len(available))
+		assert.Equal(env.T, platform1.Name, available[0].Name)
+		assert.Equal(env.T, platform2.Name, available[1].Name)
 	})
 
 	testutil.RunUnitTest("adds platforms", t, func(env *testutil.UnitTestEnv) {
@@ -0,0 +1,399 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+// pioExtraFlagsProp build property platformio build_flags are mapped to
+const pioExtraFlagsProp = "compiler.cpp.extra_flags"
+
+// pioDefaultSrcDir platformio source directory when src_dir isn't set
+const pioDefaultSrcDir = "src"
+
+// pioRefRgx matches ${section.key} references in platformio.ini values
+var pioRefRgx = regexp.MustCompile(`\$\{([^}.]+)\.([^}]+)\}`)
+
+// pioBoards maps platformio board ids to fqbns
+var pioBoards = map[string]string{
+	"uno":                "arduino:avr:uno",
+	"megaatmega2560":     "arduino:avr:mega:cpu=atmega2560",
+	"megaatmega1280":     "arduino:avr:mega:cpu=atmega1280",
+	"nanoatmega328":      "arduino:avr:nano:cpu=atmega328old",
+	"nanoatmega328new":   "arduino:avr:nano:cpu=atmega328",
+	"leonardo":           "arduino:avr:leonardo",
+	"micro":              "arduino:avr:micro",
+	"pro16MHzatmega328":  "arduino:avr:pro:cpu=16MHzatmega328",
+	"pro8MHzatmega328":   "arduino:avr:pro:cpu=8MHzatmega328",
+	"due":                "arduino:sam:arduino_due_x_dbg",
+	"zero":               "arduino:samd:arduino_zero_edbg",
+	"zeroUSB":            "arduino:samd:arduino_zero_native",
+	"mkr1000USB":         "arduino:samd:mkr1000",
+	"mkrwifi1010":        "arduino:samd:mkrwifi1010",
+	"nano_33_iot":        "arduino:samd:nano_33_iot",
+	"pico":               "arduino:mbed_rp2040:pico",
+	"esp32dev":           "esp32:esp32:esp32",
+	"esp32-s3-devkitc-1": "esp32:esp32:esp32s3",
+	"esp32-c3-devkitm-1": "esp32:esp32:esp32c3",
+	"nodemcuv2":          "esp8266:esp8266:nodemcuv2",
+	"d1_mini":            "esp8266:esp8266:d1_mini",
+	"huzzah":             "esp8266:esp8266:huzzah",
+}
+
+// pioPlatforms maps platformio platforms to ardi platforms for boards
+// without a mapping
+var pioPlatforms = map[string]string{
+	"atmelavr":      "arduino:avr",
+	"atmelsam":      "arduino:sam",
+	"raspberrypi":   "arduino:mbed_rp2040",
+	"espressif32":   "esp32:esp32",
+	"espressif8266": "esp8266:esp8266",
+}
+
+// pioListKeys platformio options whose multi-line values are lists, their
+// indented items may contain an =
+var pioListKeys = []string{
+	"build_flags",
+	"build_src_filter",
+	"build_unflags",
+	"extends",
+	"lib_deps",
+	"lib_extra_dirs",
+	"lib_ignore",
+	"monitor_filters",
+	"src_filter",
+	"upload_flags",
+}
+
+// pioBoardURLs board manager urls of the platforms not shipped in the
+// default arduino index
+var pioBoardURLs = map[string]string{
+	"esp32:esp32":     "https://raw.githubusercontent.com/espressif/arduino-esp32/gh-pages/package_esp32_index.json",
+	"esp8266:esp8266": "https://arduino.esp8266.com/stable/package_esp8266com_index.json",
+}
+
+// pioEnv represents an [env:NAME] section of platformio.ini with the values
+// it inherits from [env] and the sections it extends
+type pioEnv struct {
+	name   string
+	values map[string]string
+	keys   []string
+}
+
+// ImportPlatformIO adds a build to ardi.json for every [env:NAME] section of
+// a platformio.ini along with the platforms and libraries the environments
+// use. Settings that can't be mapped to ardi.json are returned as a report.
+func (a *ArdiConfig) ImportPlatformIO(iniPath string) ([]types.ImportIssue, error) {
+	sections, err := util.ReadINI(iniPath, pioListKeys...)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.config.Platforms == nil {
+		a.config.Platforms = make(map[string]string)
+	}
+	if a.config.Libraries == nil {
+		a.config.Libraries = make(map[string]string)
+	}
+	if a.config.Builds == nil {
+		a.config.Builds = make(map[string]types.ArdiBuild)
+	}
+
+	bySection := make(map[string]util.INISection)
+	for _, section := range sections {
+		bySection[section.Name] = section
+	}
+
+	issues := []types.ImportIssue{}
+	report := func(section, key, value, reason string, args ...interface{}) {
+		issues = append(issues, types.ImportIssue{
+			Section: section,
+			Key:     key,
+			Value:   value,
+			Reason:  fmt.Sprintf(reason, args...),
+		})
+	}
+
+	srcDir := pioDefaultSrcDir
+	if pio, ok := bySection["platformio"]; ok {
+		for _, key := range pio.Keys {
+			if key == "src_dir" {
+				srcDir = pio.Values[key]
+				continue
+			}
+			report("platformio", key, pio.Values[key], "no ardi.json equivalent")
+		}
+	}
+	srcDir = filepath.Join(filepath.Dir(iniPath), srcDir)
+
+	var project *types.Project
+	if project, err = util.ProcessSketch(srcDir); err != nil {
+		report("platformio", "src_dir", srcDir, "no .ino sketch found, arduino-cli requires a sketch named after its directory")
+	}
+
+	envs := []pioEnv{}
+	for _, section := range sections {
+		if !strings.HasPrefix(section.Name, "env:") {
+			continue
+		}
+		env, envIssues := resolvePioEnv(section, bySection)
+		envs = append(envs, env)
+		issues = append(issues, envIssues...)
+	}
+
+	if len(envs) == 0 {
+		return nil, fmt.Errorf("no [env:NAME] sections found in %s", iniPath)
+	}
+
+	for _, env := range envs {
+		section := "env:" + env.name
+		build := types.ArdiBuild{
+			Props: make(map[string]string),
+		}
+		if project != nil {
+			build.Directory = a.projectRelative(project.Directory)
+			build.Sketch = a.projectRelative(project.Sketch)
+			build.Baud = project.Baud
+		} else {
+			build.Directory = a.projectRelative(srcDir)
+			build.Baud = 9600
+		}
+
+		if framework, ok := env.values["framework"]; ok && !strings.Contains(framework, "arduino") {
+			report(section, "framework", framework, "only the arduino framework is supported, environment skipped")
+			continue
+		}
+
+		platform := ""
+		if board, ok := env.values["board"]; ok {
+			if fqbn, ok := pioBoards[board]; ok {
+				base, _, options := util.SplitFQBN(fqbn)
+				build.FQBN = base
+				if len(options) > 0 {
+					build.BoardOptions = options
+				}
+				parts := strings.Split(base, ":")
+				platform = parts[0] + ":" + parts[1]
+			} else {
+				report(section, "board", board, "no fqbn mapping for board, set the build fqbn manually")
+			}
+		} else {
+			report(section, "board", "", "no board specified, set the build fqbn manually")
+		}
+
+		for _, key := range env.keys {
+			value := env.values[key]
+			switch key {
+			case "board", "framework":
+			case "platform":
+				name, version := splitPioDep(value)
+				if i := strings.LastIndex(name, "/"); i >= 0 {
+					name = name[i+1:]
+				}
+				if platform == "" {
+					if mapped, ok := pioPlatforms[name]; ok {
+						platform = mapped
+					} else {
+						report(section, key, value, "no platform mapping, add the platform manually")
+					}
+				}
+				if version != "" {
+					report(section, key, value, "platformio platform versions don't match arduino core versions, the latest core is used")
+				}
+			case "monitor_speed":
+				baud, err := strconv.Atoi(value)
+				if err != nil {
+					report(section, key, value, "invalid baud")
+					continue
+				}
+				build.Baud = baud
+			case "build_flags":
+				if strings.HasPrefix(value, "!") {
+					report(section, key, value, "dynamic build flags are not supported")
+					continue
+				}
+				// platformio removes the shell escaping of quotes when it
+				// reads build_flags, arduino-cli passes them on as written
+				value = strings.ReplaceAll(value, `\"`, `"`)
+				if flags := strings.Fields(value); len(flags) > 0 {
+					build.Props[pioExtraFlagsProp] = strings.Join(flags, " ")
+				}
+			case "lib_deps":
+				for _, dep := range splitPioList(value) {
+					a.importPioLibrary(section, dep, report)
+				}
+			default:
+				report(section, key, value, "no ardi.json equivalent")
+			}
+		}
+
+		if platform != "" {
+			if _, ok := a.config.Platforms[platform]; !ok {
+				a.config.Platforms[platform] = ""
+			}
+			if url, ok := pioBoardURLs[platform]; ok && !util.ArrayContains(a.config.BoardURLS, url) {
+				a.config.BoardURLS = append(a.config.BoardURLS, url)
+			}
+		}
+
+		a.config.Builds[env.name] = build
+	}
+
+	return issues, a.write()
+}
+
+// private
+// importPioLibrary adds a platformio lib_deps entry to the project libraries
+func (a *ArdiConfig) importPioLibrary(section, dep string, report func(section, key, value, reason string, args ...interface{})) {
+	if strings.Contains(dep, "://") || strings.HasSuffix(dep, ".git") {
+		report(section, "lib_deps", dep, "libraries from urls and local paths are not supported")
+		return
+	}
+
+	name, spec := splitPioDep(dep)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		report(section, "lib_deps", dep, "platformio registry ids are not supported")
+		return
+	}
+
+	version := strings.TrimLeft(spec, "^~=")
+	if version != spec && spec[0] != '=' {
+		report(section, "lib_deps", dep, "version range pinned to %s", version)
+	}
+	if strings.ContainsAny(version, "<>*, ") {
+		report(section, "lib_deps", dep, "version range not supported, the latest version is used")
+		version = ""
+	}
+
+	if existing, ok := a.config.Libraries[name]; ok && existing != version {
+		report(section, "lib_deps", dep, "conflicts with version %s, keeping %s", existing, existing)
+		return
+	}
+	a.config.Libraries[name] = version
+}
+
+// resolvePioEnv returns the values of an [env:NAME] section including the
+// values it inherits from [env] and the sections it extends with all
+// ${section.key} references resolved
+func resolvePioEnv(section util.INISection, sections map[string]util.INISection) (pioEnv, []types.ImportIssue) {
+	env := pioEnv{
+		name:   strings.TrimPrefix(section.Name, "env:"),
+		values: make(map[string]string),
+	}
+	issues := []types.ImportIssue{}
+
+	layers := []util.INISection{}
+	if common, ok := sections["env"]; ok {
+		layers = append(layers, common)
+	}
+	if extends, ok := section.Values["extends"]; ok {
+		for _, name := range splitPioList(extends) {
+			if parent, ok := sections[name]; ok {
+				layers = append(layers, parent)
+				continue
+			}
+			issues = append(issues, types.ImportIssue{
+				Section: section.Name,
+				Key:     "extends",
+				Value:   name,
+				Reason:  "extended section not found",
+			})
+		}
+	}
+	layers = append(layers, section)
+
+	for _, layer := range layers {
+		for _, key := range layer.Keys {
+			if key == "extends" {
+				continue
+			}
+			if _, ok := env.values[key]; !ok {
+				env.keys = append(env.keys, key)
+			}
+			env.values[key] = layer.Values[key]
+		}
+	}
+
+	for _, key := range env.keys {
+		value, err := resolvePioRefs(env.values[key], env.values, sections, 0)
+		if err != nil {
+			issues = append(issues, types.ImportIssue{
+				Section: section.Name,
+				Key:     key,
+				Value:   env.values[key],
+				Reason:  err.Error(),
+			})
+		}
+		env.values[key] = value
+	}
+
+	return env, issues
+}
+
+// resolvePioRefs replaces ${section.key} references in value. ${this.key}
+// references the environment's own values and ${sysenv.NAME} references are
+// translated to ardi ${env:NAME} references.
+func resolvePioRefs(value string, this map[string]string, sections map[string]util.INISection, depth int) (string, error) {
+	if depth > 10 {
+		return value, fmt.Errorf("too many nested references")
+	}
+
+	var resolveErr error
+	result := pioRefRgx.ReplaceAllStringFunc(value, func(match string) string {
+		parts := pioRefRgx.FindStringSubmatch(match)
+		section, key := parts[1], parts[2]
+
+		var ref string
+		var ok bool
+		switch section {
+		case "sysenv":
+			return "${env:" + key + "}"
+		case "this":
+			ref, ok = this[key]
+		default:
+			ref, ok = sections[section].Values[key]
+		}
+		if !ok {
+			resolveErr = fmt.Errorf("unresolved reference %s", match)
+			return ""
+		}
+
+		resolved, err := resolvePioRefs(ref, this, sections, depth+1)
+		if err != nil {
+			resolveErr = err
+		}
+		return resolved
+	})
+
+	return result, resolveErr
+}
+
+// splitPioDep splits a platformio "name@version" dependency
+func splitPioDep(dep string) (string, string) {
+	if i := strings.LastIndex(dep, "@"); i > 0 {
+		return strings.TrimSpace(dep[:i]), strings.TrimSpace(dep[i+1:])
+	}
+	return strings.TrimSpace(dep), ""
+}
+
+// splitPioList splits a platformio multi-line or comma separated list
+func splitPioList(value string) []string {
+	items := []string{}
+	for _, line := range strings.Split(value, "\n") {
+		for _, item := range strings.Split(line, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,152 @@
+package core_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArdiConfigPlatformIO(t *testing.T) {
+	newProject := func(env *testutil.UnitTestEnv, ini string) (string, *core.ArdiConfig) {
+		root := env.T.TempDir()
+		sketch := path.Join(root, "src", "src.ino")
+		assert.NoError(env.T, os.MkdirAll(path.Dir(sketch), 0755))
+		assert.NoError(env.T, os.WriteFile(sketch, []byte("void setup() {}\n"), 0644))
+		assert.NoError(env.T, os.WriteFile(path.Join(root, "platformio.ini"), []byte(ini), 0644))
+		return root, core.NewArdiConfig(path.Join(root, "ardi.json"), *util.GenArdiConfig(), env.Logger)
+	}
+
+	testutil.RunUnitTest("maps environments to builds", t, func(env *testutil.UnitTestEnv) {
+		root, config := newProject(env, `[common]
+flags = -DLOG_LEVEL=2
+
+[env]
+framework = arduino
+monitor_speed = 115200
+lib_deps =
+    adafruit/Adafruit BME280 Library@2.2.2
+
+[env:mega]
+platform = atmelavr
+board = megaatmega2560
+build_flags =
+    ${common.flags}
+    -DSSID=\"${sysenv.WIFI_SSID}\"
+
+[env:esp32]
+platform = espressif32@6.3.0
+board = esp32dev
+monitor_speed = 9600
+lib_deps =
+    ${env.lib_deps}
+    bblanchon/ArduinoJson@^6.21.2
+`)
+
+		issues, err := config.ImportPlatformIO(path.Join(root, "platformio.ini"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []types.ImportIssue{
+			{
+				Section: "env:esp32",
+				Key:     "lib_deps",
+				Value:   "bblanchon/ArduinoJson@^6.21.2",
+				Reason:  "version range pinned to 6.21.2",
+			},
+			{
+				Section: "env:esp32",
+				Key:     "platform",
+				Value:   "espressif32@6.3.0",
+				Reason:  "platformio platform versions don't match arduino core versions, the latest core is used",
+			},
+		}, issues)
+
+		written, err := util.ReadArdiConfig(path.Join(root, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"arduino:avr": "", "esp32:esp32": ""}, written.Platforms)
+		assert.Equal(env.T, map[string]string{
+			"Adafruit BME280 Library": "2.2.2",
+			"ArduinoJson":             "6.21.2",
+		}, written.Libraries)
+		assert.Len(env.T, written.BoardURLS, 1)
+
+		assert.Equal(env.T, types.ArdiBuild{
+			Directory:    "src",
+			Sketch:       "src/src.ino",
+			Baud:         115200,
+			FQBN:         "arduino:avr:mega",
+			BoardOptions: map[string]string{"cpu": "atmega2560"},
+			Props: map[string]string{
+				"compiler.cpp.extra_flags": `-DLOG_LEVEL=2 -DSSID="${env:WIFI_SSID}"`,
+			},
+		}, written.Builds["mega"])
+
+		assert.Equal(env.T, "esp32:esp32:esp32", written.Builds["esp32"].FQBN)
+		assert.Equal(env.T, 9600, written.Builds["esp32"].Baud)
+	})
+
+	testutil.RunUnitTest("reports settings that can't be mapped", t, func(env *testutil.UnitTestEnv) {
+		root, config := newProject(env, `[platformio]
+default_envs = custom
+
+[env:custom]
+platform = nordicnrf52
+board = custom_board
+upload_port = /dev/ttyUSB0
+lib_deps =
+    https://github.com/me/lib.git
+    ${missing.deps}
+
+[env:native]
+platform = native
+framework = zephyr
+`)
+
+		issues, err := config.ImportPlatformIO(path.Join(root, "platformio.ini"))
+		assert.NoError(env.T, err)
+
+		reasons := map[string]string{}
+		for _, issue := range issues {
+			reasons[issue.Section+" "+issue.Key+" "+issue.Value] = issue.Reason
+		}
+		assert.Equal(env.T, map[string]string{
+			"platformio default_envs custom":                                     "no ardi.json equivalent",
+			"env:custom lib_deps https://github.com/me/lib.git\n${missing.deps}": "unresolved reference ${missing.deps}",
+			"env:custom board custom_board":                                      "no fqbn mapping for board, set the build fqbn manually",
+			"env:custom platform nordicnrf52":                                    "no platform mapping, add the platform manually",
+			"env:custom upload_port /dev/ttyUSB0":                                "no ardi.json equivalent",
+			"env:custom lib_deps https://github.com/me/lib.git":                  "libraries from urls and local paths are not supported",
+			"env:native framework zephyr":                                        "only the arduino framework is supported, environment skipped",
+		}, reasons)
+
+		written, err := util.ReadArdiConfig(path.Join(root, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, written.Builds, "custom")
+		assert.NotContains(env.T, written.Builds, "native")
+	})
+
+	testutil.RunUnitTest("maps platforms of boards without a mapping", t, func(env *testutil.UnitTestEnv) {
+		root, config := newProject(env, `[env:sam]
+platform = atmelsam
+board = dueUSB
+`)
+
+		_, err := config.ImportPlatformIO(path.Join(root, "platformio.ini"))
+		assert.NoError(env.T, err)
+
+		written, err := util.ReadArdiConfig(path.Join(root, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"arduino:sam": ""}, written.Platforms)
+	})
+
+	testutil.RunUnitTest("errors if there are no environments", t, func(env *testutil.UnitTestEnv) {
+		root, config := newProject(env, "[platformio]\nsrc_dir = src\n")
+
+		_, err := config.ImportPlatformIO(path.Join(root, "platformio.ini"))
+		assert.ErrorContains(env.T, err, "no [env:NAME] sections found")
+	})
+}
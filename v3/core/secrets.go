@@ -0,0 +1,155 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+// redacted replacement for secret values in output
+const redacted = "********"
+
+// minRedactLen secret values shorter than this are not redacted to avoid
+// mangling unrelated output
+const minRedactLen = 4
+
+// secretDefineRgx matches defines whose name suggests they hold a secret
+// along with their value
+var secretDefineRgx = regexp.MustCompile(`(?i)-D\s*(\w*(?:PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PSK)\w*)=(\S+)`)
+
+// Secrets represents secret values loaded from a project's git-ignored
+// ardi.secrets.json and .env files
+type Secrets struct {
+	values map[string]string
+}
+
+// LoadSecrets loads the secrets for a project. Values in ardi.secrets.json
+// take precedence over values in .env. Missing files are ignored.
+func LoadSecrets(projectPaths paths.ProjectPaths) (*Secrets, error) {
+	values := make(map[string]string)
+
+	dotEnv, err := util.ReadDotEnv(projectPaths.DotEnv)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", projectPaths.DotEnv, err)
+	}
+	for k, v := range dotEnv {
+		values[k] = v
+	}
+
+	secrets, err := util.ReadSecrets(projectPaths.Secrets)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", projectPaths.Secrets, err)
+	}
+	for k, v := range secrets {
+		values[k] = v
+	}
+
+	return &Secrets{values: values}, nil
+}
+
+// Lookup returns the value of the named secret
+func (s *Secrets) Lookup(name string) (string, bool) {
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Values returns all secret values long enough to be redacted, longest first
+func (s *Secrets) Values() []string {
+	var values []string
+	for _, v := range s.values {
+		if len(v) >= minRedactLen {
+			values = append(values, v)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	return values
+}
+
+// Redact replaces all secret values in text
+func (s *Secrets) Redact(text string) string {
+	return Redact(text, s.Values())
+}
+
+// Redact replaces each of the values in text
+func Redact(text string, values []string) string {
+	for _, v := range values {
+		if v != "" {
+			text = strings.ReplaceAll(text, v, redacted)
+		}
+	}
+	return text
+}
+
+// RedactWriter is an io.Writer that redacts secret values from each line
+// before writing it to the underlying writer
+type RedactWriter struct {
+	out    io.Writer
+	values []string
+	buf    bytes.Buffer
+	mux    sync.Mutex
+}
+
+// NewRedactWriter returns a RedactWriter redacting values written to out
+func NewRedactWriter(out io.Writer, values []string) *RedactWriter {
+	return &RedactWriter{
+		out:    out,
+		values: values,
+	}
+}
+
+// Write buffers p and writes every complete line with secrets redacted
+func (w *RedactWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	w.buf.Write(p)
+
+	for {
+		idx := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(w.buf.Next(idx + 1))
+		if _, err := io.WriteString(w.out, Redact(line, w.values)); err != nil {
+			return len(p), err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush writes any remaining partial line with secrets redacted
+func (w *RedactWriter) Flush() error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := io.WriteString(w.out, Redact(w.buf.String(), w.values))
+	w.buf.Reset()
+	return err
+}
+
+// LiteralSecrets returns the names of defines in value that look like they
+// contain a literal secret rather than a ${secret:NAME} reference
+func LiteralSecrets(value string) []string {
+	names := []string{}
+	for _, match := range secretDefineRgx.FindAllStringSubmatch(value, -1) {
+		if !strings.Contains(match[2], "${") {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
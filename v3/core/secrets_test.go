@@ -0,0 +1,42 @@
+package core_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/core"
+)
+
+func TestRedact(t *testing.T) {
+	t.Run("redacts secret values", func(st *testing.T) {
+		text := core.Redact("connecting to home with hunter22", []string{"hunter22"})
+		assert.Equal(st, "connecting to home with ********", text)
+	})
+
+	t.Run("redacts lines split across writes", func(st *testing.T) {
+		var out bytes.Buffer
+		w := core.NewRedactWriter(&out, []string{"hunter22"})
+
+		w.Write([]byte("-DWIFI_PASS=hun"))
+		w.Write([]byte("ter22 -DLED=13\nhunter"))
+		assert.Equal(st, "-DWIFI_PASS=******** -DLED=13\n", out.String())
+
+		w.Write([]byte("22"))
+		assert.NoError(st, w.Flush())
+		assert.Equal(st, "-DWIFI_PASS=******** -DLED=13\n********", out.String())
+	})
+}
+
+func TestLiteralSecrets(t *testing.T) {
+	t.Run("returns defines that look like secrets", func(st *testing.T) {
+		names := core.LiteralSecrets(`-DWIFI_PASSWORD="hunter22" -DApiKey=abc -DLED=13`)
+		assert.Equal(st, []string{"WIFI_PASSWORD", "ApiKey"}, names)
+	})
+
+	t.Run("ignores secret references", func(st *testing.T) {
+		names := core.LiteralSecrets("-DWIFI_PASSWORD=${secret:WIFI_PASSWORD}")
+		assert.Empty(st, names)
+	})
+}
@@ -0,0 +1,352 @@
+package core
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+// baudPortConfig sketch.yaml port_config setting holding the baud rate
+const baudPortConfig = "baudrate"
+
+// profileRefRgx matches "NAME (VERSION)" platform and library references in
+// sketch.yaml profiles
+var profileRefRgx = regexp.MustCompile(`^(.+?)\s*\(\s*([^)]*?)\s*\)$`)
+
+// ExportSketchYAML returns a sketch.yaml for each sketch directory in the
+// project keyed by the absolute directory path. Every build becomes a
+// profile pinning the project's platforms and the libraries its sketch
+// includes, along with their dependencies, as found in the installed
+// libraries. Build fields that can't be represented in sketch.yaml are
+// logged as warnings.
+func (a *ArdiConfig) ExportSketchYAML(installed map[string]util.LibraryContents) (map[string]*types.SketchYAML, error) {
+	if len(a.config.Scripts) > 0 || len(a.config.Vars) > 0 {
+		a.logger.Warn("scripts and vars can't be represented in sketch.yaml and are not exported")
+	}
+
+	a.warnUnpinned()
+
+	names := []string{}
+	for name := range a.config.Builds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	usedURLs := make(map[string]bool)
+	sketchYAMLs := make(map[string]*types.SketchYAML)
+
+	for _, name := range names {
+		build, err := a.InterpolatedBuild(name)
+		if err != nil {
+			return nil, err
+		}
+		a.warnUnexportable(name, build)
+
+		dir := a.resolvePath(build.Directory)
+
+		profile := types.SketchProfile{
+			FQBN:      util.FQBNWithOptions(build.FQBN, build.BoardOptions),
+			Platforms: a.profilePlatforms(build.FQBN, usedURLs),
+			Libraries: a.profileLibraries(name, dir, installed),
+		}
+		if build.Baud != 0 {
+			profile.PortConfig = map[string]string{baudPortConfig: strconv.Itoa(build.Baud)}
+		}
+
+		if _, ok := sketchYAMLs[dir]; !ok {
+			sketchYAMLs[dir] = &types.SketchYAML{Profiles: make(map[string]types.SketchProfile)}
+		}
+		sketchYAMLs[dir].Profiles[name] = profile
+	}
+
+	for _, url := range a.config.BoardURLS {
+		if !usedURLs[url] {
+			a.logger.WithField("board-url", url).Warn("board url doesn't match any platform and can't be represented in sketch.yaml")
+		}
+	}
+
+	return sketchYAMLs, nil
+}
+
+// ImportSketchYAML adds a build to ardi.json for every profile in the
+// sketch.yaml of the sketch in sketchDir along with the platforms, board
+// urls, and libraries the profiles pin. Profile fields that can't be
+// represented in ardi.json are logged as warnings.
+func (a *ArdiConfig) ImportSketchYAML(sketchDir string, sketchYAML *types.SketchYAML) error {
+	project, err := util.ProcessSketch(sketchDir)
+	if err != nil {
+		return err
+	}
+
+	if a.config.Platforms == nil {
+		a.config.Platforms = make(map[string]string)
+	}
+	if a.config.Libraries == nil {
+		a.config.Libraries = make(map[string]string)
+	}
+	if a.config.Builds == nil {
+		a.config.Builds = make(map[string]types.ArdiBuild)
+	}
+
+	defaults := map[string]string{
+		"default_profile": sketchYAML.DefaultProfile,
+		"default_fqbn":    sketchYAML.DefaultFQBN,
+		"default_port":    sketchYAML.DefaultPort,
+	}
+	a.warnUnimportable("", defaults)
+
+	names := []string{}
+	for name := range sketchYAML.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		profile := sketchYAML.Profiles[name]
+
+		unsupported := map[string]string{
+			"notes":      profile.Notes,
+			"programmer": profile.Programmer,
+			"port":       profile.Port,
+			"protocol":   profile.Protocol,
+		}
+		for setting, value := range profile.PortConfig {
+			if setting != baudPortConfig {
+				unsupported["port_config."+setting] = value
+			}
+		}
+		a.warnUnimportable(name, unsupported)
+
+		for _, p := range profile.Platforms {
+			platform, version := parseProfileRef(p.Platform)
+			a.importDependency(name, "platform", a.config.Platforms, platform, version)
+			if p.PlatformIndexURL != "" && !util.ArrayContains(a.config.BoardURLS, p.PlatformIndexURL) {
+				a.config.BoardURLS = append(a.config.BoardURLS, p.PlatformIndexURL)
+			}
+		}
+
+		for _, l := range profile.Libraries {
+			library, version := parseProfileRef(l)
+			a.importDependency(name, "library", a.config.Libraries, library, version)
+		}
+
+		baud := project.Baud
+		if value, ok := profile.PortConfig[baudPortConfig]; ok {
+			if baud, err = strconv.Atoi(value); err != nil {
+				return fmt.Errorf("invalid %s %s in profile %s", baudPortConfig, value, name)
+			}
+		}
+
+		fqbn, _, options := util.SplitFQBN(profile.FQBN)
+
+		build := types.ArdiBuild{
+			Directory: a.projectRelative(project.Directory),
+			Sketch:    a.projectRelative(project.Sketch),
+			Baud:      baud,
+			FQBN:      fqbn,
+			Props:     make(map[string]string),
+		}
+		if len(options) > 0 {
+			build.BoardOptions = options
+		}
+
+		a.config.Builds[name] = build
+	}
+
+	return a.write()
+}
+
+// private
+// warnUnexportable warns about build fields that have no sketch.yaml
+// equivalent
+func (a *ArdiConfig) warnUnexportable(name string, build types.ArdiBuild) {
+	fields := []string{}
+	if len(build.Props) > 0 {
+		fields = append(fields, "props")
+	}
+	if len(build.PreBuild) > 0 {
+		fields = append(fields, "preBuild")
+	}
+	if len(build.PostBuild) > 0 {
+		fields = append(fields, "postBuild")
+	}
+	if build.VersionInjected() {
+		fields = append(fields, "injectVersion")
+	}
+	for _, field := range fields {
+		a.logger.WithField("build", name).Warnf("%s can't be represented in sketch.yaml and is not exported", field)
+	}
+}
+
+// warnUnimportable warns about the set sketch.yaml fields that have no
+// ardi.json equivalent
+func (a *ArdiConfig) warnUnimportable(profile string, fields map[string]string) {
+	names := []string{}
+	for name, value := range fields {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	logger := a.logger.WithFields(nil)
+	if profile != "" {
+		logger = logger.WithField("profile", profile)
+	}
+	for _, name := range names {
+		logger.Warnf("%s can't be represented in ardi.json and is not imported", name)
+	}
+}
+
+// importDependency adds a platform or library pinned by a profile keeping
+// the version already in ardi.json if the profiles disagree
+func (a *ArdiConfig) importDependency(profile, kind string, deps map[string]string, name, version string) {
+	if existing, ok := deps[name]; ok && existing != version {
+		a.logger.WithField("profile", profile).Warnf(
+			"%s %s version %s conflicts with %s, keeping %s",
+			kind, name, version, existing, existing,
+		)
+		return
+	}
+	deps[name] = version
+}
+
+// profilePlatforms returns the project's platforms as sketch.yaml platform
+// references with the platform of fqbn first. Board urls are assigned to the
+// platforms whose vendor they reference.
+func (a *ArdiConfig) profilePlatforms(fqbn string, usedURLs map[string]bool) []types.SketchProfilePlatform {
+	boardPlatform := ""
+	if parts := strings.Split(fqbn, ":"); len(parts) >= 2 {
+		boardPlatform = parts[0] + ":" + parts[1]
+	}
+
+	names := []string{}
+	for name := range a.config.Platforms {
+		names = append(names, name)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		if names[i] == boardPlatform || names[j] == boardPlatform {
+			return names[i] == boardPlatform
+		}
+		return names[i] < names[j]
+	})
+
+	platforms := []types.SketchProfilePlatform{}
+	for _, name := range names {
+		platform := types.SketchProfilePlatform{
+			Platform: profileRef(name, a.config.Platforms[name]),
+		}
+		vendor := strings.Split(name, ":")[0]
+		for _, url := range a.config.BoardURLS {
+			if vendor != "arduino" && strings.Contains(strings.ToLower(url), strings.ToLower(vendor)) {
+				platform.PlatformIndexURL = url
+				usedURLs[url] = true
+				break
+			}
+		}
+		platforms = append(platforms, platform)
+	}
+
+	return platforms
+}
+
+// profileLibraries returns the project libraries included by the sketch in
+// sketchDir, and the project libraries they depend on, as sketch.yaml
+// library references. Libraries that aren't installed are matched by name
+// against the included headers. Every project library is referenced if the
+// sketch can't be scanned.
+func (a *ArdiConfig) profileLibraries(build, sketchDir string, installed map[string]util.LibraryContents) []string {
+	names := []string{}
+	for name := range a.config.Libraries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers, err := util.ScanIncludes(sketchDir)
+	if err != nil {
+		a.logger.WithError(err).WithField("build", build).Warn("Failed to scan sketch includes, referencing every library")
+	} else {
+		included := make(map[string]bool)
+		for _, header := range headers {
+			included[filepath.Base(header)] = true
+		}
+
+		used := make(map[string]bool)
+		var use func(name string)
+		use = func(name string) {
+			if _, ok := a.config.Libraries[name]; !ok || used[name] {
+				return
+			}
+			used[name] = true
+			for _, dep := range installed[name].Depends {
+				use(dep)
+			}
+		}
+
+		for _, name := range names {
+			contents, ok := installed[name]
+			if !ok {
+				if included[strings.ReplaceAll(name, " ", "_")+".h"] {
+					use(name)
+				}
+				continue
+			}
+			for _, header := range contents.Headers {
+				if included[header] {
+					use(name)
+					break
+				}
+			}
+		}
+
+		usedNames := []string{}
+		for _, name := range names {
+			if used[name] {
+				usedNames = append(usedNames, name)
+			}
+		}
+		names = usedNames
+	}
+
+	libraries := []string{}
+	for _, name := range names {
+		libraries = append(libraries, profileRef(name, a.config.Libraries[name]))
+	}
+	return libraries
+}
+
+// warnUnpinned warns about platforms and libraries without a version as
+// sketch.yaml profiles require every dependency to be pinned
+func (a *ArdiConfig) warnUnpinned() {
+	for kind, deps := range map[string]map[string]string{
+		"platform": a.config.Platforms,
+		"library":  a.config.Libraries,
+	} {
+		for name, version := range deps {
+			if version == "" {
+				a.logger.Warnf("%s %s has no pinned version, sketch.yaml profiles require one", kind, name)
+			}
+		}
+	}
+}
+
+func profileRef(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, version)
+}
+
+func parseProfileRef(ref string) (string, string) {
+	ref = strings.TrimSpace(ref)
+	if match := profileRefRgx.FindStringSubmatch(ref); match != nil {
+		return match[1], match[2]
+	}
+	return ref, ""
+}
@@ -0,0 +1,183 @@
+package core_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArdiConfigSketchYAML(t *testing.T) {
+	esp32URL := "https://raw.githubusercontent.com/espressif/arduino-esp32/gh-pages/package_esp32_index.json"
+
+	newProject := func(env *testutil.UnitTestEnv, config *types.ArdiConfig) (string, *core.ArdiConfig) {
+		root := env.T.TempDir()
+		sketch := path.Join(root, "weather", "weather.ino")
+		assert.NoError(env.T, os.MkdirAll(path.Dir(sketch), 0755))
+		assert.NoError(env.T, os.WriteFile(sketch, []byte("#include <Adafruit_BME280.h>\n\nvoid setup()\n{\n  Serial.begin(9600);\n}\n"), 0644))
+		return root, core.NewArdiConfig(path.Join(root, "ardi.json"), *config, env.Logger)
+	}
+
+	installed := map[string]util.LibraryContents{
+		"Adafruit BME280 Library": {Headers: []string{"Adafruit_BME280.h"}, Depends: []string{"Adafruit Unified Sensor"}},
+		"Adafruit Unified Sensor": {Headers: []string{"Adafruit_Sensor.h"}},
+		"U8g2":                    {Headers: []string{"U8g2lib.h"}},
+	}
+
+	newConfig := func() *types.ArdiConfig {
+		config := util.GenArdiConfig()
+		config.Platforms = map[string]string{"esp32:esp32": "2.0.9", "arduino:avr": "1.8.6"}
+		config.BoardURLS = []string{esp32URL}
+		config.Libraries = map[string]string{"Adafruit BME280 Library": "2.2.2"}
+		config.Builds = map[string]types.ArdiBuild{
+			"esp32": {
+				Directory:    "weather",
+				Sketch:       "weather/weather.ino",
+				Baud:         115200,
+				FQBN:         "esp32:esp32:esp32",
+				Props:        map[string]string{},
+				BoardOptions: map[string]string{"PartitionScheme": "min_spiffs"},
+			},
+			"uno": {
+				Directory: "weather",
+				Sketch:    "weather/weather.ino",
+				Baud:      9600,
+				FQBN:      "arduino:avr:uno",
+				Props:     map[string]string{},
+			},
+		}
+		return config
+	}
+
+	testutil.RunUnitTest("exports builds as profiles", t, func(env *testutil.UnitTestEnv) {
+		root, config := newProject(env, newConfig())
+
+		sketchYAMLs, err := config.ExportSketchYAML(installed)
+		assert.NoError(env.T, err)
+		assert.Len(env.T, sketchYAMLs, 1)
+
+		sketchYAML := sketchYAMLs[path.Join(root, "weather")]
+		assert.Equal(env.T, types.SketchProfile{
+			FQBN:       "esp32:esp32:esp32:PartitionScheme=min_spiffs",
+			PortConfig: map[string]string{"baudrate": "115200"},
+			Platforms: []types.SketchProfilePlatform{
+				{Platform: "esp32:esp32 (2.0.9)", PlatformIndexURL: esp32URL},
+				{Platform: "arduino:avr (1.8.6)"},
+			},
+			Libraries: []string{"Adafruit BME280 Library (2.2.2)"},
+		}, sketchYAML.Profiles["esp32"])
+		assert.Equal(env.T, "arduino:avr (1.8.6)", sketchYAML.Profiles["uno"].Platforms[0].Platform)
+	})
+
+	testutil.RunUnitTest("exports the libraries each sketch includes", t, func(env *testutil.UnitTestEnv) {
+		initial := newConfig()
+		initial.Vars = map[string]string{"scheme": "huge_app"}
+		initial.Libraries["Adafruit Unified Sensor"] = "1.1.9"
+		initial.Libraries["U8g2"] = "2.34.4"
+		initial.Libraries["Servo"] = "1.2.1"
+		esp32 := initial.Builds["esp32"]
+		esp32.BoardOptions = map[string]string{"PartitionScheme": "${var:scheme}"}
+		initial.Builds["esp32"] = esp32
+		initial.Builds["display"] = types.ArdiBuild{
+			Directory: "display",
+			Sketch:    "display/display.ino",
+			FQBN:      "arduino:avr:uno",
+		}
+		root, config := newProject(env, initial)
+		sketch := path.Join(root, "display", "display.ino")
+		assert.NoError(env.T, os.MkdirAll(path.Dir(sketch), 0755))
+		assert.NoError(env.T, os.WriteFile(sketch, []byte("#include <U8g2lib.h>\n#include <Servo.h>\n"), 0644))
+
+		sketchYAMLs, err := config.ExportSketchYAML(installed)
+		assert.NoError(env.T, err)
+
+		weather := sketchYAMLs[path.Join(root, "weather")].Profiles["esp32"]
+		assert.Equal(env.T, "esp32:esp32:esp32:PartitionScheme=huge_app", weather.FQBN)
+		assert.Equal(env.T, []string{"Adafruit BME280 Library (2.2.2)", "Adafruit Unified Sensor (1.1.9)"}, weather.Libraries)
+
+		display := sketchYAMLs[path.Join(root, "display")].Profiles["display"]
+		assert.Equal(env.T, []string{"Servo (1.2.1)", "U8g2 (2.34.4)"}, display.Libraries)
+	})
+
+	testutil.RunUnitTest("round trips builds through sketch.yaml", t, func(env *testutil.UnitTestEnv) {
+		original := newConfig()
+		root, config := newProject(env, original)
+
+		sketchYAMLs, err := config.ExportSketchYAML(installed)
+		assert.NoError(env.T, err)
+
+		file := path.Join(root, "weather", "sketch.yaml")
+		err = util.WriteSketchYAML(file, sketchYAMLs[path.Join(root, "weather")])
+		assert.NoError(env.T, err)
+
+		sketchYAML, err := util.ReadSketchYAML(file)
+		assert.NoError(env.T, err)
+
+		imported := core.NewArdiConfig(path.Join(root, "ardi.json"), *util.GenArdiConfig(), env.Logger)
+		err = imported.ImportSketchYAML(path.Join(root, "weather"), sketchYAML)
+		assert.NoError(env.T, err)
+
+		written, err := util.ReadArdiConfig(path.Join(root, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, original, written)
+	})
+
+	testutil.RunUnitTest("warns about fields that can't be exported", t, func(env *testutil.UnitTestEnv) {
+		initial := newConfig()
+		initial.Libraries["Unpinned"] = ""
+		build := initial.Builds["uno"]
+		build.Props = map[string]string{"build.extra_flags": "-DDEBUG"}
+		build.PreBuild = []string{"./gen.sh"}
+		initial.Builds["uno"] = build
+		initial.BoardURLS = append(initial.BoardURLS, "https://example.com/package_other_index.json")
+		_, config := newProject(env, initial)
+
+		_, err := config.ExportSketchYAML(installed)
+		assert.NoError(env.T, err)
+
+		out := env.Stdout.String()
+		assert.Contains(env.T, out, "props can't be represented in sketch.yaml")
+		assert.Contains(env.T, out, "preBuild can't be represented in sketch.yaml")
+		assert.Contains(env.T, out, "library Unpinned has no pinned version")
+		assert.Contains(env.T, out, "package_other_index.json")
+	})
+
+	testutil.RunUnitTest("warns about fields that can't be imported", t, func(env *testutil.UnitTestEnv) {
+		root, config := newProject(env, util.GenArdiConfig())
+
+		sketchYAML := &types.SketchYAML{
+			DefaultProfile: "uno",
+			Profiles: map[string]types.SketchProfile{
+				"uno": {
+					FQBN:       "arduino:avr:uno",
+					Programmer: "avrisp",
+					PortConfig: map[string]string{"parity": "none"},
+					Platforms:  []types.SketchProfilePlatform{{Platform: "arduino:avr (1.8.6)"}},
+				},
+				"mega": {
+					FQBN:      "arduino:avr:mega",
+					Platforms: []types.SketchProfilePlatform{{Platform: "arduino:avr (1.8.5)"}},
+				},
+			},
+		}
+
+		err := config.ImportSketchYAML(path.Join(root, "weather"), sketchYAML)
+		assert.NoError(env.T, err)
+
+		written, err := util.ReadArdiConfig(path.Join(root, "ardi.json"))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"arduino:avr": "1.8.5"}, written.Platforms)
+		assert.Equal(env.T, 9600, written.Builds["uno"].Baud)
+
+		out := env.Stdout.String()
+		assert.Contains(env.T, out, "default_profile can't be represented in ardi.json")
+		assert.Contains(env.T, out, "programmer can't be represented in ardi.json")
+		assert.Contains(env.T, out, "port_config.parity can't be represented in ardi.json")
+		assert.Contains(env.T, out, "platform arduino:avr version 1.8.6 conflicts with 1.8.5")
+	})
+}
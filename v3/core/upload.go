@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+)
+
+// UploadCore represents core module for upload commands
+type UploadCore struct {
+	logger *log.Logger
+	cli    *cli.Wrapper
+}
+
+// UploadCoreOption represents options for the UploadCore
+type UploadCoreOption = func(c *UploadCore)
+
+// NewUploadCore instance of core module for upload commands
+func NewUploadCore(logger *log.Logger, options ...UploadCoreOption) *UploadCore {
+	c := &UploadCore{
+		logger: logger,
+	}
+
+	for _, o := range options {
+		o(c)
+	}
+
+	return c
+}
+
+// WithUploadCoreCliWrapper allows an injectable cli wrapper
+func WithUploadCoreCliWrapper(cliWrapper *cli.Wrapper) UploadCoreOption {
+	return func(c *UploadCore) {
+		c.cli = cliWrapper
+	}
+}
+
+// FindPort returns the port of a connected board matching the given fqbn.
+// Board options appended to the fqbn are ignored when matching.
+func (c *UploadCore) FindPort(fqbn string) (string, error) {
+	target := baseFQBN(fqbn)
+	for _, b := range c.cli.ConnectedBoards() {
+		if baseFQBN(b.FQBN) == target {
+			return b.Port, nil
+		}
+	}
+	return "", fmt.Errorf("no connected board found for %s", fqbn)
+}
+
+// Upload uploads a previously compiled sketch to a connected board
+func (c *UploadCore) Upload(opts cli.UploadOpts) error {
+	fields := log.Fields{
+		"sketch": opts.SketchPath,
+		"fqbn":   opts.FQBN,
+		"port":   opts.Port,
+	}
+	fieldsLogger := c.logger.WithFields(fields)
+	fieldsLogger.Info("Uploading...")
+	if err := c.cli.Upload(opts); err != nil {
+		fieldsLogger.WithError(err).Error("Upload failed")
+		return err
+	}
+	fieldsLogger.Info("Upload successful")
+	return nil
+}
+
+// private helpers
+func baseFQBN(fqbn string) string {
+	parts := strings.SplitN(fqbn, ":", 4)
+	if len(parts) > 3 {
+		parts = parts[:3]
+	}
+	return strings.Join(parts, ":")
+}
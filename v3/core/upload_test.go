@@ -0,0 +1,81 @@
+package core_test
+
+import (
+	"errors"
+	"path"
+	"testing"
+
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/testutil"
+)
+
+func TestUploadCore(t *testing.T) {
+	instance := &rpc.Instance{Id: int32(1)}
+	projectDir := testutil.BlinkProjectDir()
+	fqbn := "arduino:avr:mega"
+	port := "/dev/ttyACM0"
+
+	uploadOpts := cli.UploadOpts{
+		FQBN:       fqbn,
+		SketchDir:  projectDir,
+		SketchPath: path.Join(projectDir, "blink.ino"),
+		Port:       port,
+	}
+
+	uploadReq := &rpc.UploadRequest{
+		Instance:   instance,
+		Fqbn:       fqbn,
+		SketchPath: path.Join(projectDir, "blink.ino"),
+		ImportDir:  path.Join(projectDir, "build"),
+		Port:       &rpc.Port{Address: port},
+		Verbose:    true,
+	}
+
+	connected := []*rpc.DetectedPort{
+		{
+			Port: &rpc.Port{Address: port},
+			MatchingBoards: []*rpc.BoardListItem{
+				{Name: "Arduino Mega", Fqbn: fqbn},
+			},
+		},
+	}
+
+	testutil.RunUnitTest("uploads sketch", t, func(env *testutil.UnitTestEnv) {
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().Upload(gomock.Any(), uploadReq, gomock.Any(), gomock.Any())
+
+		err := env.ArdiCore.Uploader.Upload(uploadOpts)
+		assert.NoError(env.T, err)
+	})
+
+	testutil.RunUnitTest("returns upload error", t, func(env *testutil.UnitTestEnv) {
+		dummyErr := errors.New("dummy error")
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().Upload(gomock.Any(), uploadReq, gomock.Any(), gomock.Any()).Return(nil, dummyErr)
+
+		err := env.ArdiCore.Uploader.Upload(uploadOpts)
+		assert.ErrorIs(env.T, err, dummyErr)
+	})
+
+	testutil.RunUnitTest("finds port for fqbn with board options", t, func(env *testutil.UnitTestEnv) {
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().ConnectedBoards(gomock.Any()).Return(connected, nil)
+
+		found, err := env.ArdiCore.Uploader.FindPort(fqbn + ":cpu=atmega2560")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, port, found)
+	})
+
+	testutil.RunUnitTest("returns error if no board connected for fqbn", t, func(env *testutil.UnitTestEnv) {
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+		env.ArduinoCli.EXPECT().ConnectedBoards(gomock.Any()).Return(connected, nil)
+
+		_, err := env.ArdiCore.Uploader.FindPort("esp8266:esp8266:nodemcu")
+		assert.Error(env.T, err)
+	})
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// Workspace represents a group of ardi projects that share a single data
+// directory so common platforms and libraries are only installed once
+type Workspace struct {
+	Paths   paths.WorkspacePaths
+	members map[string]*ArdiCore
+	logger  *log.Logger
+}
+
+// NewWorkspace returns a new empty workspace
+func NewWorkspace(workspacePaths paths.WorkspacePaths, logger *log.Logger) *Workspace {
+	return &Workspace{
+		Paths:   workspacePaths,
+		members: make(map[string]*ArdiCore),
+		logger:  logger,
+	}
+}
+
+// AddMember adds a member project to the workspace
+func (w *Workspace) AddMember(name string, ardiCore *ArdiCore) {
+	w.members[name] = ardiCore
+}
+
+// Members returns the sorted names of all workspace members
+func (w *Workspace) Members() []string {
+	names := []string{}
+	for name := range w.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Member returns the ArdiCore for the named member
+func (w *Workspace) Member(name string) (*ArdiCore, error) {
+	member, ok := w.members[name]
+	if !ok {
+		return nil, fmt.Errorf("no workspace member named %s", name)
+	}
+	if !util.IsProjectDirectory(member.Paths) {
+		return nil, fmt.Errorf("workspace member %s is not an ardi project, run 'ardi init' in %s", name, member.Paths.Root)
+	}
+	return member, nil
+}
+
+// ResolveBuild resolves a build reference of the form member/build to the
+// member's ArdiCore and the build name
+func (w *Workspace) ResolveBuild(ref string) (*ArdiCore, string, error) {
+	idx := strings.LastIndex(ref, "/")
+	if idx <= 0 || idx == len(ref)-1 {
+		return nil, "", fmt.Errorf("invalid workspace build %s, expected member/build", ref)
+	}
+
+	member, err := w.Member(ref[:idx])
+	if err != nil {
+		return nil, "", err
+	}
+
+	build := ref[idx+1:]
+	if _, ok := member.Config.GetBuilds()[build]; !ok {
+		return nil, "", fmt.Errorf("no builds found for %s", ref)
+	}
+
+	return member, build, nil
+}
+
+// Platforms returns the platforms required by all members. An error is
+// returned if members require different versions of the same platform.
+func (w *Workspace) Platforms() (map[string]string, error) {
+	return w.mergeDependencies("platform", func(c *ArdiCore) map[string]string {
+		return c.Config.GetPlatforms()
+	})
+}
+
+// Libraries returns the libraries required by all members. An error is
+// returned if members require different versions of the same library.
+func (w *Workspace) Libraries() (map[string]string, error) {
+	return w.mergeDependencies("library", func(c *ArdiCore) map[string]string {
+		return c.Config.GetLibraries()
+	})
+}
+
+// BoardURLS returns the board urls required by all members
+func (w *Workspace) BoardURLS() []string {
+	urls := []string{}
+	for _, name := range w.Members() {
+		for _, url := range w.members[name].Config.GetBoardURLS() {
+			if !util.ArrayContains(urls, url) {
+				urls = append(urls, url)
+			}
+		}
+	}
+	return urls
+}
+
+// private
+func (w *Workspace) mergeDependencies(kind string, deps func(c *ArdiCore) map[string]string) (map[string]string, error) {
+	merged := make(map[string]string)
+	owners := make(map[string]string)
+
+	for _, name := range w.Members() {
+		member, err := w.Member(name)
+		if err != nil {
+			return nil, err
+		}
+		for dep, version := range deps(member) {
+			if existing, ok := merged[dep]; ok && existing != version {
+				return nil, fmt.Errorf(
+					"%s %s version conflict: %s requires %s, %s requires %s",
+					kind, dep, owners[dep], existing, name, version,
+				)
+			}
+			merged[dep] = version
+			owners[dep] = name
+		}
+	}
+
+	return merged, nil
+}
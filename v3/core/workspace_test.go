@@ -0,0 +1,102 @@
+package core_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+func TestWorkspace(t *testing.T) {
+	newMember := func(env *testutil.UnitTestEnv, ws *core.Workspace, name string) *core.ArdiCore {
+		memberPaths := ws.Paths.MemberPaths(name)
+		assert.NoError(env.T, os.MkdirAll(memberPaths.Root, 0755))
+		err := util.InitProjectDirectory(memberPaths)
+		assert.NoError(env.T, err)
+
+		ardiConfig, cliSettings, err := util.GetAllSettings(memberPaths)
+		assert.NoError(env.T, err)
+		member := core.NewArdiCore(core.NewArdiCoreOpts{
+			Ctx:                env.Ctx,
+			Logger:             env.Logger,
+			Paths:              memberPaths,
+			ArdiConfig:         *ardiConfig,
+			ArduinoCliSettings: *cliSettings,
+		})
+		ws.AddMember(name, member)
+		return member
+	}
+
+	testutil.RunUnitTest("merges member dependencies", t, func(env *testutil.UnitTestEnv) {
+		ws := core.NewWorkspace(paths.NewWorkspacePaths(env.T.TempDir()), env.Logger)
+		sensor := newMember(env, ws, "firmware/sensor")
+		gateway := newMember(env, ws, "firmware/gateway")
+
+		assert.NoError(env.T, sensor.Config.AddPlatform("arduino:avr", "1.8.3"))
+		assert.NoError(env.T, gateway.Config.AddPlatform("arduino:avr", "1.8.3"))
+		assert.NoError(env.T, gateway.Config.AddPlatform("esp8266:esp8266", "2.7.4"))
+		assert.NoError(env.T, sensor.Config.AddLibrary("Adafruit Pixie", "1.0.0"))
+		assert.NoError(env.T, sensor.Config.AddBoardURL(testutil.Esp8266BoardURL()))
+		assert.NoError(env.T, gateway.Config.AddBoardURL(testutil.Esp8266BoardURL()))
+
+		assert.Equal(env.T, []string{"firmware/gateway", "firmware/sensor"}, ws.Members())
+
+		platforms, err := ws.Platforms()
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"arduino:avr": "1.8.3", "esp8266:esp8266": "2.7.4"}, platforms)
+
+		libraries, err := ws.Libraries()
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, map[string]string{"Adafruit Pixie": "1.0.0"}, libraries)
+
+		assert.Equal(env.T, []string{testutil.Esp8266BoardURL()}, ws.BoardURLS())
+	})
+
+	testutil.RunUnitTest("returns error on conflicting versions", t, func(env *testutil.UnitTestEnv) {
+		ws := core.NewWorkspace(paths.NewWorkspacePaths(env.T.TempDir()), env.Logger)
+		sensor := newMember(env, ws, "sensor")
+		gateway := newMember(env, ws, "gateway")
+
+		assert.NoError(env.T, sensor.Config.AddPlatform("arduino:avr", "1.8.3"))
+		assert.NoError(env.T, gateway.Config.AddPlatform("arduino:avr", "1.8.6"))
+
+		_, err := ws.Platforms()
+		assert.EqualError(env.T, err, "platform arduino:avr version conflict: gateway requires 1.8.6, sensor requires 1.8.3")
+	})
+
+	testutil.RunUnitTest("resolves member builds", t, func(env *testutil.UnitTestEnv) {
+		ws := core.NewWorkspace(paths.NewWorkspacePaths(env.T.TempDir()), env.Logger)
+		sensor := newMember(env, ws, "firmware/sensor")
+
+		err := sensor.Config.AddBuild("blink", testutil.BlinkProjectDir(), testutil.ArduinoMegaFQBN(), 0, []string{}, nil)
+		assert.NoError(env.T, err)
+
+		member, build, err := ws.ResolveBuild("firmware/sensor/blink")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, sensor, member)
+		assert.Equal(env.T, "blink", build)
+
+		_, _, err = ws.ResolveBuild("firmware/sensor/noop")
+		assert.Error(env.T, err)
+
+		_, _, err = ws.ResolveBuild("firmware/noop/blink")
+		assert.Error(env.T, err)
+
+		_, _, err = ws.ResolveBuild("blink")
+		assert.Error(env.T, err)
+	})
+
+	testutil.RunUnitTest("returns error for uninitialized member", t, func(env *testutil.UnitTestEnv) {
+		ws := core.NewWorkspace(paths.NewWorkspacePaths(env.T.TempDir()), env.Logger)
+		ws.AddMember("sensor", env.ArdiCore)
+		env.ArdiCore.Paths = ws.Paths.MemberPaths("sensor")
+
+		_, err := ws.Member("sensor")
+		assert.Error(env.T, err)
+	})
+}
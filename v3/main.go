@@ -31,7 +31,6 @@ import (
 	"github.com/robgonnella/ardi/v3/cli-wrapper"
 	"github.com/robgonnella/ardi/v3/commands"
 	"github.com/robgonnella/ardi/v3/core"
-	"github.com/robgonnella/ardi/v3/util"
 )
 
 func main() {
@@ -39,30 +38,14 @@ func main() {
 	defer cancel()
 	logger := log.New()
 
-	ardiConfig, svrSettings := util.GetAllSettings()
-	cliSettingsPath := util.GetCliSettingsPath()
-
-	if util.IsProjectDirectory() {
-		if err := util.WriteAllSettings(ardiConfig, svrSettings); err != nil {
-			logger.WithError(err).Fatal("Failed to write settings files")
-		}
-	}
-
-	coreOpts := core.NewArdiCoreOpts{
-		Ctx:                ctx,
-		Logger:             logger,
-		CliSettingsPath:    cliSettingsPath,
-		ArdiConfig:         *ardiConfig,
-		ArduinoCliSettings: *svrSettings,
-	}
-
 	arduinoCli := cli.NewArduinoCli()
 	withArduinoCli := core.WithArduinoCli(arduinoCli)
-	ardiCore := core.NewArdiCore(coreOpts, withArduinoCli)
 
 	env := &commands.CommandEnv{
-		ArdiCore: ardiCore,
-		Logger:   logger,
+		Logger: logger,
+		NewArdiCore: func(opts core.NewArdiCoreOpts) *core.ArdiCore {
+			return core.NewArdiCore(opts, withArduinoCli)
+		},
 	}
 
 	rootCmd := commands.NewRootCmd(env)
@@ -36,6 +36,36 @@ func (m *MockCli) EXPECT() *MockCliMockRecorder {
 	return m.recorder
 }
 
+// BoardDetails mocks base method.
+func (m *MockCli) BoardDetails(arg0 context.Context, arg1 *commands.BoardDetailsRequest) (*commands.BoardDetailsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BoardDetails", arg0, arg1)
+	ret0, _ := ret[0].(*commands.BoardDetailsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BoardDetails indicates an expected call of BoardDetails.
+func (mr *MockCliMockRecorder) BoardDetails(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BoardDetails", reflect.TypeOf((*MockCli)(nil).BoardDetails), arg0, arg1)
+}
+
+// BoardSearch mocks base method.
+func (m *MockCli) BoardSearch(arg0 context.Context, arg1 *commands.BoardSearchRequest) (*commands.BoardSearchResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BoardSearch", arg0, arg1)
+	ret0, _ := ret[0].(*commands.BoardSearchResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BoardSearch indicates an expected call of BoardSearch.
+func (mr *MockCliMockRecorder) BoardSearch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BoardSearch", reflect.TypeOf((*MockCli)(nil).BoardSearch), arg0, arg1)
+}
+
 // Compile mocks base method.
 func (m *MockCli) Compile(arg0 context.Context, arg1 *commands.CompileRequest, arg2, arg3 io.Writer, arg4 commands.TaskProgressCB, arg5 bool) (*commands.CompileResponse, error) {
 	m.ctrl.T.Helper()
@@ -51,6 +81,21 @@ func (mr *MockCliMockRecorder) Compile(arg0, arg1, arg2, arg3, arg4, arg5 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compile", reflect.TypeOf((*MockCli)(nil).Compile), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
+// ConnectedBoards mocks base method.
+func (m *MockCli) ConnectedBoards(arg0 *commands.BoardListRequest) ([]*commands.DetectedPort, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConnectedBoards", arg0)
+	ret0, _ := ret[0].([]*commands.DetectedPort)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConnectedBoards indicates an expected call of ConnectedBoards.
+func (mr *MockCliMockRecorder) ConnectedBoards(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConnectedBoards", reflect.TypeOf((*MockCli)(nil).ConnectedBoards), arg0)
+}
+
 // CreateInstance mocks base method.
 func (m *MockCli) CreateInstance() *commands.Instance {
 	m.ctrl.T.Helper()
@@ -223,6 +268,21 @@ func (mr *MockCliMockRecorder) UpdateLibrariesIndex(arg0, arg1, arg2 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLibrariesIndex", reflect.TypeOf((*MockCli)(nil).UpdateLibrariesIndex), arg0, arg1, arg2)
 }
 
+// Upload mocks base method.
+func (m *MockCli) Upload(arg0 context.Context, arg1 *commands.UploadRequest, arg2, arg3 io.Writer) (*commands.UploadResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*commands.UploadResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockCliMockRecorder) Upload(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockCli)(nil).Upload), arg0, arg1, arg2, arg3)
+}
+
 // Version mocks base method.
 func (m *MockCli) Version() string {
 	m.ctrl.T.Helper()
@@ -1,12 +1,15 @@
 package paths
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 )
 
-// arduinoCliDataDir data directory name
-const arduinoCliDataDir = ".ardi"
+// DataDirName name of the data directory in project and workspace roots
+const DataDirName = ".ardi"
 
 // arduinoCliDataConfig data directory config name
 const arduinoCliDataConfig = "arduino-cli.yaml"
@@ -14,11 +17,208 @@ const arduinoCliDataConfig = "arduino-cli.yaml"
 // ardi config name
 const ardiConfig = "ardi.json"
 
-// ArdiProjectConfig per-project ardi config
-var ArdiProjectConfig, _ = filepath.Abs(path.Join(".", ardiConfig))
+// SecretsFile name of the git-ignored file of secret values
+const SecretsFile = "ardi.secrets.json"
 
-// ArduinoCliProjectDataDir per-project data config directory for cores, libraries etc
-var ArduinoCliProjectDataDir, _ = filepath.Abs(path.Join(".", arduinoCliDataDir))
+// DotEnvFile name of the git-ignored .env file of secret values
+const DotEnvFile = ".env"
 
-// ArduinoCliProjectConfig per-project arduino-cli config
-var ArduinoCliProjectConfig, _ = filepath.Abs(path.Join(ArduinoCliProjectDataDir, arduinoCliDataConfig))
+// ArdiConfigLockFile name of the lock file in the data directory guarding
+// writes to ardi.json across processes
+const ArdiConfigLockFile = ardiConfig + ".lock"
+
+// SketchYAMLFile name of the arduino-cli build profiles file in a sketch
+// directory
+const SketchYAMLFile = "sketch.yaml"
+
+// workspace config name
+const workspaceConfig = "ardi-workspace.json"
+
+// ProjectDirEnv environment variable used to specify the project directory
+const ProjectDirEnv = "ARDI_PROJECT_DIR"
+
+// ErrProjectNotFound returned when no ardi.json is found while walking up
+// the directory tree
+var ErrProjectNotFound = errors.New("no ardi.json found in any parent directory")
+
+// ErrWorkspaceNotFound returned when no ardi-workspace.json is found while
+// walking up the directory tree
+var ErrWorkspaceNotFound = errors.New("no ardi-workspace.json found in any parent directory")
+
+// ProjectPaths represents the locations of an ardi project's files
+type ProjectPaths struct {
+	// Root project root directory containing ardi.json
+	Root string
+	// ArdiConfig per-project ardi config
+	ArdiConfig string
+	// ArduinoCliDataDir per-project data directory for cores, libraries etc
+	ArduinoCliDataDir string
+	// ArduinoCliConfig per-project arduino-cli config
+	ArduinoCliConfig string
+	// Secrets git-ignored secret values referenced by builds
+	Secrets string
+	// DotEnv git-ignored .env file of secret values referenced by builds
+	DotEnv string
+}
+
+// NewProjectPaths returns the paths of an ardi project rooted at dir
+func NewProjectPaths(dir string) ProjectPaths {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		root = dir
+	}
+	dataDir := path.Join(root, DataDirName)
+	return ProjectPaths{
+		Root:              root,
+		ArdiConfig:        path.Join(root, ardiConfig),
+		ArduinoCliDataDir: dataDir,
+		ArduinoCliConfig:  path.Join(dataDir, arduinoCliDataConfig),
+		Secrets:           path.Join(root, SecretsFile),
+		DotEnv:            path.Join(root, DotEnvFile),
+	}
+}
+
+// WorkspacePaths represents the locations of an ardi workspace's files
+type WorkspacePaths struct {
+	// Root workspace root directory containing ardi-workspace.json
+	Root string
+	// WorkspaceConfig workspace config listing member projects
+	WorkspaceConfig string
+	// ArduinoCliDataDir data directory shared by all member projects
+	ArduinoCliDataDir string
+	// ArduinoCliConfig arduino-cli config shared by all member projects
+	ArduinoCliConfig string
+}
+
+// NewWorkspacePaths returns the paths of an ardi workspace rooted at dir
+func NewWorkspacePaths(dir string) WorkspacePaths {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		root = dir
+	}
+	dataDir := path.Join(root, DataDirName)
+	return WorkspacePaths{
+		Root:              root,
+		WorkspaceConfig:   path.Join(root, workspaceConfig),
+		ArduinoCliDataDir: dataDir,
+		ArduinoCliConfig:  path.Join(dataDir, arduinoCliDataConfig),
+	}
+}
+
+// MemberPaths returns the paths of a workspace member project. Members keep
+// their own ardi.json but share the workspace data directory.
+func (w WorkspacePaths) MemberPaths(member string) ProjectPaths {
+	root := filepath.Join(w.Root, filepath.FromSlash(member))
+	return ProjectPaths{
+		Root:              root,
+		ArdiConfig:        path.Join(root, ardiConfig),
+		ArduinoCliDataDir: w.ArduinoCliDataDir,
+		ArduinoCliConfig:  w.ArduinoCliConfig,
+		Secrets:           path.Join(root, SecretsFile),
+		DotEnv:            path.Join(root, DotEnvFile),
+	}
+}
+
+// MemberName returns the name of the workspace member rooted at dir, which
+// is dir relative to the workspace root using forward slashes
+func (w WorkspacePaths) MemberName(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(w.Root, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Env returns environment variables describing the project for use by
+// scripts and hooks
+func (p ProjectPaths) Env() []string {
+	return []string{
+		ProjectDirEnv + "=" + p.Root,
+		"ARDI_CONFIG=" + p.ArdiConfig,
+		"ARDI_DATA_DIR=" + p.ArduinoCliDataDir,
+		"ARDI_CLI_CONFIG=" + p.ArduinoCliConfig,
+	}
+}
+
+// FindProjectRoot walks up from dir and returns the first directory
+// containing an ardi.json
+func FindProjectRoot(dir string) (string, error) {
+	root, err := findParentContaining(dir, ardiConfig)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrProjectNotFound
+	}
+	return root, err
+}
+
+// FindWorkspaceRoot walks up from dir and returns the first directory
+// containing an ardi-workspace.json
+func FindWorkspaceRoot(dir string) (string, error) {
+	root, err := findParentContaining(dir, workspaceConfig)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrWorkspaceNotFound
+	}
+	return root, err
+}
+
+// ProjectDir returns dir if set, otherwise the value of ARDI_PROJECT_DIR
+func ProjectDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	return os.Getenv(ProjectDirEnv)
+}
+
+// ResolveProjectPaths returns the paths for the project in dir. When dir is
+// empty, ARDI_PROJECT_DIR is used, and when that is unset the nearest
+// directory at or above the current working directory containing an
+// ardi.json is used. If no project is found the working directory is used.
+func ResolveProjectPaths(dir string) (ProjectPaths, error) {
+	if dir = ProjectDir(dir); dir != "" {
+		stat, err := os.Stat(dir)
+		if err != nil {
+			return ProjectPaths{}, err
+		}
+		if !stat.IsDir() {
+			return ProjectPaths{}, fmt.Errorf("project directory is not a directory: %s", dir)
+		}
+		return NewProjectPaths(dir), nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ProjectPaths{}, err
+	}
+
+	root, err := FindProjectRoot(cwd)
+	if errors.Is(err, ErrProjectNotFound) {
+		return NewProjectPaths(cwd), nil
+	}
+	if err != nil {
+		return ProjectPaths{}, err
+	}
+
+	return NewProjectPaths(root), nil
+}
+
+// private helpers
+func findParentContaining(dir, file string) (string, error) {
+	current, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if stat, err := os.Stat(path.Join(current, file)); err == nil && stat.Mode().IsRegular() {
+			return current, nil
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", os.ErrNotExist
+		}
+		current = parent
+	}
+}
@@ -0,0 +1,117 @@
+package paths_test
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/paths"
+)
+
+func TestProjectPaths(t *testing.T) {
+	t.Run("returns absolute project paths", func(st *testing.T) {
+		dir := st.TempDir()
+		p := paths.NewProjectPaths(dir)
+		assert.Equal(st, dir, p.Root)
+		assert.Equal(st, path.Join(dir, "ardi.json"), p.ArdiConfig)
+		assert.Equal(st, path.Join(dir, ".ardi"), p.ArduinoCliDataDir)
+		assert.Equal(st, path.Join(dir, ".ardi", "arduino-cli.yaml"), p.ArduinoCliConfig)
+		assert.Equal(st, path.Join(dir, "ardi.secrets.json"), p.Secrets)
+		assert.Equal(st, path.Join(dir, ".env"), p.DotEnv)
+
+		here, _ := filepath.Abs(".")
+		assert.Equal(st, here, paths.NewProjectPaths(".").Root)
+	})
+
+	t.Run("finds project root in parent directory", func(st *testing.T) {
+		root := st.TempDir()
+		nested := path.Join(root, "sketches", "blink")
+		assert.NoError(st, os.MkdirAll(nested, 0755))
+		assert.NoError(st, os.WriteFile(path.Join(root, "ardi.json"), []byte("{}"), 0644))
+
+		found, err := paths.FindProjectRoot(nested)
+		assert.NoError(st, err)
+		assert.Equal(st, root, found)
+	})
+
+	t.Run("returns error if no project root found", func(st *testing.T) {
+		_, err := paths.FindProjectRoot(st.TempDir())
+		assert.ErrorIs(st, err, paths.ErrProjectNotFound)
+	})
+
+	t.Run("resolves explicit project directory", func(st *testing.T) {
+		dir := st.TempDir()
+		p, err := paths.ResolveProjectPaths(dir)
+		assert.NoError(st, err)
+		assert.Equal(st, dir, p.Root)
+	})
+
+	t.Run("resolves project directory from environment", func(st *testing.T) {
+		dir := st.TempDir()
+		st.Setenv(paths.ProjectDirEnv, dir)
+		p, err := paths.ResolveProjectPaths("")
+		assert.NoError(st, err)
+		assert.Equal(st, dir, p.Root)
+	})
+
+	t.Run("prefers explicit directory over environment", func(st *testing.T) {
+		dir := st.TempDir()
+		st.Setenv(paths.ProjectDirEnv, st.TempDir())
+		p, err := paths.ResolveProjectPaths(dir)
+		assert.NoError(st, err)
+		assert.Equal(st, dir, p.Root)
+	})
+
+	t.Run("returns error if explicit directory does not exist", func(st *testing.T) {
+		_, err := paths.ResolveProjectPaths(path.Join(st.TempDir(), "noop"))
+		assert.Error(st, err)
+	})
+}
+
+func TestWorkspacePaths(t *testing.T) {
+	t.Run("returns member paths sharing workspace data directory", func(st *testing.T) {
+		root := st.TempDir()
+		w := paths.NewWorkspacePaths(root)
+		assert.Equal(st, path.Join(root, "ardi-workspace.json"), w.WorkspaceConfig)
+
+		m := w.MemberPaths("firmware/sensor")
+		assert.Equal(st, path.Join(root, "firmware", "sensor"), m.Root)
+		assert.Equal(st, path.Join(root, "firmware", "sensor", "ardi.json"), m.ArdiConfig)
+		assert.Equal(st, w.ArduinoCliDataDir, m.ArduinoCliDataDir)
+		assert.Equal(st, w.ArduinoCliConfig, m.ArduinoCliConfig)
+
+		name, err := w.MemberName(m.Root)
+		assert.NoError(st, err)
+		assert.Equal(st, "firmware/sensor", name)
+	})
+
+	t.Run("finds workspace root in parent directory", func(st *testing.T) {
+		root := st.TempDir()
+		member := path.Join(root, "firmware", "sensor")
+		assert.NoError(st, os.MkdirAll(member, 0755))
+		assert.NoError(st, os.WriteFile(path.Join(root, "ardi-workspace.json"), []byte("{}"), 0644))
+
+		found, err := paths.FindWorkspaceRoot(member)
+		assert.NoError(st, err)
+		assert.Equal(st, root, found)
+	})
+
+	t.Run("returns error if no workspace root found", func(st *testing.T) {
+		_, err := paths.FindWorkspaceRoot(st.TempDir())
+		assert.ErrorIs(st, err, paths.ErrWorkspaceNotFound)
+	})
+}
+
+func TestProjectEnv(t *testing.T) {
+	t.Run("returns project environment variables", func(st *testing.T) {
+		dir := st.TempDir()
+		env := paths.NewProjectPaths(dir).Env()
+		assert.Contains(st, env, "ARDI_PROJECT_DIR="+dir)
+		assert.Contains(st, env, "ARDI_CONFIG="+path.Join(dir, "ardi.json"))
+		assert.Contains(st, env, "ARDI_DATA_DIR="+path.Join(dir, ".ardi"))
+		assert.Contains(st, env, "ARDI_CLI_CONFIG="+path.Join(dir, ".ardi", "arduino-cli.yaml"))
+	})
+}
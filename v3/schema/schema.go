@@ -0,0 +1,455 @@
+// Package schema provides the JSON Schemas for ardi.json and
+// ardi-workspace.json and validates configs against them reporting the line
+// and column of every problem.
+package schema
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ArdiConfigSchema JSON Schema for ardi.json
+//
+//go:embed ardi.schema.json
+var ArdiConfigSchema []byte
+
+// WorkspaceConfigSchema JSON Schema for ardi-workspace.json
+//
+//go:embed ardi-workspace.schema.json
+var WorkspaceConfigSchema []byte
+
+// Error a single problem found in a config
+type Error struct {
+	Line    int    `json:"line" yaml:"line"`
+	Column  int    `json:"column" yaml:"column"`
+	Path    string `json:"path" yaml:"path"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// Error implements the error interface
+func (e Error) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// ConfigError returned when a config file fails to parse or validate
+type ConfigError struct {
+	File   string  `json:"file" yaml:"file"`
+	Errors []Error `json:"errors" yaml:"errors"`
+}
+
+// Error implements the error interface listing every problem prefixed with
+// the file name
+func (e *ConfigError) Error() string {
+	lines := []string{fmt.Sprintf("invalid %s", e.File)}
+	for _, err := range e.Errors {
+		lines = append(lines, fmt.Sprintf("  %s:%s", e.File, err.Error()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// definition the subset of JSON Schema used by ardi schemas
+type definition struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 interface{}            `json:"type"`
+	Properties           map[string]*definition `json:"properties"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"`
+	Items                *definition            `json:"items"`
+	Required             []string               `json:"required"`
+	Minimum              *float64               `json:"minimum"`
+	Definitions          map[string]*definition `json:"definitions"`
+}
+
+// node a parsed JSON value along with its offset in the source
+type node struct {
+	kind       string
+	offset     int64
+	keys       []string
+	keyOffsets map[string]int64
+	fields     map[string]*node
+	items      []*node
+	number     json.Number
+}
+
+// ValidateArdiConfig validates ardi.json content against ArdiConfigSchema.
+// All problems found are returned sorted by their position, an empty slice
+// is returned if the content is valid.
+func ValidateArdiConfig(data []byte) []Error {
+	return Validate(ArdiConfigSchema, data)
+}
+
+// ValidateWorkspaceConfig validates ardi-workspace.json content against
+// WorkspaceConfigSchema the same way ValidateArdiConfig does
+func ValidateWorkspaceConfig(data []byte) []Error {
+	return Validate(WorkspaceConfigSchema, data)
+}
+
+// Validate validates JSON content against a JSON Schema. Only the schema
+// keywords used by ardi schemas are supported.
+func Validate(schemaData, data []byte) []Error {
+	var root definition
+	if err := json.Unmarshal(schemaData, &root); err != nil {
+		return []Error{{Line: 1, Column: 1, Message: fmt.Sprintf("invalid schema: %s", err)}}
+	}
+
+	value, err := parse(data)
+	if err != nil {
+		offset := int64(len(data))
+		var syntaxErr *json.SyntaxError
+		var parseErr *parseError
+		switch {
+		case errors.As(err, &syntaxErr):
+			// the offending character is the last byte read
+			offset = syntaxErr.Offset - 1
+		case errors.As(err, &parseErr):
+			offset = parseErr.offset
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			err = errors.New("unexpected end of JSON input")
+		}
+		line, column := position(data, offset)
+		return []Error{{Line: line, Column: column, Message: err.Error()}}
+	}
+
+	v := &validator{root: &root}
+	v.validate(&root, value, "")
+
+	sort.SliceStable(v.failures, func(i, j int) bool {
+		return v.failures[i].offset < v.failures[j].offset
+	})
+
+	errs := []Error{}
+	for _, f := range v.failures {
+		line, column := position(data, f.offset)
+		errs = append(errs, Error{Line: line, Column: column, Path: f.path, Message: f.message})
+	}
+
+	return errs
+}
+
+// private
+type failure struct {
+	offset  int64
+	path    string
+	message string
+}
+
+type validator struct {
+	root     *definition
+	failures []failure
+}
+
+func (v *validator) fail(offset int64, path, format string, args ...interface{}) {
+	v.failures = append(v.failures, failure{
+		offset:  offset,
+		path:    path,
+		message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) resolve(def *definition) *definition {
+	for def.Ref != "" {
+		name := strings.TrimPrefix(def.Ref, "#/definitions/")
+		ref, ok := v.root.Definitions[name]
+		if !ok {
+			return &definition{}
+		}
+		def = ref
+	}
+	return def
+}
+
+func (v *validator) validate(def *definition, value *node, path string) {
+	def = v.resolve(def)
+
+	types := schemaTypes(def.Type)
+	if len(types) > 0 && !matchesType(types, value) {
+		v.fail(value.offset, path, "expected %s, got %s", strings.Join(types, " or "), value.kind)
+		return
+	}
+
+	switch value.kind {
+	case "object":
+		v.validateObject(def, value, path)
+	case "array":
+		if def.Items != nil {
+			for i, item := range value.items {
+				v.validate(def.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case "number":
+		if def.Minimum != nil {
+			if n, err := value.number.Float64(); err == nil && n < *def.Minimum {
+				v.fail(value.offset, path, "must be at least %s", strconv.FormatFloat(*def.Minimum, 'f', -1, 64))
+			}
+		}
+	}
+}
+
+func (v *validator) validateObject(def *definition, value *node, path string) {
+	for _, name := range def.Required {
+		if _, ok := value.fields[name]; !ok {
+			v.fail(value.offset, path, "missing required field %q", name)
+		}
+	}
+
+	additional, allowed := additionalProperties(def.AdditionalProperties)
+
+	for _, key := range value.keys {
+		child := value.fields[key]
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+
+		if prop, ok := def.Properties[key]; ok {
+			v.validate(prop, child, childPath)
+			continue
+		}
+		if additional != nil {
+			v.validate(additional, child, childPath)
+			continue
+		}
+		if !allowed {
+			message := fmt.Sprintf("unknown field %q", key)
+			if suggestion := closest(key, def.Properties); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			v.fail(value.keyOffsets[key], path, "%s", message)
+		}
+	}
+}
+
+// additionalProperties returns the schema additional properties must match,
+// or whether they are allowed when the keyword is a boolean
+func additionalProperties(raw json.RawMessage) (*definition, bool) {
+	if len(raw) == 0 {
+		return nil, true
+	}
+	var allowed bool
+	if err := json.Unmarshal(raw, &allowed); err == nil {
+		return nil, allowed
+	}
+	var def definition
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return nil, true
+	}
+	return &def, true
+}
+
+func schemaTypes(t interface{}) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := []string{}
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesType(types []string, value *node) bool {
+	for _, t := range types {
+		if t == value.kind {
+			return true
+		}
+		if t == "integer" && value.kind == "number" {
+			if _, err := value.number.Int64(); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// closest returns the property name within an edit distance of two of key
+func closest(key string, properties map[string]*definition) string {
+	best := ""
+	bestDistance := 3
+	names := []string{}
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if d := distance(strings.ToLower(key), strings.ToLower(name)); d < bestDistance {
+			best = name
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// distance returns the levenshtein distance between a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// position returns the 1 based line and column of offset in data
+func position(data []byte, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
+
+// parse decodes data into nodes recording the offset of every value and key
+func parse(data []byte) (*node, error) {
+	p := &parser{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	p.dec.UseNumber()
+
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := p.skip(p.dec.InputOffset())
+	if _, err := p.dec.Token(); err != io.EOF {
+		if err == nil {
+			return nil, &parseError{offset: offset, message: "unexpected content after top-level value"}
+		}
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// parseError a parse error that isn't reported by the json package
+type parseError struct {
+	offset  int64
+	message string
+}
+
+func (e *parseError) Error() string {
+	return e.message
+}
+
+type parser struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// skip returns the offset of the next token at or after offset
+func (p *parser) skip(offset int64) int64 {
+	for offset < int64(len(p.data)) {
+		switch p.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func (p *parser) value() (*node, error) {
+	offset := p.skip(p.dec.InputOffset())
+
+	tok, err := p.dec.Token()
+	if err == io.EOF {
+		return nil, &parseError{offset: offset, message: "unexpected end of JSON input"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n := &node{offset: offset}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			n.kind = "object"
+			n.fields = make(map[string]*node)
+			n.keyOffsets = make(map[string]int64)
+			for p.dec.More() {
+				keyOffset := p.skip(p.dec.InputOffset())
+				keyTok, err := p.dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyTok.(string)
+				child, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := n.fields[key]; !ok {
+					n.keys = append(n.keys, key)
+				}
+				n.fields[key] = child
+				n.keyOffsets[key] = keyOffset
+			}
+		} else {
+			n.kind = "array"
+			for p.dec.More() {
+				item, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				n.items = append(n.items, item)
+			}
+		}
+		// closing delimiter
+		if _, err := p.dec.Token(); err == io.EOF {
+			return nil, &parseError{offset: int64(len(p.data)), message: "unexpected end of JSON input"}
+		} else if err != nil {
+			return nil, err
+		}
+	case string:
+		n.kind = "string"
+	case json.Number:
+		n.kind = "number"
+		n.number = t
+	case bool:
+		n.kind = "boolean"
+	case nil:
+		n.kind = "null"
+	}
+
+	return n, nil
+}
@@ -0,0 +1,106 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/schema"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(t *testing.T) {
+	jsonFields := func(v interface{}) []string {
+		fields := []string{}
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+			fields = append(fields, name)
+		}
+		return fields
+	}
+
+	testutil.RunUnitTest("documents every ardi.json field", t, func(env *testutil.UnitTestEnv) {
+		var s struct {
+			Properties  map[string]interface{} `json:"properties"`
+			Definitions struct {
+				Build struct {
+					Properties map[string]interface{} `json:"properties"`
+				} `json:"build"`
+			} `json:"definitions"`
+		}
+		err := json.Unmarshal(schema.ArdiConfigSchema, &s)
+		assert.NoError(env.T, err)
+
+		for _, field := range jsonFields(types.ArdiConfig{}) {
+			assert.Contains(env.T, s.Properties, field)
+		}
+		for _, field := range jsonFields(types.ArdiBuild{}) {
+			assert.Contains(env.T, s.Definitions.Build.Properties, field)
+		}
+	})
+
+	testutil.RunUnitTest("accepts valid configs", t, func(env *testutil.UnitTestEnv) {
+		config := `{
+  "$schema": "./ardi.schema.json",
+  "platforms": {"arduino:avr": "1.8.6"},
+  "boardUrls": null,
+  "libraries": {},
+  "builds": {
+    "uno": {
+      "directory": "blink",
+      "sketch": "blink/blink.ino",
+      "baud": 9600,
+      "fqbn": "arduino:avr:uno",
+      "props": {"build.extra_flags": "-DDEBUG"},
+      "preBuild": ["echo pre"],
+      "injectVersion": true
+    }
+  },
+  "scripts": {"flash": "ardi upload uno"}
+}`
+		assert.Empty(env.T, schema.ValidateArdiConfig([]byte(config)))
+	})
+
+	testutil.RunUnitTest("reports schema violations", t, func(env *testutil.UnitTestEnv) {
+		config := "{\n" +
+			"  \"platform\": {},\n" +
+			"  \"builds\": {\n" +
+			"    \"uno\": {\"baud\": -1, \"preBuild\": [\"ok\", 1], \"props\": []}\n" +
+			"  }\n" +
+			"}"
+
+		assert.Equal(env.T, []schema.Error{
+			{Line: 2, Column: 3, Path: "", Message: `unknown field "platform", did you mean "platforms"?`},
+			{Line: 4, Column: 21, Path: "builds.uno.baud", Message: "must be at least 0"},
+			{Line: 4, Column: 44, Path: "builds.uno.preBuild[1]", Message: "expected string, got number"},
+			{Line: 4, Column: 57, Path: "builds.uno.props", Message: "expected object or null, got array"},
+		}, schema.ValidateArdiConfig([]byte(config)))
+	})
+
+	testutil.RunUnitTest("reports syntax errors", t, func(env *testutil.UnitTestEnv) {
+		errs := schema.ValidateArdiConfig([]byte("{\n  \"builds\": {},\n}"))
+		assert.Equal(env.T, []schema.Error{
+			{Line: 2, Column: 15, Message: "invalid character ',' looking for beginning of value"},
+		}, errs)
+
+		errs = schema.ValidateArdiConfig([]byte(""))
+		assert.Equal(env.T, []schema.Error{
+			{Line: 1, Column: 1, Message: "unexpected end of JSON input"},
+		}, errs)
+	})
+
+	testutil.RunUnitTest("formats config errors", t, func(env *testutil.UnitTestEnv) {
+		err := &schema.ConfigError{
+			File: "ardi.json",
+			Errors: []schema.Error{
+				{Line: 1, Column: 2, Message: "bad"},
+				{Line: 3, Column: 4, Path: "builds", Message: "worse"},
+			},
+		}
+		assert.EqualError(env.T, err, "invalid ardi.json\n  ardi.json:1:2: bad\n  ardi.json:3:4: builds: worse")
+	})
+}
@@ -0,0 +1,14 @@
+#include <Counter.h>
+
+Counter counter;
+
+void setup()
+{
+  Serial.begin(9600);
+}
+
+void loop() {
+  counter.increment();
+  Serial.println(counter.value());
+  delay(1000);
+}
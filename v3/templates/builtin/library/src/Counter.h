@@ -0,0 +1,16 @@
+#ifndef COUNTER_H
+#define COUNTER_H
+
+#include <stdint.h>
+
+class Counter {
+public:
+  void increment() { count++; }
+  void reset() { count = 0; }
+  uint32_t value() const { return count; }
+
+private:
+  uint32_t count = 0;
+};
+
+#endif
@@ -0,0 +1,15 @@
+#include "src/led.h"
+
+Led led(LED_BUILTIN);
+
+void setup()
+{
+  Serial.begin(115200);
+  led.begin();
+}
+
+void loop() {
+  led.toggle();
+  Serial.println(led.isOn() ? "on" : "off");
+  delay(1000);
+}
@@ -0,0 +1,17 @@
+#include "led.h"
+
+Led::Led(uint8_t pin) : pin(pin), on(false) {}
+
+void Led::begin() {
+  pinMode(pin, OUTPUT);
+  digitalWrite(pin, LOW);
+}
+
+void Led::toggle() {
+  on = !on;
+  digitalWrite(pin, on ? HIGH : LOW);
+}
+
+bool Led::isOn() const {
+  return on;
+}
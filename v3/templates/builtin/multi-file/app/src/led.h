@@ -0,0 +1,18 @@
+#ifndef LED_H
+#define LED_H
+
+#include <Arduino.h>
+
+class Led {
+public:
+  explicit Led(uint8_t pin);
+  void begin();
+  void toggle();
+  bool isOn() const;
+
+private:
+  uint8_t pin;
+  bool on;
+};
+
+#endif
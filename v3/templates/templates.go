@@ -0,0 +1,193 @@
+package templates
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+// configFile name of the ardi.json every template must contain
+const configFile = "ardi.json"
+
+// buildDir name of the directory compiled artifacts are exported to
+const buildDir = "build"
+
+// skipped reports whether a template file or directory is local state, such
+// as secrets, installed dependencies, or build output, that is never copied
+func skipped(d fs.DirEntry) bool {
+	name := d.Name()
+	if d.IsDir() {
+		return name == ".git" || name == paths.DataDirName || name == buildDir
+	}
+	return name == paths.SecretsFile ||
+		name == paths.DotEnvFile ||
+		strings.HasSuffix(name, paths.ArdiConfigLockFile)
+}
+
+//go:embed builtin
+var builtinFS embed.FS
+
+// builtins templates shipped with ardi in the order they are listed
+var builtins = []struct {
+	name        string
+	description string
+}{
+	{"blink", "Blink the built-in LED of an Arduino Uno"},
+	{"multi-file", "Sketch with additional sources in a src/ directory"},
+	{"library", "Header-only library with an example sketch build"},
+}
+
+// Template represents a project template. A template is a directory
+// containing an ardi.json, listing the platforms, libraries, and builds of
+// the project, along with the sketch files for its builds.
+type Template struct {
+	Name        string
+	Description string
+	files       fs.FS
+	cleanup     func() error
+}
+
+// Builtin returns the templates shipped with ardi
+func Builtin() []Template {
+	templates := []Template{}
+	for _, b := range builtins {
+		files, _ := fs.Sub(builtinFS, path.Join("builtin", b.name))
+		templates = append(templates, Template{
+			Name:        b.name,
+			Description: b.description,
+			files:       files,
+		})
+	}
+	return templates
+}
+
+// BuiltinNames returns the names of the templates shipped with ardi
+func BuiltinNames() []string {
+	names := []string{}
+	for _, b := range builtins {
+		names = append(names, b.name)
+	}
+	return names
+}
+
+// Load returns the template for source, which is either the name of a
+// builtin template, a local directory, or a git repository url. Close must
+// be called on the returned template to remove any cloned repository.
+func Load(source string) (*Template, error) {
+	for _, t := range Builtin() {
+		if t.Name == source {
+			return validate(&t)
+		}
+	}
+
+	if stat, err := os.Stat(source); err == nil && stat.IsDir() {
+		return validate(&Template{Name: source, files: os.DirFS(source)})
+	}
+
+	if IsGitURL(source) {
+		dir, err := os.MkdirTemp("", "ardi-template-")
+		if err != nil {
+			return nil, err
+		}
+		t := &Template{
+			Name:    source,
+			files:   os.DirFS(dir),
+			cleanup: func() error { return os.RemoveAll(dir) },
+		}
+		if err := util.GitClone(source, dir); err != nil {
+			t.Close()
+			return nil, fmt.Errorf("failed to clone template %s: %w", source, err)
+		}
+		return validate(t)
+	}
+
+	return nil, fmt.Errorf(
+		"unknown template %s, expected a directory, git repository, or one of: %s",
+		source,
+		strings.Join(BuiltinNames(), ", "),
+	)
+}
+
+// IsGitURL returns whether source looks like a git repository url
+func IsGitURL(source string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "git@", "file://"} {
+		if strings.HasPrefix(source, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(source, ".git")
+}
+
+// Config returns the ardi.json of the template
+func (t *Template) Config() (*types.ArdiConfig, error) {
+	data, err := fs.ReadFile(t.files, configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.ParseArdiConfig(path.Join(t.Name, configFile), data)
+}
+
+// Render copies the template files into dir skipping git metadata, secrets,
+// the data directory, lock files, and build output. Existing files are never
+// overwritten.
+func (t *Template) Render(dir string) error {
+	return fs.WalkDir(t.files, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p != "." && skipped(d) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		dest := filepath.Join(dir, filepath.FromSlash(p))
+
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+
+		if _, err := os.Stat(dest); err == nil {
+			return fmt.Errorf("%s already exists", dest)
+		}
+
+		data, err := fs.ReadFile(t.files, p)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(dest, data, 0644)
+	})
+}
+
+// Close removes any repository cloned for the template
+func (t *Template) Close() error {
+	if t.cleanup == nil {
+		return nil
+	}
+	return t.cleanup()
+}
+
+// private helpers
+func validate(t *Template) (*Template, error) {
+	if _, err := t.Config(); err != nil {
+		t.Close()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("template %s is missing %s", t.Name, configFile)
+		}
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,164 @@
+package templates_test
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/templates"
+)
+
+func TestTemplates(t *testing.T) {
+	t.Run("loads and renders every builtin template", func(st *testing.T) {
+		for _, name := range templates.BuiltinNames() {
+			tmpl, err := templates.Load(name)
+			assert.NoError(st, err)
+
+			config, err := tmpl.Config()
+			assert.NoError(st, err)
+			assert.NotEmpty(st, config.Builds)
+			assert.NotEmpty(st, config.Platforms)
+
+			dir := st.TempDir()
+			err = tmpl.Render(dir)
+			assert.NoError(st, err)
+
+			assert.FileExists(st, path.Join(dir, "ardi.json"))
+			for _, build := range config.Builds {
+				assert.FileExists(st, path.Join(dir, build.Sketch))
+			}
+			assert.NoError(st, tmpl.Close())
+		}
+	})
+
+	t.Run("renders multi-file template with src directory", func(st *testing.T) {
+		tmpl, err := templates.Load("multi-file")
+		assert.NoError(st, err)
+
+		dir := st.TempDir()
+		err = tmpl.Render(dir)
+		assert.NoError(st, err)
+
+		assert.FileExists(st, path.Join(dir, "app", "app.ino"))
+		assert.FileExists(st, path.Join(dir, "app", "src", "led.h"))
+		assert.FileExists(st, path.Join(dir, "app", "src", "led.cpp"))
+	})
+
+	t.Run("does not overwrite existing files", func(st *testing.T) {
+		tmpl, err := templates.Load("blink")
+		assert.NoError(st, err)
+
+		dir := st.TempDir()
+		err = os.WriteFile(path.Join(dir, "ardi.json"), []byte("{}"), 0644)
+		assert.NoError(st, err)
+
+		err = tmpl.Render(dir)
+		assert.Error(st, err)
+
+		data, err := os.ReadFile(path.Join(dir, "ardi.json"))
+		assert.NoError(st, err)
+		assert.Equal(st, "{}", string(data))
+	})
+
+	t.Run("loads template from local directory", func(st *testing.T) {
+		src := st.TempDir()
+		err := os.WriteFile(path.Join(src, "ardi.json"), []byte(`{"builds": {}}`), 0644)
+		assert.NoError(st, err)
+		err = os.MkdirAll(path.Join(src, "sketch"), 0755)
+		assert.NoError(st, err)
+		err = os.WriteFile(path.Join(src, "sketch", "sketch.ino"), []byte("void setup() {}"), 0644)
+		assert.NoError(st, err)
+
+		tmpl, err := templates.Load(src)
+		assert.NoError(st, err)
+
+		dir := st.TempDir()
+		err = tmpl.Render(dir)
+		assert.NoError(st, err)
+		assert.FileExists(st, path.Join(dir, "sketch", "sketch.ino"))
+	})
+
+	t.Run("skips local state of a project used as a template", func(st *testing.T) {
+		src := st.TempDir()
+		files := map[string]string{
+			"ardi.json":                   `{"builds": {}}`,
+			"ardi.secrets.json":           `{"token": "secret"}`,
+			".env":                        "TOKEN=secret\n",
+			"sketch/sketch.ino":           "void setup() {}",
+			"sketch/build/sketch.ino.hex": ":00000001FF\n",
+			".ardi/arduino-cli.yaml":      "{}",
+			".ardi/ardi.json.lock":        "",
+			"ardi.json.lock":              "",
+		}
+		for name, content := range files {
+			p := path.Join(src, name)
+			assert.NoError(st, os.MkdirAll(path.Dir(p), 0755))
+			assert.NoError(st, os.WriteFile(p, []byte(content), 0644))
+		}
+
+		tmpl, err := templates.Load(src)
+		assert.NoError(st, err)
+
+		dir := st.TempDir()
+		err = tmpl.Render(dir)
+		assert.NoError(st, err)
+		assert.FileExists(st, path.Join(dir, "ardi.json"))
+		assert.FileExists(st, path.Join(dir, "sketch", "sketch.ino"))
+		assert.NoFileExists(st, path.Join(dir, "ardi.secrets.json"))
+		assert.NoFileExists(st, path.Join(dir, ".env"))
+		assert.NoFileExists(st, path.Join(dir, "ardi.json.lock"))
+		assert.NoDirExists(st, path.Join(dir, "sketch", "build"))
+		assert.NoDirExists(st, path.Join(dir, ".ardi"))
+	})
+
+	t.Run("loads template from git repository", func(st *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			st.Skip("git not installed")
+		}
+
+		src := st.TempDir()
+		err := os.WriteFile(path.Join(src, "ardi.json"), []byte(`{"builds": {}}`), 0644)
+		assert.NoError(st, err)
+
+		for _, args := range [][]string{
+			{"init", "-q"},
+			{"add", "."},
+			{"-c", "user.name=ardi", "-c", "user.email=ardi@example.com", "commit", "-q", "-m", "initial"},
+		} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = src
+			out, err := cmd.CombinedOutput()
+			assert.NoError(st, err, string(out))
+		}
+
+		tmpl, err := templates.Load("file://" + src)
+		assert.NoError(st, err)
+		defer tmpl.Close()
+
+		dir := st.TempDir()
+		err = tmpl.Render(dir)
+		assert.NoError(st, err)
+		assert.FileExists(st, path.Join(dir, "ardi.json"))
+		assert.NoDirExists(st, path.Join(dir, ".git"))
+	})
+
+	t.Run("errors if template is missing ardi.json", func(st *testing.T) {
+		_, err := templates.Load(st.TempDir())
+		assert.ErrorContains(st, err, "missing ardi.json")
+	})
+
+	t.Run("errors for unknown template", func(st *testing.T) {
+		_, err := templates.Load("noop")
+		assert.EqualError(st, err, "unknown template noop, expected a directory, git repository, or one of: blink, multi-file, library")
+	})
+
+	t.Run("detects git urls", func(st *testing.T) {
+		assert.True(st, templates.IsGitURL("https://github.com/me/template"))
+		assert.True(st, templates.IsGitURL("git@github.com:me/template.git"))
+		assert.True(st, templates.IsGitURL("template.git"))
+		assert.False(st, templates.IsGitURL("../template"))
+	})
+}
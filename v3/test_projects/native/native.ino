@@ -0,0 +1,19 @@
+#include "src/counter.h"
+
+Counter counter(3);
+
+void setup()
+{
+  Serial.begin(9600);
+  pinMode(LED_BUILTIN, OUTPUT);
+}
+
+void loop()
+{
+  if (counter.tick())
+  {
+    digitalWrite(LED_BUILTIN, !digitalRead(LED_BUILTIN));
+    Serial.println(counter.label());
+  }
+  delay(1000);
+}
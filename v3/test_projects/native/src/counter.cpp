@@ -0,0 +1,19 @@
+#include "counter.h"
+
+Counter::Counter(unsigned int every) : every(every), ticks(0) {}
+
+bool Counter::tick()
+{
+  ticks++;
+  return ticks % every == 0;
+}
+
+unsigned int Counter::count() const
+{
+  return ticks;
+}
+
+String Counter::label() const
+{
+  return String("count: ") + String(ticks);
+}
@@ -0,0 +1,19 @@
+#ifndef COUNTER_H
+#define COUNTER_H
+
+#include <Arduino.h>
+
+class Counter
+{
+public:
+  Counter(unsigned int every);
+  bool tick();
+  unsigned int count() const;
+  String label() const;
+
+private:
+  unsigned int every;
+  unsigned int ticks;
+};
+
+#endif
@@ -0,0 +1,25 @@
+#include <ArdiTest.h>
+#include "counter.h"
+
+TEST(fires_every_third_tick)
+{
+  Counter c(3);
+  ASSERT_FALSE(c.tick());
+  ASSERT_FALSE(c.tick());
+  ASSERT_TRUE(c.tick());
+  ASSERT_EQ(3u, c.count());
+}
+
+TEST(formats_label)
+{
+  Counter c(2);
+  c.tick();
+  ASSERT_EQ(String("count: 1"), c.label());
+}
+
+TEST(uses_fake_clock)
+{
+  unsigned long start = millis();
+  delay(250);
+  ASSERT_EQ(start + 250, millis());
+}
@@ -31,8 +31,20 @@ func CleanCoreDir() {
 func CleanCommandsDir() {
 	projectDataDir := path.Join(here, "../commands/.ardi")
 	projectJSONFile := path.Join(here, "../commands/ardi.json")
+	gitIgnoreFile := path.Join(here, "../commands/.gitignore")
 	os.RemoveAll(projectDataDir)
 	os.Remove(projectJSONFile)
+	os.Remove(gitIgnoreFile)
+}
+
+// CleanUIDir removes project data from ui directory
+func CleanUIDir() {
+	projectDataDir := path.Join(here, "../ui/.ardi")
+	projectJSONFile := path.Join(here, "../ui/ardi.json")
+	gitIgnoreFile := path.Join(here, "../ui/.gitignore")
+	os.RemoveAll(projectDataDir)
+	os.Remove(projectJSONFile)
+	os.Remove(gitIgnoreFile)
 }
 
 // CleanPixieDir removes project data from test pixie project directory
@@ -55,7 +67,9 @@ func CleanBuilds() {
 func CleanAll() {
 	CleanCoreDir()
 	CleanCommandsDir()
+	CleanUIDir()
 	CleanBuilds()
+	os.RemoveAll(path.Join(here, ".ardi"))
 }
 
 // ArduinoMegaFQBN returns appropriate fqbn for arduino mega 2560
@@ -143,6 +157,11 @@ func Blink14400ProjectDir() string {
 	return path.Join(here, "../test_projects/blink14400")
 }
 
+// NativeProjectDir returns path to native test project directory
+func NativeProjectDir() string {
+	return path.Join(here, "../test_projects/native")
+}
+
 // PixieProjectDir returns path to blink project directory
 func PixieProjectDir() string {
 	return path.Join(here, "../test_projects/pixie")
@@ -3,6 +3,7 @@ package testutil
 import (
 	"bytes"
 	"context"
+	"io"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -12,6 +13,7 @@ import (
 	"github.com/robgonnella/ardi/v3/commands"
 	"github.com/robgonnella/ardi/v3/core"
 	"github.com/robgonnella/ardi/v3/mocks"
+	"github.com/robgonnella/ardi/v3/paths"
 	"github.com/robgonnella/ardi/v3/util"
 )
 
@@ -57,20 +59,24 @@ func RunUnitTest(name string, t *testing.T, f func(env *UnitTestEnv)) {
 		logger.SetOutput(&b)
 		logger.SetLevel(log.DebugLevel)
 
-		ardiConfig, svrSettings := util.GetAllSettings()
-		settingsPath := util.GetCliSettingsPath()
+		projectPaths := paths.NewProjectPaths(".")
+		ardiConfig, svrSettings, err := util.GetAllSettings(projectPaths)
+		if err != nil {
+			st.Fatal(err)
+		}
 
-		cliInstance.EXPECT().InitSettings(settingsPath).AnyTimes()
+		cliInstance.EXPECT().InitSettings(projectPaths.ArduinoCliConfig).AnyTimes()
 		withArduinoCli := core.WithArduinoCli(cliInstance)
 
 		coreOpts := core.NewArdiCoreOpts{
 			Ctx:                ctx,
 			Logger:             logger,
-			CliSettingsPath:    settingsPath,
+			Paths:              projectPaths,
 			ArdiConfig:         *ardiConfig,
 			ArduinoCliSettings: *svrSettings,
 		}
-		ardiCore := core.NewArdiCore(coreOpts, withArduinoCli)
+		withSerialPort := core.WithSerialPort(portInatance)
+		ardiCore := core.NewArdiCore(coreOpts, withArduinoCli, withSerialPort)
 
 		env := UnitTestEnv{
 			T:          st,
@@ -127,24 +133,14 @@ func (e *IntegrationTestEnv) RunProjectInit() error {
 
 // Execute executes the root command with given arguments
 func (e *IntegrationTestEnv) Execute(args []string) error {
-	ardiConfig, svrSettings := util.GetAllSettings()
-	cliSettingsPath := util.GetCliSettingsPath()
-
-	coreOpts := core.NewArdiCoreOpts{
-		Ctx:                e.ctx,
-		Logger:             e.logger,
-		CliSettingsPath:    cliSettingsPath,
-		ArdiConfig:         *ardiConfig,
-		ArduinoCliSettings: *svrSettings,
-	}
-
 	arduinoCli := cli.NewArduinoCli()
 	withArduinoCli := core.WithArduinoCli(arduinoCli)
-	ardiCore := core.NewArdiCore(coreOpts, withArduinoCli)
 
 	env := &commands.CommandEnv{
-		ArdiCore: ardiCore,
-		Logger:   e.logger,
+		Logger: e.logger,
+		NewArdiCore: func(opts core.NewArdiCoreOpts) *core.ArdiCore {
+			return core.NewArdiCore(opts, withArduinoCli)
+		},
 	}
 
 	rootCmd := commands.NewRootCmd(env)
@@ -164,6 +160,7 @@ func (e *IntegrationTestEnv) ClearStdout() {
 // MockIntegrationTestEnv represents our integration test environment with a mocked arduino cli
 type MockIntegrationTestEnv struct {
 	T          *testing.T
+	Stdin      io.Reader
 	Stdout     *bytes.Buffer
 	ArdiCore   *core.ArdiCore
 	ArduinoCli *mocks.MockCli
@@ -223,30 +220,23 @@ func (e *MockIntegrationTestEnv) ClearStdout() {
 
 // Execute executes the root command with given arguments for mock cli test
 func (e *MockIntegrationTestEnv) Execute(args []string) error {
-	ardiConfig, svrSettings := util.GetAllSettings()
-	cliSettingsPath := util.GetCliSettingsPath()
-
-	coreOpts := core.NewArdiCoreOpts{
-		Ctx:                e.ctx,
-		Logger:             e.logger,
-		CliSettingsPath:    cliSettingsPath,
-		ArdiConfig:         *ardiConfig,
-		ArduinoCliSettings: *svrSettings,
-	}
-
 	withArduinoCli := core.WithArduinoCli(e.ArduinoCli)
-	ardiCore := core.NewArdiCore(coreOpts, withArduinoCli)
-
-	e.ArdiCore = ardiCore
+	withSerialPort := core.WithSerialPort(e.SerialPort)
 
 	env := &commands.CommandEnv{
-		ArdiCore: ardiCore,
-		Logger:   e.logger,
+		Logger: e.logger,
+		NewArdiCore: func(opts core.NewArdiCoreOpts) *core.ArdiCore {
+			e.ArdiCore = core.NewArdiCore(opts, withArduinoCli, withSerialPort)
+			return e.ArdiCore
+		},
 	}
 
 	rootCmd := commands.NewRootCmd(env)
 	rootCmd.SetOut(e.logger.Out)
 	rootCmd.SetArgs(args)
+	if e.Stdin != nil {
+		rootCmd.SetIn(e.Stdin)
+	}
 
 	return rootCmd.ExecuteContext(e.ctx)
 }
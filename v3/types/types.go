@@ -61,17 +61,174 @@ type ArduinoCliSettings struct {
 
 // ArdiBuild represents the build properties in ardi.json
 type ArdiBuild struct {
-	Directory string            `json:"directory"`
-	Sketch    string            `json:"sketch"`
-	Baud      int               `json:"baud"`
-	FQBN      string            `json:"fqbn"`
-	Props     map[string]string `json:"props"`
+	// Extends name of a build to inherit unset fields and props from
+	Extends   string            `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Directory string            `json:"directory" yaml:"directory"`
+	Sketch    string            `json:"sketch" yaml:"sketch"`
+	Baud      int               `json:"baud" yaml:"baud"`
+	FQBN      string            `json:"fqbn" yaml:"fqbn"`
+	Props     map[string]string `json:"props" yaml:"props"`
+	PreBuild  []string          `json:"preBuild,omitempty" yaml:"preBuild,omitempty"`
+	PostBuild []string          `json:"postBuild,omitempty" yaml:"postBuild,omitempty"`
+	// BoardOptions board menu options appended to the fqbn when compiling
+	BoardOptions map[string]string `json:"boardOptions,omitempty" yaml:"boardOptions,omitempty"`
+	// InjectVersion adds version and git metadata defines to build.extra_flags,
+	// unset inherits the value of the extended build
+	InjectVersion *bool `json:"injectVersion,omitempty" yaml:"injectVersion,omitempty"`
+}
+
+// VersionInjected returns whether version defines are injected for the build
+func (b ArdiBuild) VersionInjected() bool {
+	return b.InjectVersion != nil && *b.InjectVersion
 }
 
 // ArdiConfig represents the ardi.json file
 type ArdiConfig struct {
+	// Schema JSON Schema reference used by editors, preserved when writing
+	Schema    string               `json:"$schema,omitempty"`
 	Platforms map[string]string    `json:"platforms"`
 	BoardURLS []string             `json:"boardUrls"`
 	Libraries map[string]string    `json:"libraries"`
 	Builds    map[string]ArdiBuild `json:"builds"`
+	Scripts   map[string]string    `json:"scripts,omitempty"`
+	Vars      map[string]string    `json:"vars,omitempty"`
+}
+
+// ArdiWorkspace represents the ardi-workspace.json file
+type ArdiWorkspace struct {
+	Schema  string   `json:"$schema,omitempty"`
+	Members []string `json:"members"`
+}
+
+// SketchYAML represents an arduino-cli sketch.yaml file of build profiles
+type SketchYAML struct {
+	Profiles       map[string]SketchProfile `yaml:"profiles"`
+	DefaultProfile string                   `yaml:"default_profile,omitempty"`
+	DefaultFQBN    string                   `yaml:"default_fqbn,omitempty"`
+	DefaultPort    string                   `yaml:"default_port,omitempty"`
+}
+
+// SketchProfile represents a build profile in sketch.yaml
+type SketchProfile struct {
+	Notes      string                  `yaml:"notes,omitempty"`
+	FQBN       string                  `yaml:"fqbn"`
+	Programmer string                  `yaml:"programmer,omitempty"`
+	Port       string                  `yaml:"port,omitempty"`
+	PortConfig map[string]string       `yaml:"port_config,omitempty"`
+	Protocol   string                  `yaml:"protocol,omitempty"`
+	Platforms  []SketchProfilePlatform `yaml:"platforms"`
+	Libraries  []string                `yaml:"libraries,omitempty"`
+}
+
+// SketchProfilePlatform represents a pinned platform in a sketch.yaml profile
+type SketchProfilePlatform struct {
+	Platform         string `yaml:"platform"`
+	PlatformIndexURL string `yaml:"platform_index_url,omitempty"`
+}
+
+// ImportIssue represents a setting that could not be imported from another
+// build tool in command output
+type ImportIssue struct {
+	Section string `json:"section" yaml:"section"`
+	Key     string `json:"key,omitempty" yaml:"key,omitempty"`
+	Value   string `json:"value,omitempty" yaml:"value,omitempty"`
+	Reason  string `json:"reason" yaml:"reason"`
+}
+
+// MigrationChange represents a change made, or an issue found, while
+// migrating a project in command output
+type MigrationChange struct {
+	Target string `json:"target" yaml:"target"`
+	Kind   string `json:"kind" yaml:"kind"`
+	Change string `json:"change" yaml:"change"`
+}
+
+// Setting represents a config setting in command output
+type Setting struct {
+	File  string      `json:"file" yaml:"file"`
+	Path  string      `json:"path" yaml:"path"`
+	Value interface{} `json:"value" yaml:"value"`
+}
+
+// DoctorCheck represents the result of a project health check in command
+// output
+type DoctorCheck struct {
+	Check   string `json:"check" yaml:"check"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+	Fix     string `json:"fix,omitempty" yaml:"fix,omitempty"`
+}
+
+// Platform represents a platform in command output
+type Platform struct {
+	ID        string `json:"id" yaml:"id"`
+	Name      string `json:"name" yaml:"name"`
+	Installed string `json:"installed,omitempty" yaml:"installed,omitempty"`
+	Latest    string `json:"latest,omitempty" yaml:"latest,omitempty"`
+}
+
+// Board represents a board in command output
+type Board struct {
+	Name     string `json:"name" yaml:"name"`
+	FQBN     string `json:"fqbn" yaml:"fqbn"`
+	Platform string `json:"platform,omitempty" yaml:"platform,omitempty"`
+	Port     string `json:"port,omitempty" yaml:"port,omitempty"`
+}
+
+// BoardOption represents a board menu option and its choices in command
+// output
+type BoardOption struct {
+	Option string             `json:"option" yaml:"option"`
+	Label  string             `json:"label" yaml:"label"`
+	Values []BoardOptionValue `json:"values" yaml:"values"`
+}
+
+// BoardOptionValue represents a single choice for a board option
+type BoardOptionValue struct {
+	Value   string `json:"value" yaml:"value"`
+	Label   string `json:"label" yaml:"label"`
+	Default bool   `json:"default" yaml:"default"`
+}
+
+// InstalledLibrary represents an installed library in command output
+type InstalledLibrary struct {
+	Name        string `json:"name" yaml:"name"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description" yaml:"description"`
+	Latest      string `json:"latest,omitempty" yaml:"latest,omitempty"`
+}
+
+// SearchedLibrary represents a library search result in command output
+type SearchedLibrary struct {
+	Name     string   `json:"name" yaml:"name"`
+	Latest   string   `json:"latest" yaml:"latest"`
+	Releases []string `json:"releases" yaml:"releases"`
+}
+
+// SketchInclude represents a header included by a sketch and the library
+// providing it in command output
+type SketchInclude struct {
+	Include string `json:"include" yaml:"include"`
+	Library string `json:"library,omitempty" yaml:"library,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// ProjectPlatforms represents platforms specified in ardi.json alongside
+// installed platforms in command output
+type ProjectPlatforms struct {
+	Config    map[string]string `json:"config" yaml:"config"`
+	Installed []Platform        `json:"installed" yaml:"installed"`
+}
+
+// ProjectLibraries represents libraries specified in ardi.json alongside
+// installed libraries in command output
+type ProjectLibraries struct {
+	Config    map[string]string  `json:"config" yaml:"config"`
+	Installed []InstalledLibrary `json:"installed" yaml:"installed"`
+}
+
+// VersionInfo represents ardi and arduino-cli versions in command output
+type VersionInfo struct {
+	Ardi       string `json:"ardi" yaml:"ardi"`
+	ArduinoCli string `json:"arduinoCli" yaml:"arduinoCli"`
 }
@@ -0,0 +1,355 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BuildState represents the last known state of a build in the dashboard
+type BuildState string
+
+// Possible build states
+const (
+	BuildIdle     BuildState = "idle"
+	BuildRunning  BuildState = "building"
+	BuildSuccess  BuildState = "built"
+	BuildFailed   BuildState = "build failed"
+	UploadRunning BuildState = "uploading"
+	UploadSuccess BuildState = "uploaded"
+	UploadFailed  BuildState = "upload failed"
+)
+
+// BuildStatus represents the last known status of a build
+type BuildStatus struct {
+	State   BuildState
+	Updated time.Time
+	Err     error
+}
+
+// DependencyStatus represents a platform or library and its versions
+type DependencyStatus struct {
+	Name      string
+	Declared  string
+	Installed string
+	Latest    string
+}
+
+// Outdated returns whether a newer version than the installed version is
+// available
+func (d DependencyStatus) Outdated() bool {
+	return d.Installed != "" && d.Latest != "" && d.Latest != d.Installed
+}
+
+// Missing returns whether a dependency declared in ardi.json is not
+// installed at the declared version
+func (d DependencyStatus) Missing() bool {
+	return d.Declared != "" && d.Declared != d.Installed
+}
+
+// Dashboard represents the state and actions of the ardi terminal dashboard
+type Dashboard struct {
+	project    Project
+	logger     *log.Logger
+	log        *LogBuffer
+	builds     []string
+	selected   int
+	statuses   map[string]BuildStatus
+	platforms  []DependencyStatus
+	libraries  []DependencyStatus
+	monitoring string
+	onChange   func()
+	mux        sync.Mutex
+}
+
+// NewDashboard returns a new dashboard for the given project
+func NewDashboard(project Project, logger *log.Logger) *Dashboard {
+	return &Dashboard{
+		project:  project,
+		logger:   logger,
+		log:      NewLogBuffer(defaultLogLines),
+		statuses: make(map[string]BuildStatus),
+		onChange: func() {},
+	}
+}
+
+// Log returns the dashboard's log buffer
+func (d *Dashboard) Log() *LogBuffer {
+	return d.log
+}
+
+// OnChange registers a function called whenever dashboard state changes
+func (d *Dashboard) OnChange(fn func()) {
+	d.mux.Lock()
+	d.onChange = fn
+	d.mux.Unlock()
+	d.log.OnWrite(fn)
+}
+
+// Refresh reloads builds, platforms, and libraries from the project
+func (d *Dashboard) Refresh() error {
+	builds := []string{}
+	for name := range d.project.Builds() {
+		builds = append(builds, name)
+	}
+	sort.Strings(builds)
+
+	installedPlatforms, err := d.project.InstalledPlatforms()
+	if err != nil {
+		return err
+	}
+
+	platforms := map[string]*DependencyStatus{}
+	for id, version := range d.project.ConfigPlatforms() {
+		platforms[id] = &DependencyStatus{Name: id, Declared: version}
+	}
+	for _, p := range installedPlatforms {
+		if _, ok := platforms[p.ID]; !ok {
+			platforms[p.ID] = &DependencyStatus{Name: p.ID}
+		}
+		platforms[p.ID].Installed = p.Installed
+		platforms[p.ID].Latest = p.Latest
+	}
+
+	installedLibraries, err := d.project.InstalledLibraries()
+	if err != nil {
+		return err
+	}
+
+	libraries := map[string]*DependencyStatus{}
+	for name, version := range d.project.ConfigLibraries() {
+		libraries[name] = &DependencyStatus{Name: name, Declared: version}
+	}
+	for _, l := range installedLibraries {
+		if _, ok := libraries[l.Name]; !ok {
+			libraries[l.Name] = &DependencyStatus{Name: l.Name}
+		}
+		libraries[l.Name].Installed = l.Version
+		libraries[l.Name].Latest = l.Latest
+	}
+
+	d.mux.Lock()
+	d.builds = builds
+	if d.selected >= len(builds) {
+		d.selected = 0
+	}
+	d.platforms = sortedDependencies(platforms)
+	d.libraries = sortedDependencies(libraries)
+	d.mux.Unlock()
+
+	d.changed()
+	return nil
+}
+
+// Builds returns the sorted names of all builds in ardi.json
+func (d *Dashboard) Builds() []string {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return append([]string{}, d.builds...)
+}
+
+// Selected returns the name of the currently selected build
+func (d *Dashboard) Selected() string {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if len(d.builds) == 0 {
+		return ""
+	}
+	return d.builds[d.selected]
+}
+
+// Select moves the build selection by delta, wrapping around
+func (d *Dashboard) Select(delta int) {
+	d.mux.Lock()
+	if n := len(d.builds); n > 0 {
+		d.selected = ((d.selected+delta)%n + n) % n
+	}
+	d.mux.Unlock()
+	d.changed()
+}
+
+// Status returns the last known status of a build
+func (d *Dashboard) Status(build string) BuildStatus {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if s, ok := d.statuses[build]; ok {
+		return s
+	}
+	return BuildStatus{State: BuildIdle}
+}
+
+// Platforms returns the status of all project platforms
+func (d *Dashboard) Platforms() []DependencyStatus {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return append([]DependencyStatus{}, d.platforms...)
+}
+
+// Libraries returns the status of all project libraries
+func (d *Dashboard) Libraries() []DependencyStatus {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return append([]DependencyStatus{}, d.libraries...)
+}
+
+// Monitoring returns the name of the build being monitored if any
+func (d *Dashboard) Monitoring() string {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.monitoring
+}
+
+// Build compiles the named build capturing output in the log buffer
+func (d *Dashboard) Build(build string) error {
+	d.setStatus(build, BuildRunning, nil)
+	if err := d.project.Compile(build, d.log); err != nil {
+		d.setStatus(build, BuildFailed, err)
+		return err
+	}
+	d.setStatus(build, BuildSuccess, nil)
+	return nil
+}
+
+// Upload uploads the named build capturing output in the log buffer. Any
+// active serial monitor is stopped first to free the port.
+func (d *Dashboard) Upload(build string) error {
+	d.StopMonitor()
+	d.setStatus(build, UploadRunning, nil)
+	if err := d.project.Upload(build, d.log); err != nil {
+		d.setStatus(build, UploadFailed, err)
+		return err
+	}
+	d.setStatus(build, UploadSuccess, nil)
+	return nil
+}
+
+// Monitor streams serial output for the named build into the log buffer,
+// blocking until the monitor is stopped
+func (d *Dashboard) Monitor(build string) error {
+	d.StopMonitor()
+
+	d.mux.Lock()
+	d.monitoring = build
+	d.mux.Unlock()
+	d.changed()
+
+	err := d.project.Monitor(build)
+
+	d.mux.Lock()
+	if d.monitoring == build {
+		d.monitoring = ""
+	}
+	d.mux.Unlock()
+	d.changed()
+
+	return err
+}
+
+// StopMonitor stops any active serial monitor
+func (d *Dashboard) StopMonitor() {
+	if d.Monitoring() == "" {
+		return
+	}
+	d.project.StopMonitor()
+	d.mux.Lock()
+	d.monitoring = ""
+	d.mux.Unlock()
+	d.changed()
+}
+
+// BuildLines returns the formatted lines of the builds pane
+func (d *Dashboard) BuildLines() []string {
+	builds := d.Project().Builds()
+	selected := d.Selected()
+	monitoring := d.Monitoring()
+
+	lines := []string{}
+	for _, name := range d.Builds() {
+		cursor := " "
+		if name == selected {
+			cursor = ">"
+		}
+		status := d.Status(name)
+		state := string(status.State)
+		if !status.Updated.IsZero() {
+			state = fmt.Sprintf("%s (%s)", state, status.Updated.Format("15:04:05"))
+		}
+		if name == monitoring {
+			state += " [monitoring]"
+		}
+		lines = append(lines, fmt.Sprintf("%s %-16s %-28s %s", cursor, name, builds[name].FQBN, state))
+	}
+	return lines
+}
+
+// DependencyLines returns the formatted lines of the dependencies pane
+func (d *Dashboard) DependencyLines() []string {
+	lines := []string{"Platforms"}
+	lines = append(lines, dependencyLines(d.Platforms())...)
+	lines = append(lines, "", "Libraries")
+	lines = append(lines, dependencyLines(d.Libraries())...)
+	return lines
+}
+
+// Project returns the dashboard's project
+func (d *Dashboard) Project() Project {
+	return d.project
+}
+
+// private
+func (d *Dashboard) setStatus(build string, state BuildState, err error) {
+	d.mux.Lock()
+	d.statuses[build] = BuildStatus{
+		State:   state,
+		Updated: time.Now(),
+		Err:     err,
+	}
+	d.mux.Unlock()
+
+	if err != nil {
+		d.logger.WithError(err).WithField("build", build).Error(string(state))
+	}
+
+	d.changed()
+}
+
+func (d *Dashboard) changed() {
+	d.mux.Lock()
+	fn := d.onChange
+	d.mux.Unlock()
+	fn()
+}
+
+// private helpers
+func sortedDependencies(deps map[string]*DependencyStatus) []DependencyStatus {
+	sorted := []DependencyStatus{}
+	for _, dep := range deps {
+		sorted = append(sorted, *dep)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func dependencyLines(deps []DependencyStatus) []string {
+	lines := []string{}
+	for _, dep := range deps {
+		installed := dep.Installed
+		if installed == "" {
+			installed = "-"
+		}
+		markers := ""
+		if dep.Missing() {
+			markers += fmt.Sprintf(" [wants %s]", dep.Declared)
+		}
+		if dep.Outdated() {
+			markers += fmt.Sprintf(" [outdated: %s]", dep.Latest)
+		}
+		lines = append(lines, fmt.Sprintf("  %-28s %-10s%s", dep.Name, installed, markers))
+	}
+	return lines
+}
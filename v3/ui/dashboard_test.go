@@ -0,0 +1,206 @@
+package ui_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	rpc "github.com/arduino/arduino-cli/rpc/cc/arduino/cli/commands/v1"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/ui"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+func TestDashboard(t *testing.T) {
+	instance := &rpc.Instance{Id: int32(1)}
+	fqbn := testutil.ArduinoMegaFQBN()
+	port := "/dev/ttyACM0"
+
+	connected := []*rpc.DetectedPort{
+		{
+			Port: &rpc.Port{Address: port},
+			MatchingBoards: []*rpc.BoardListItem{
+				{Name: "Arduino Mega", Fqbn: fqbn},
+			},
+		},
+	}
+
+	setup := func(env *testutil.UnitTestEnv) *ui.Dashboard {
+		util.InitProjectDirectory(env.ArdiCore.Paths)
+		err := env.ArdiCore.Config.AddBuild("blink", testutil.BlinkProjectDir(), fqbn, 0, []string{}, nil)
+		assert.NoError(env.T, err)
+		err = env.ArdiCore.Config.AddBuild("pixie", testutil.PixieProjectDir(), fqbn, 0, []string{}, nil)
+		assert.NoError(env.T, err)
+		err = env.ArdiCore.Config.AddPlatform("arduino:avr", "1.8.5")
+		assert.NoError(env.T, err)
+		err = env.ArdiCore.Config.AddLibrary("Adafruit Pixie", "1.0.2")
+		assert.NoError(env.T, err)
+
+		env.ArduinoCli.EXPECT().CreateInstance().Return(instance).AnyTimes()
+
+		project := ui.NewCoreProject(env.ArdiCore)
+		return ui.NewDashboard(project, env.Logger)
+	}
+
+	expectDependencies := func(env *testutil.UnitTestEnv) {
+		platforms := []*rpc.Platform{
+			{Id: "arduino:avr", Name: "Arduino AVR Boards", Installed: "1.8.5", Latest: "1.8.6"},
+		}
+		libraries := &rpc.LibraryListResponse{
+			InstalledLibraries: []*rpc.InstalledLibrary{
+				{Library: &rpc.Library{Name: "Adafruit Pixie", Version: "1.0.1"}},
+			},
+		}
+		// only the updatable list includes the latest release
+		updatable := &rpc.LibraryListResponse{
+			InstalledLibraries: []*rpc.InstalledLibrary{
+				{
+					Library: &rpc.Library{Name: "Adafruit Pixie", Version: "1.0.1"},
+					Release: &rpc.LibraryRelease{Version: "1.0.3"},
+				},
+			},
+		}
+		env.ArduinoCli.EXPECT().GetPlatforms(gomock.Any()).Return(platforms, nil)
+		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), &rpc.LibraryListRequest{Instance: instance}).Return(libraries, nil)
+		env.ArduinoCli.EXPECT().LibraryList(gomock.Any(), &rpc.LibraryListRequest{Instance: instance, Updatable: true}).Return(updatable, nil)
+	}
+
+	testutil.RunUnitTest("loads builds and dependency markers", t, func(env *testutil.UnitTestEnv) {
+		dashboard := setup(env)
+		expectDependencies(env)
+
+		err := dashboard.Refresh()
+		assert.NoError(env.T, err)
+
+		assert.Equal(env.T, []string{"blink", "pixie"}, dashboard.Builds())
+		assert.Equal(env.T, "blink", dashboard.Selected())
+		assert.Equal(env.T, ui.BuildIdle, dashboard.Status("blink").State)
+
+		platforms := dashboard.Platforms()
+		assert.Len(env.T, platforms, 1)
+		assert.True(env.T, platforms[0].Outdated())
+		assert.False(env.T, platforms[0].Missing())
+
+		libraries := dashboard.Libraries()
+		assert.Len(env.T, libraries, 1)
+		assert.True(env.T, libraries[0].Outdated())
+		assert.True(env.T, libraries[0].Missing())
+
+		lines := dashboard.DependencyLines()
+		assert.Contains(env.T, lines, "  arduino:avr                  1.8.5      [outdated: 1.8.6]")
+		assert.Contains(env.T, lines, "  Adafruit Pixie               1.0.1      [wants 1.0.2] [outdated: 1.0.3]")
+	})
+
+	testutil.RunUnitTest("moves selection with wrap around", t, func(env *testutil.UnitTestEnv) {
+		dashboard := setup(env)
+		expectDependencies(env)
+
+		err := dashboard.Refresh()
+		assert.NoError(env.T, err)
+
+		dashboard.Select(1)
+		assert.Equal(env.T, "pixie", dashboard.Selected())
+		dashboard.Select(1)
+		assert.Equal(env.T, "blink", dashboard.Selected())
+		dashboard.Select(-1)
+		assert.Equal(env.T, "pixie", dashboard.Selected())
+	})
+
+	testutil.RunUnitTest("builds and captures compiler output", t, func(env *testutil.UnitTestEnv) {
+		dashboard := setup(env)
+
+		changes := 0
+		dashboard.OnChange(func() { changes++ })
+
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_, _ interface{}, stdout, stderr io.Writer, _, _ interface{}) (*rpc.CompileResponse, error) {
+				io.WriteString(stdout, "Sketch uses 1024 bytes\n")
+				return &rpc.CompileResponse{}, nil
+			},
+		)
+
+		err := dashboard.Build("blink")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, ui.BuildSuccess, dashboard.Status("blink").State)
+		assert.Contains(env.T, dashboard.Log().Lines(), "Sketch uses 1024 bytes")
+		assert.Greater(env.T, changes, 0)
+	})
+
+	testutil.RunUnitTest("records failed builds", t, func(env *testutil.UnitTestEnv) {
+		dashboard := setup(env)
+		dummyErr := errors.New("dummy error")
+
+		env.ArduinoCli.EXPECT().Compile(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, dummyErr)
+
+		err := dashboard.Build("blink")
+		assert.ErrorIs(env.T, err, dummyErr)
+		status := dashboard.Status("blink")
+		assert.Equal(env.T, ui.BuildFailed, status.State)
+		assert.ErrorIs(env.T, status.Err, dummyErr)
+	})
+
+	testutil.RunUnitTest("uploads to connected board", t, func(env *testutil.UnitTestEnv) {
+		dashboard := setup(env)
+
+		env.ArduinoCli.EXPECT().ConnectedBoards(gomock.Any()).Return(connected, nil)
+		env.ArduinoCli.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ interface{}, req *rpc.UploadRequest, _, _ io.Writer) (*rpc.UploadResponse, error) {
+				assert.Equal(env.T, port, req.GetPort().GetAddress())
+				assert.Equal(env.T, fqbn, req.GetFqbn())
+				return &rpc.UploadResponse{}, nil
+			},
+		)
+
+		err := dashboard.Upload("blink")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, ui.UploadSuccess, dashboard.Status("blink").State)
+	})
+
+	testutil.RunUnitTest("records failed upload when no board is connected", t, func(env *testutil.UnitTestEnv) {
+		dashboard := setup(env)
+
+		env.ArduinoCli.EXPECT().ConnectedBoards(gomock.Any()).Return([]*rpc.DetectedPort{}, nil)
+
+		err := dashboard.Upload("blink")
+		assert.Error(env.T, err)
+		assert.Equal(env.T, ui.UploadFailed, dashboard.Status("blink").State)
+	})
+
+	testutil.RunUnitTest("monitors build serial port", t, func(env *testutil.UnitTestEnv) {
+		dashboard := setup(env)
+
+		env.ArduinoCli.EXPECT().ConnectedBoards(gomock.Any()).Return(connected, nil)
+		env.SerialPort.EXPECT().SetTargets(port, 9600)
+		env.SerialPort.EXPECT().Watch().DoAndReturn(func() error {
+			assert.Equal(env.T, "blink", dashboard.Monitoring())
+			return nil
+		})
+
+		err := dashboard.Monitor("blink")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "", dashboard.Monitoring())
+	})
+
+	testutil.RunUnitTest("resolves inherited and interpolated build fields", t, func(env *testutil.UnitTestEnv) {
+		dashboard := setup(env)
+		expectDependencies(env)
+
+		assert.NoError(env.T, env.ArdiCore.Config.SetSetting("vars.board", fqbn))
+		assert.NoError(env.T, env.ArdiCore.Config.SetSetting("builds.debug.extends", "blink"))
+		assert.NoError(env.T, env.ArdiCore.Config.SetSetting("builds.debug.fqbn", "${var:board}"))
+
+		err := dashboard.Refresh()
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, dashboard.BuildLines()[1], fqbn)
+
+		env.ArduinoCli.EXPECT().ConnectedBoards(gomock.Any()).Return(connected, nil)
+		env.SerialPort.EXPECT().SetTargets(port, 9600)
+		env.SerialPort.EXPECT().Watch().Return(nil)
+
+		err = dashboard.Monitor("debug")
+		assert.NoError(env.T, err)
+	})
+}
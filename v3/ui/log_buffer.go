@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"strings"
+	"sync"
+)
+
+const defaultLogLines = 1000
+
+// LogBuffer is a thread safe io.Writer that retains the most recent lines
+// written to it for display in the dashboard's log pane
+type LogBuffer struct {
+	lines    []string
+	partial  string
+	maxLines int
+	onWrite  func()
+	mux      sync.Mutex
+}
+
+// NewLogBuffer returns a LogBuffer retaining at most maxLines lines
+func NewLogBuffer(maxLines int) *LogBuffer {
+	return &LogBuffer{
+		maxLines: maxLines,
+		onWrite:  func() {},
+	}
+}
+
+// OnWrite registers a function called after every write
+func (b *LogBuffer) OnWrite(fn func()) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.onWrite = fn
+}
+
+// Write appends p to the buffer
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	text := strings.ReplaceAll(b.partial+string(p), "\r\n", "\n")
+	parts := strings.Split(text, "\n")
+	b.partial = parts[len(parts)-1]
+	b.lines = append(b.lines, parts[:len(parts)-1]...)
+	if over := len(b.lines) - b.maxLines; over > 0 {
+		b.lines = b.lines[over:]
+	}
+	fn := b.onWrite
+	b.mux.Unlock()
+
+	fn()
+	return len(p), nil
+}
+
+// Lines returns all retained lines including any trailing partial line
+func (b *LogBuffer) Lines() []string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	lines := append([]string{}, b.lines...)
+	if b.partial != "" {
+		lines = append(lines, b.partial)
+	}
+	return lines
+}
+
+// String returns the retained lines joined by newlines
+func (b *LogBuffer) String() string {
+	return strings.Join(b.Lines(), "\n")
+}
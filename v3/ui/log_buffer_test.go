@@ -0,0 +1,21 @@
+package ui_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/ui"
+)
+
+func TestLogBuffer(t *testing.T) {
+	t.Run("retains most recent lines", func(st *testing.T) {
+		buf := ui.NewLogBuffer(2)
+		io.WriteString(buf, "one\ntw")
+		io.WriteString(buf, "o\r\nthree\nfour")
+
+		assert.Equal(st, []string{"two", "three", "four"}, buf.Lines())
+		assert.Equal(st, "two\nthree\nfour", buf.String())
+	})
+}
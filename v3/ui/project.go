@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"io"
+
+	cli "github.com/robgonnella/ardi/v3/cli-wrapper"
+	"github.com/robgonnella/ardi/v3/core"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+// Project represents the ardi project operations used by the dashboard
+type Project interface {
+	Builds() map[string]types.ArdiBuild
+	ConfigPlatforms() map[string]string
+	ConfigLibraries() map[string]string
+	InstalledPlatforms() ([]types.Platform, error)
+	InstalledLibraries() ([]types.InstalledLibrary, error)
+	Compile(build string, out io.Writer) error
+	Upload(build string, out io.Writer) error
+	Monitor(build string) error
+	StopMonitor()
+}
+
+// CoreProject implements Project using an ArdiCore
+type CoreProject struct {
+	ardiCore *core.ArdiCore
+}
+
+// NewCoreProject returns a Project backed by the given ArdiCore
+func NewCoreProject(ardiCore *core.ArdiCore) *CoreProject {
+	return &CoreProject{ardiCore: ardiCore}
+}
+
+// Builds returns the builds specified in ardi.json with inherited fields
+// merged and variable references resolved. Builds that fail to resolve are
+// returned as written, compiling them reports the error.
+func (p *CoreProject) Builds() map[string]types.ArdiBuild {
+	builds := make(map[string]types.ArdiBuild)
+	for name, build := range p.ardiCore.Config.GetBuilds() {
+		if resolved, err := p.ardiCore.Config.InterpolatedBuild(name); err == nil {
+			build = resolved
+		}
+		builds[name] = build
+	}
+	return builds
+}
+
+// ConfigPlatforms returns the platforms specified in ardi.json
+func (p *CoreProject) ConfigPlatforms() map[string]string {
+	return p.ardiCore.Config.GetPlatforms()
+}
+
+// ConfigLibraries returns the libraries specified in ardi.json
+func (p *CoreProject) ConfigLibraries() map[string]string {
+	return p.ardiCore.Config.GetLibraries()
+}
+
+// InstalledPlatforms returns the platforms installed for the project
+func (p *CoreProject) InstalledPlatforms() ([]types.Platform, error) {
+	return p.ardiCore.Platform.ListInstalled()
+}
+
+// InstalledLibraries returns the libraries installed for the project
+func (p *CoreProject) InstalledLibraries() ([]types.InstalledLibrary, error) {
+	return p.ardiCore.Lib.ListInstalled()
+}
+
+// Compile compiles the named build writing compiler output to out
+func (p *CoreProject) Compile(build string, out io.Writer) error {
+	opts, err := p.ardiCore.Config.GetCompileOpts(build)
+	if err != nil {
+		return err
+	}
+	opts.BuildName = build
+	opts.Stdout = out
+	opts.Stderr = out
+	return p.ardiCore.Compiler.Compile(*opts)
+}
+
+// Upload uploads the named build to its connected board writing uploader
+// output to out
+func (p *CoreProject) Upload(build string, out io.Writer) error {
+	opts, err := p.ardiCore.Config.GetCompileOpts(build)
+	if err != nil {
+		return err
+	}
+
+	port, err := p.ardiCore.Uploader.FindPort(opts.FQBN)
+	if err != nil {
+		return err
+	}
+
+	return p.ardiCore.Uploader.Upload(cli.UploadOpts{
+		FQBN:       opts.FQBN,
+		SketchDir:  opts.SketchDir,
+		SketchPath: opts.SketchPath,
+		Port:       port,
+		Stdout:     out,
+		Stderr:     out,
+	})
+}
+
+// Monitor attaches to the serial port of the named build's connected board
+// and blocks while streaming its output
+func (p *CoreProject) Monitor(build string) error {
+	b, err := p.ardiCore.Config.InterpolatedBuild(build)
+	if err != nil {
+		return err
+	}
+
+	port, err := p.ardiCore.Uploader.FindPort(util.FQBNWithOptions(b.FQBN, b.BoardOptions))
+	if err != nil {
+		return err
+	}
+
+	p.ardiCore.SerialPort.SetTargets(port, b.Baud)
+	return p.ardiCore.SerialPort.Watch()
+}
+
+// StopMonitor detaches from any attached serial port
+func (p *CoreProject) StopMonitor() {
+	if p.ardiCore.SerialPort.Streaming() {
+		p.ardiCore.SerialPort.Close()
+	}
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+const (
+	buildsView = "builds"
+	depsView   = "dependencies"
+	logView    = "log"
+	helpView   = "help"
+)
+
+const helpText = "↑/↓ select  b build  u upload  m monitor  s stop monitor  r refresh  q quit"
+
+// Run renders the dashboard in the terminal and blocks until the user quits
+func Run(d *Dashboard) error {
+	g, err := gocui.NewGui(gocui.OutputNormal)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	d.OnChange(func() {
+		g.Update(func(g *gocui.Gui) error { return nil })
+	})
+	defer d.OnChange(func() {})
+	defer d.StopMonitor()
+
+	g.SetManagerFunc(func(g *gocui.Gui) error {
+		return layout(g, d)
+	})
+
+	if err := setKeybindings(g, d); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := d.Refresh(); err != nil {
+			d.logger.WithError(err).Error("Failed to load project dependencies")
+		}
+	}()
+
+	if err := g.MainLoop(); err != nil && !errors.Is(err, gocui.ErrQuit) {
+		return err
+	}
+
+	return nil
+}
+
+// private helpers
+func layout(g *gocui.Gui, d *Dashboard) error {
+	maxX, maxY := g.Size()
+	splitX := maxX * 3 / 5
+	splitY := maxY * 2 / 5
+
+	panes := []struct {
+		name           string
+		title          string
+		x0, y0, x1, y1 int
+		lines          []string
+	}{
+		{buildsView, "Builds", 0, 0, splitX - 1, splitY - 1, d.BuildLines()},
+		{depsView, "Platforms & Libraries", splitX, 0, maxX - 1, splitY - 1, d.DependencyLines()},
+		{logView, "Log", 0, splitY, maxX - 1, maxY - 3, d.Log().Lines()},
+		{helpView, "", 0, maxY - 2, maxX - 1, maxY, []string{helpText}},
+	}
+
+	for _, p := range panes {
+		v, err := g.SetView(p.name, p.x0, p.y0, p.x1, p.y1)
+		if err != nil && !errors.Is(err, gocui.ErrUnknownView) {
+			return err
+		}
+		v.Title = p.title
+		v.Frame = p.name != helpView
+		v.Clear()
+		lines := p.lines
+		if p.name == logView {
+			_, height := v.Size()
+			if height > 0 && len(lines) > height {
+				lines = lines[len(lines)-height:]
+			}
+		}
+		fmt.Fprint(v, strings.Join(lines, "\n"))
+	}
+
+	return nil
+}
+
+func setKeybindings(g *gocui.Gui, d *Dashboard) error {
+	async := func(fn func(build string) error) func(*gocui.Gui, *gocui.View) error {
+		return func(*gocui.Gui, *gocui.View) error {
+			if build := d.Selected(); build != "" {
+				go fn(build)
+			}
+			return nil
+		}
+	}
+
+	bindings := []struct {
+		key     interface{}
+		handler func(*gocui.Gui, *gocui.View) error
+	}{
+		{gocui.KeyCtrlC, quit},
+		{'q', quit},
+		{gocui.KeyArrowUp, func(*gocui.Gui, *gocui.View) error { d.Select(-1); return nil }},
+		{'k', func(*gocui.Gui, *gocui.View) error { d.Select(-1); return nil }},
+		{gocui.KeyArrowDown, func(*gocui.Gui, *gocui.View) error { d.Select(1); return nil }},
+		{'j', func(*gocui.Gui, *gocui.View) error { d.Select(1); return nil }},
+		{'b', async(d.Build)},
+		{'u', async(d.Upload)},
+		{'m', async(d.Monitor)},
+		{'s', func(*gocui.Gui, *gocui.View) error { d.StopMonitor(); return nil }},
+		{'r', func(*gocui.Gui, *gocui.View) error { go d.Refresh(); return nil }},
+	}
+
+	for _, b := range bindings {
+		if err := g.SetKeybinding("", b.key, gocui.ModNone, b.handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func quit(*gocui.Gui, *gocui.View) error {
+	return gocui.ErrQuit
+}
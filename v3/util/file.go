@@ -0,0 +1,170 @@
+package util
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+
+	"github.com/robgonnella/ardi/v3/paths"
+)
+
+// LockPath returns the path of the lock file guarding writes to p. Lock
+// files are kept in the data directory, which isn't tracked, so files
+// already in a data directory are locked alongside, project files in the
+// project data directory, and workspace member files in the shared
+// workspace data directory prefixed with the member name.
+func LockPath(p string) string {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		abs = p
+	}
+	dir, name := filepath.Split(abs)
+	dir = filepath.Clean(dir)
+	lockName := name + ".lock"
+
+	projectPaths := paths.NewProjectPaths(dir)
+	if filepath.Base(dir) == paths.DataDirName {
+		return abs + ".lock"
+	}
+
+	if root, err := paths.FindWorkspaceRoot(dir); err == nil && root != dir {
+		workspacePaths := paths.NewWorkspacePaths(root)
+		if member, err := workspacePaths.MemberName(dir); err == nil {
+			memberName := strings.ReplaceAll(member, "/", "_")
+			return filepath.Join(workspacePaths.ArduinoCliDataDir, memberName+"."+lockName)
+		}
+	}
+
+	return filepath.Join(projectPaths.ArduinoCliDataDir, lockName)
+}
+
+// LockFile takes an exclusive advisory lock guarding writes to p, blocking
+// until any other process holding the lock releases it. The lock is held on
+// a separate lock file as p may be replaced while locked. The returned
+// function releases the lock.
+func LockFile(p string) (func() error, error) {
+	lockPath := LockPath(p)
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0777); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer file.Close()
+		return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	}, nil
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory as
+// p and renames it over p so readers never see a partially written file.
+// The permissions of an existing file are preserved.
+func WriteFileAtomic(p string, data []byte, perm os.FileMode) error {
+	if stat, err := os.Stat(p); err == nil {
+		perm = stat.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p)
+}
+
+// MergeChanges applies the changes made from base to local onto disk and
+// stores the result in out. Objects are merged key by key so changes made to
+// disk by another process are kept unless local changed the same value, in
+// which case local wins. Values are compared by their JSON representation.
+func MergeChanges(base, local, disk, out interface{}) error {
+	var trees [3]interface{}
+	for i, v := range []interface{}{base, local, disk} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &trees[i]); err != nil {
+			return err
+		}
+	}
+
+	merged, err := json.Marshal(mergeTree(trees[0], trees[1], trees[2]))
+	if err != nil {
+		return err
+	}
+
+	// unmarshal merges into existing maps so out is reset first
+	if v := reflect.ValueOf(out); v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+
+	return json.Unmarshal(merged, out)
+}
+
+// private helpers
+func mergeTree(base, local, disk interface{}) interface{} {
+	if reflect.DeepEqual(base, local) {
+		return disk
+	}
+
+	baseMap, baseOK := base.(map[string]interface{})
+	localMap, localOK := local.(map[string]interface{})
+	diskMap, diskOK := disk.(map[string]interface{})
+	if !baseOK || !localOK || !diskOK {
+		return local
+	}
+
+	merged := make(map[string]interface{})
+	for key, value := range diskMap {
+		merged[key] = value
+	}
+	for key := range baseMap {
+		if _, ok := localMap[key]; !ok {
+			delete(merged, key)
+		}
+	}
+	for key, localValue := range localMap {
+		baseValue, inBase := baseMap[key]
+		diskValue, onDisk := diskMap[key]
+		switch {
+		case !inBase:
+			merged[key] = localValue
+		case !onDisk:
+			// removed externally, kept only if changed locally
+			if !reflect.DeepEqual(baseValue, localValue) {
+				merged[key] = localValue
+			}
+		default:
+			merged[key] = mergeTree(baseValue, localValue, diskValue)
+		}
+	}
+
+	return merged
+}
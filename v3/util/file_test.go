@@ -0,0 +1,118 @@
+package util_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUtilFile(t *testing.T) {
+	testutil.RunUnitTest("locks ardi.json in the project data directory", t, func(env *testutil.UnitTestEnv) {
+		projectPaths := paths.NewProjectPaths(env.T.TempDir())
+		assert.Equal(env.T, path.Join(projectPaths.ArduinoCliDataDir, paths.ArdiConfigLockFile), util.LockPath(projectPaths.ArdiConfig))
+		assert.Equal(env.T, projectPaths.ArduinoCliConfig+".lock", util.LockPath(projectPaths.ArduinoCliConfig))
+	})
+
+	testutil.RunUnitTest("locks member ardi.json in the workspace data directory", t, func(env *testutil.UnitTestEnv) {
+		workspacePaths := paths.NewWorkspacePaths(env.T.TempDir())
+		assert.NoError(env.T, os.WriteFile(workspacePaths.WorkspaceConfig, []byte(`{"members": ["boards/uno"]}`), 0644))
+		memberPaths := workspacePaths.MemberPaths("boards/uno")
+		assert.Equal(env.T, path.Join(workspacePaths.ArduinoCliDataDir, "boards_uno."+paths.ArdiConfigLockFile), util.LockPath(memberPaths.ArdiConfig))
+	})
+
+	testutil.RunUnitTest("blocks until lock is released", t, func(env *testutil.UnitTestEnv) {
+		file := path.Join(env.T.TempDir(), "ardi.json")
+
+		unlock, err := util.LockFile(file)
+		assert.NoError(env.T, err)
+
+		acquired := make(chan struct{})
+		go func() {
+			unlockSecond, err := util.LockFile(file)
+			assert.NoError(env.T, err)
+			close(acquired)
+			unlockSecond()
+		}()
+
+		select {
+		case <-acquired:
+			assert.Fail(env.T, "lock acquired while held")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		assert.NoError(env.T, unlock())
+
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			assert.Fail(env.T, "lock not acquired after release")
+		}
+	})
+
+	testutil.RunUnitTest("writes files atomically preserving permissions", t, func(env *testutil.UnitTestEnv) {
+		dir := env.T.TempDir()
+		file := path.Join(dir, "ardi.json")
+		assert.NoError(env.T, os.WriteFile(file, []byte("old"), 0600))
+
+		err := util.WriteFileAtomic(file, []byte("new"), 0644)
+		assert.NoError(env.T, err)
+
+		data, err := os.ReadFile(file)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "new", string(data))
+
+		stat, err := os.Stat(file)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, os.FileMode(0600), stat.Mode().Perm())
+
+		entries, err := os.ReadDir(dir)
+		assert.NoError(env.T, err)
+		assert.Len(env.T, entries, 1)
+	})
+
+	testutil.RunUnitTest("merges local changes into external changes", t, func(env *testutil.UnitTestEnv) {
+		base := types.ArdiConfig{
+			Platforms: map[string]string{"arduino:avr": "1.8.5"},
+			Libraries: map[string]string{"Servo": "1.1.8", "Wire": ""},
+			Builds: map[string]types.ArdiBuild{
+				"uno": {Sketch: "blink/blink.ino", FQBN: "arduino:avr:uno", Baud: 9600},
+			},
+		}
+
+		local := types.ArdiConfig{
+			Platforms: map[string]string{"arduino:avr": "1.8.6"},
+			Libraries: map[string]string{"Servo": "1.1.8"},
+			Builds: map[string]types.ArdiBuild{
+				"uno": {Sketch: "blink/blink.ino", FQBN: "arduino:avr:uno", Baud: 115200},
+			},
+		}
+
+		disk := types.ArdiConfig{
+			Platforms: map[string]string{"arduino:avr": "1.8.5", "esp32:esp32": "2.0.9"},
+			Libraries: map[string]string{"Servo": "1.2.0", "Wire": ""},
+			Builds: map[string]types.ArdiBuild{
+				"uno": {Sketch: "app/app.ino", FQBN: "arduino:avr:uno", Baud: 9600},
+			},
+			Scripts: map[string]string{"flash": "ardi upload uno"},
+		}
+
+		merged := types.ArdiConfig{Vars: map[string]string{"stale": "value"}}
+		err := util.MergeChanges(base, local, disk, &merged)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, types.ArdiConfig{
+			Platforms: map[string]string{"arduino:avr": "1.8.6", "esp32:esp32": "2.0.9"},
+			Libraries: map[string]string{"Servo": "1.2.0"},
+			Builds: map[string]types.ArdiBuild{
+				"uno": {Sketch: "app/app.ino", FQBN: "arduino:avr:uno", Baud: 115200},
+			},
+			Scripts: map[string]string{"flash": "ardi upload uno"},
+		}, merged)
+	})
+}
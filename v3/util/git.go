@@ -0,0 +1,61 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GitInfo represents version information read from a local git repository
+type GitInfo struct {
+	Version string
+	SHA     string
+}
+
+// unknownGitValue used when git information is unavailable
+const unknownGitValue = "unknown"
+
+// ReadGitInfo returns the version described by the most recent tag and the
+// commit sha of the repository containing dir. Only the local repository is
+// read. Values are set to "unknown" when dir is not within a git repository
+// or git is not installed.
+func ReadGitInfo(dir string) GitInfo {
+	info := GitInfo{Version: unknownGitValue, SHA: unknownGitValue}
+
+	if sha, err := git(dir, "rev-parse", "HEAD"); err == nil {
+		info.SHA = sha
+	}
+
+	if version, err := git(dir, "describe", "--tags", "--always", "--dirty"); err == nil {
+		info.Version = version
+	}
+
+	return info
+}
+
+// GitClone shallow clones the repository at url into dir
+func GitClone(url, dir string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "clone", "--depth", "1", url, dir)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// private helpers
+func git(dir string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.String()), nil
+}
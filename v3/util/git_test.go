@@ -0,0 +1,51 @@
+package util_test
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+func TestUtilReadGitInfo(t *testing.T) {
+	run := func(t *testing.T, dir string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(
+			os.Environ(),
+			"GIT_AUTHOR_NAME=ardi",
+			"GIT_AUTHOR_EMAIL=ardi@example.com",
+			"GIT_COMMITTER_NAME=ardi",
+			"GIT_COMMITTER_EMAIL=ardi@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+
+	t.Run("returns version from tags and commit sha", func(st *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			st.Skip("git not installed")
+		}
+
+		dir := st.TempDir()
+		run(st, dir, "init", "-q")
+		assert.NoError(st, os.WriteFile(path.Join(dir, "blink.ino"), []byte("void setup() {}"), 0644))
+		run(st, dir, "add", ".")
+		run(st, dir, "commit", "-q", "-m", "initial")
+		run(st, dir, "tag", "v1.2.3")
+
+		info := util.ReadGitInfo(dir)
+		assert.Equal(st, "v1.2.3", info.Version)
+		assert.Len(st, info.SHA, 40)
+	})
+
+	t.Run("returns unknown outside of git repository", func(st *testing.T) {
+		info := util.ReadGitInfo(st.TempDir())
+		assert.Equal(st, "unknown", info.Version)
+		assert.Equal(st, "unknown", info.SHA)
+	})
+}
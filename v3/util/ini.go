@@ -0,0 +1,99 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// INISection represents a section of an INI file
+type INISection struct {
+	Name string
+	// Keys setting names in the order they appear
+	Keys   []string
+	Values map[string]string
+}
+
+// ReadINI reads and parses an INI file, see ParseINI for listKeys
+func ReadINI(iniPath string, listKeys ...string) ([]INISection, error) {
+	file, err := os.Open(iniPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseINI(file, listKeys...)
+}
+
+// ParseINI parses INI formatted content into its sections in the order they
+// appear. Lines starting with ; or # and inline " ;" comments are ignored.
+// Indented lines without an = continue the value of the previous setting and
+// are joined with newlines, as do all indented lines following one of the
+// listKeys, whose items may contain an =. Other indented lines are settings.
+func ParseINI(r io.Reader, listKeys ...string) ([]INISection, error) {
+	sections := []INISection{}
+	var current *INISection
+	lastKey := ""
+	lineNum := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNum++
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if idx := strings.Index(line, " ;"); idx >= 0 {
+			line = strings.TrimSpace(line[:idx])
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			sections = append(sections, INISection{
+				Name:   strings.TrimSpace(line[1 : len(line)-1]),
+				Values: make(map[string]string),
+			})
+			current = &sections[len(sections)-1]
+			lastKey = ""
+			continue
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("line %d: setting outside of a section", lineNum)
+		}
+
+		indented := raw[0] == ' ' || raw[0] == '\t'
+		if indented && (!strings.Contains(line, "=") || ArrayContains(listKeys, lastKey)) {
+			if lastKey == "" {
+				return nil, fmt.Errorf("line %d: unexpected indented line", lineNum)
+			}
+			if value := current.Values[lastKey]; value != "" {
+				line = value + "\n" + line
+			}
+			current.Values[lastKey] = line
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNum)
+		}
+
+		key := strings.TrimSpace(line[:sep])
+		if _, ok := current.Values[key]; !ok {
+			current.Keys = append(current.Keys, key)
+		}
+		current.Values[key] = strings.TrimSpace(line[sep+1:])
+		lastKey = key
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
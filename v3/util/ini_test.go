@@ -0,0 +1,62 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+func TestUtilParseINI(t *testing.T) {
+	t.Run("parses sections with multi-line values and comments", func(st *testing.T) {
+		content := `; PlatformIO Project Configuration File
+[platformio]
+src_dir = firmware
+
+[env:uno]
+platform = atmelavr ; inline comment
+board: uno
+lib_deps =
+    adafruit/Adafruit BME280 Library@^2.2.2
+    # commented out
+    Wire
+`
+		sections, err := util.ParseINI(strings.NewReader(content))
+		assert.NoError(st, err)
+		assert.Len(st, sections, 2)
+
+		assert.Equal(st, "platformio", sections[0].Name)
+		assert.Equal(st, "firmware", sections[0].Values["src_dir"])
+
+		assert.Equal(st, "env:uno", sections[1].Name)
+		assert.Equal(st, []string{"platform", "board", "lib_deps"}, sections[1].Keys)
+		assert.Equal(st, "atmelavr", sections[1].Values["platform"])
+		assert.Equal(st, "uno", sections[1].Values["board"])
+		assert.Equal(st, "adafruit/Adafruit BME280 Library@^2.2.2\nWire", sections[1].Values["lib_deps"])
+	})
+
+	t.Run("parses indented settings unless continuing a list", func(st *testing.T) {
+		content := `[env:uno]
+lib_deps =
+    Wire
+  board = uno
+build_flags =
+    -DLED=13
+    -DDEBUG
+`
+		sections, err := util.ParseINI(strings.NewReader(content), "build_flags")
+		assert.NoError(st, err)
+		assert.Len(st, sections, 1)
+		assert.Equal(st, []string{"lib_deps", "board", "build_flags"}, sections[0].Keys)
+		assert.Equal(st, "-DLED=13\n-DDEBUG", sections[0].Values["build_flags"])
+		assert.Equal(st, "uno", sections[0].Values["board"])
+		assert.Equal(st, "Wire", sections[0].Values["lib_deps"])
+	})
+
+	t.Run("errors for settings outside of a section", func(st *testing.T) {
+		_, err := util.ParseINI(strings.NewReader("board = uno\n"))
+		assert.EqualError(st, err, "line 1: setting outside of a section")
+	})
+}
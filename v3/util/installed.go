@@ -0,0 +1,139 @@
+package util
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// InstalledPlatformVersions returns the versions of the platforms installed
+// in an arduino-cli data directory keyed by vendor:arch. When several
+// versions of a platform are installed the highest version is used.
+func InstalledPlatformVersions(dataDir string) map[string]string {
+	versions := make(map[string]string)
+
+	matches, _ := filepath.Glob(filepath.Join(dataDir, "packages", "*", "hardware", "*", "*"))
+
+	for _, match := range matches {
+		if stat, err := os.Stat(match); err != nil || !stat.IsDir() {
+			continue
+		}
+		version := filepath.Base(match)
+		arch := filepath.Base(filepath.Dir(match))
+		vendor := filepath.Base(filepath.Dir(filepath.Dir(filepath.Dir(match))))
+		platform := vendor + ":" + arch
+		if existing, ok := versions[platform]; !ok || versionLess(existing, version) {
+			versions[platform] = version
+		}
+	}
+
+	return versions
+}
+
+// InstalledLibraryVersions returns the versions of the libraries installed
+// in an arduino-cli user directory keyed by library name as read from each
+// library's library.properties
+func InstalledLibraryVersions(userDir string) map[string]string {
+	versions := make(map[string]string)
+
+	matches, _ := filepath.Glob(filepath.Join(userDir, "libraries", "*", "library.properties"))
+	for _, match := range matches {
+		props, err := readProperties(match)
+		if err != nil || props["name"] == "" {
+			continue
+		}
+		versions[props["name"]] = props["version"]
+	}
+
+	return versions
+}
+
+// LibraryContents the headers an installed library provides and the names
+// of the libraries it depends on
+type LibraryContents struct {
+	Headers []string
+	Depends []string
+}
+
+// InstalledLibraryContents returns the contents of the libraries installed
+// in an arduino-cli user directory keyed by library name. Headers are read
+// from the library's src directory, or its root for the legacy layout, and
+// dependencies from the depends field of its library.properties.
+func InstalledLibraryContents(userDir string) map[string]LibraryContents {
+	contents := make(map[string]LibraryContents)
+
+	matches, _ := filepath.Glob(filepath.Join(userDir, "libraries", "*", "library.properties"))
+	for _, match := range matches {
+		props, err := readProperties(match)
+		if err != nil || props["name"] == "" {
+			continue
+		}
+
+		libDir := filepath.Dir(match)
+		if stat, err := os.Stat(filepath.Join(libDir, "src")); err == nil && stat.IsDir() {
+			libDir = filepath.Join(libDir, "src")
+		}
+
+		library := LibraryContents{Headers: []string{}, Depends: []string{}}
+		entries, _ := os.ReadDir(libDir)
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".h", ".hpp":
+				library.Headers = append(library.Headers, entry.Name())
+			}
+		}
+		for _, dep := range strings.Split(props["depends"], ",") {
+			// dependencies may constrain versions, e.g. "Servo (>=1.1.0)"
+			if name := strings.TrimSpace(strings.SplitN(dep, "(", 2)[0]); name != "" {
+				library.Depends = append(library.Depends, name)
+			}
+		}
+
+		contents[props["name"]] = library
+	}
+
+	return contents
+}
+
+// private helpers
+// versionLess compares dot separated versions numerically where possible
+func versionLess(a, b string) bool {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if aParts[i] == bParts[i] {
+			continue
+		}
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		if aErr == nil && bErr == nil {
+			return aNum < bNum
+		}
+		return aParts[i] < bParts[i]
+	}
+	return len(aParts) < len(bParts)
+}
+
+func readProperties(propsPath string) (map[string]string, error) {
+	file, err := os.Open(propsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+			props[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	return props, scanner.Err()
+}
@@ -0,0 +1,73 @@
+package util_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUtilInstalledVersions(t *testing.T) {
+	testutil.RunUnitTest("returns highest installed platform versions", t, func(env *testutil.UnitTestEnv) {
+		dataDir := env.T.TempDir()
+		for _, dir := range []string{
+			"packages/arduino/hardware/avr/1.8.5",
+			"packages/arduino/hardware/avr/1.8.10",
+			"packages/esp32/hardware/esp32/2.0.9",
+			"packages/arduino/tools/avr-gcc/7.3.0",
+		} {
+			assert.NoError(env.T, os.MkdirAll(path.Join(dataDir, dir), 0755))
+		}
+
+		assert.Equal(env.T, map[string]string{
+			"arduino:avr": "1.8.10",
+			"esp32:esp32": "2.0.9",
+		}, util.InstalledPlatformVersions(dataDir))
+	})
+
+	testutil.RunUnitTest("returns installed library versions", t, func(env *testutil.UnitTestEnv) {
+		userDir := env.T.TempDir()
+		libDir := path.Join(userDir, "libraries", "Adafruit_Pixie")
+		assert.NoError(env.T, os.MkdirAll(libDir, 0755))
+		props := "# comment\nname=Adafruit Pixie\nversion=1.0.3\n"
+		assert.NoError(env.T, os.WriteFile(path.Join(libDir, "library.properties"), []byte(props), 0644))
+		assert.NoError(env.T, os.MkdirAll(path.Join(userDir, "libraries", "NoProps"), 0755))
+
+		assert.Equal(env.T, map[string]string{"Adafruit Pixie": "1.0.3"}, util.InstalledLibraryVersions(userDir))
+	})
+
+	testutil.RunUnitTest("returns installed library headers and dependencies", t, func(env *testutil.UnitTestEnv) {
+		userDir := env.T.TempDir()
+		files := map[string]string{
+			"libraries/Adafruit_BME280_Library/library.properties":  "name=Adafruit BME280 Library\ndepends=Adafruit Unified Sensor, Adafruit BusIO (>=1.0.0)\n",
+			"libraries/Adafruit_BME280_Library/Adafruit_BME280.h":   "",
+			"libraries/Adafruit_BME280_Library/Adafruit_BME280.cpp": "",
+			"libraries/U8g2/library.properties":                     "name=U8g2\n",
+			"libraries/U8g2/src/U8g2lib.h":                          "",
+			"libraries/U8g2/src/clib/u8g2.h":                        "",
+		}
+		for name, content := range files {
+			p := path.Join(userDir, name)
+			assert.NoError(env.T, os.MkdirAll(path.Dir(p), 0755))
+			assert.NoError(env.T, os.WriteFile(p, []byte(content), 0644))
+		}
+
+		assert.Equal(env.T, map[string]util.LibraryContents{
+			"Adafruit BME280 Library": {
+				Headers: []string{"Adafruit_BME280.h"},
+				Depends: []string{"Adafruit Unified Sensor", "Adafruit BusIO"},
+			},
+			"U8g2": {Headers: []string{"U8g2lib.h"}, Depends: []string{}},
+		}, util.InstalledLibraryContents(userDir))
+	})
+
+	testutil.RunUnitTest("returns empty maps for missing directories", t, func(env *testutil.UnitTestEnv) {
+		dir := path.Join(env.T.TempDir(), "noop")
+		assert.Empty(env.T, util.InstalledPlatformVersions(dir))
+		assert.Empty(env.T, util.InstalledLibraryVersions(dir))
+		assert.Empty(env.T, util.InstalledLibraryContents(dir))
+	})
+}
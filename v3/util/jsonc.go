@@ -0,0 +1,683 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// defaultJSONIndent indentation used for files without existing indentation
+const defaultJSONIndent = "\t"
+
+// StripJSONC returns JSONC data with comments and trailing commas replaced
+// by spaces so it can be decoded as JSON. Newlines are kept so the offsets,
+// lines, and columns of all values are unchanged.
+func StripJSONC(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '"':
+			i = stringEnd(out, i) - 1
+		case '/':
+			if end := commentEnd(out, i); end > i {
+				for ; i < end; i++ {
+					if out[i] != '\n' {
+						out[i] = ' '
+					}
+				}
+				i--
+			}
+		case ',':
+			if next := skipJSONCSpace(out, i+1); next < len(out) && (out[next] == '}' || out[next] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+
+	return out
+}
+
+// PatchJSONC returns the JSONC document original updated to hold value while
+// preserving its formatting, key order, and comments. Unchanged values are
+// left untouched, changed values are replaced in place, removed members are
+// deleted along with the comment lines above them, and new members are added
+// after the member preceding them in value. omitZero reports whether a
+// member at the given path may be left out when new and zero valued, which
+// keeps optional fields the document omits from being added. When original
+// is empty value is formatted with tab indentation.
+func PatchJSONC(original []byte, value interface{}, omitZero func(path []string) bool) ([]byte, error) {
+	target, err := toOrdered(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(original)) == 0 {
+		var buf bytes.Buffer
+		renderJSON(&buf, target, "", defaultJSONIndent, false)
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	}
+
+	p := &jsoncParser{data: original}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	patcher := &jsoncPatcher{
+		data:     original,
+		unit:     detectIndent(original),
+		omitZero: omitZero,
+	}
+	if err := patcher.patch(root, target, []string{}); err != nil {
+		return nil, err
+	}
+
+	return patcher.apply(), nil
+}
+
+// private
+// orderedObject a decoded JSON object that keeps its key order
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// toOrdered converts value to a tree of orderedObjects, []interface{}, and
+// scalars keeping the key order produced by encoding/json
+func toOrdered(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrdered(data)
+}
+
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeOrderedValue(dec)
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, errors.New("unexpected end of JSON input")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			obj := &orderedObject{values: make(map[string]interface{})}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyTok.(string)
+				value, err := decodeOrderedValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := obj.values[key]; !ok {
+					obj.keys = append(obj.keys, key)
+				}
+				obj.values[key] = value
+			}
+			_, err := dec.Token()
+			return obj, err
+		}
+		items := []interface{}{}
+		for dec.More() {
+			item, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token()
+		return items, err
+	default:
+		return t, nil
+	}
+}
+
+// plainValue converts an ordered tree to maps so values can be compared
+// regardless of key order
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *orderedObject:
+		m := make(map[string]interface{})
+		for key, child := range v.values {
+			m[key] = plainValue(child)
+		}
+		return m
+	case []interface{}:
+		items := []interface{}{}
+		for _, item := range v {
+			items = append(items, plainValue(item))
+		}
+		return items
+	case json.Number:
+		return v.String()
+	}
+	return value
+}
+
+func isZeroValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case *orderedObject:
+		return len(v.keys) == 0
+	case []interface{}:
+		return len(v) == 0
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	}
+	return false
+}
+
+// renderJSON writes value as JSON indenting nested lines with indent plus
+// unit per level, or on a single line when inline is set
+func renderJSON(buf *bytes.Buffer, value interface{}, indent, unit string, inline bool) {
+	switch v := value.(type) {
+	case *orderedObject:
+		if len(v.keys) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteString("{")
+		for i, key := range v.keys {
+			if inline {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+			} else {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString("\n" + indent + unit)
+			}
+			renderJSON(buf, key, "", "", true)
+			buf.WriteString(": ")
+			renderJSON(buf, v.values[key], indent+unit, unit, inline)
+		}
+		if !inline {
+			buf.WriteString("\n" + indent)
+		}
+		buf.WriteString("}")
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteString("[")
+		for i, item := range v {
+			if inline {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+			} else {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString("\n" + indent + unit)
+			}
+			renderJSON(buf, item, indent+unit, unit, inline)
+		}
+		if !inline {
+			buf.WriteString("\n" + indent)
+		}
+		buf.WriteString("]")
+	case json.Number:
+		buf.WriteString(v.String())
+	default:
+		var encoded bytes.Buffer
+		enc := json.NewEncoder(&encoded)
+		enc.SetEscapeHTML(false)
+		enc.Encode(v)
+		buf.Write(bytes.TrimRight(encoded.Bytes(), "\n"))
+	}
+}
+
+// stringEnd returns the offset just past the string starting at start
+func stringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// commentEnd returns the offset just past the comment starting at start, or
+// start if there is no comment there
+func commentEnd(data []byte, start int) int {
+	if start+1 >= len(data) || data[start] != '/' {
+		return start
+	}
+	switch data[start+1] {
+	case '/':
+		if end := bytes.IndexByte(data[start:], '\n'); end >= 0 {
+			return start + end
+		}
+		return len(data)
+	case '*':
+		if end := bytes.Index(data[start+2:], []byte("*/")); end >= 0 {
+			return start + 2 + end + 2
+		}
+		return len(data)
+	}
+	return start
+}
+
+// skipJSONCSpace returns the offset of the next token at or after pos
+// skipping whitespace and comments
+func skipJSONCSpace(data []byte, pos int) int {
+	for pos < len(data) {
+		switch data[pos] {
+		case ' ', '\t', '\r', '\n':
+			pos++
+		case '/':
+			end := commentEnd(data, pos)
+			if end == pos {
+				return pos
+			}
+			pos = end
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// lineStart returns the offset of the start of the line containing pos
+func lineStart(data []byte, pos int) int {
+	return bytes.LastIndexByte(data[:pos], '\n') + 1
+}
+
+// lineIndent returns the leading whitespace of the line containing pos
+func lineIndent(data []byte, pos int) string {
+	start := lineStart(data, pos)
+	end := start
+	for end < len(data) && (data[end] == ' ' || data[end] == '\t') {
+		end++
+	}
+	return string(data[start:end])
+}
+
+// detectIndent returns the indentation of the first indented line
+func detectIndent(data []byte) string {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(trimmed) > 0 && len(trimmed) < len(line) {
+			return string(line[:len(line)-len(trimmed)])
+		}
+	}
+	return defaultJSONIndent
+}
+
+// jsoncNode a parsed JSONC value and its span in the document
+type jsoncNode struct {
+	start   int
+	end     int
+	object  bool
+	members []*jsoncMember
+}
+
+// jsoncMember an object member, comma is -1 if no comma follows the value
+type jsoncMember struct {
+	key      string
+	keyStart int
+	value    *jsoncNode
+	comma    int
+}
+
+type jsoncParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *jsoncParser) parse() (*jsoncNode, error) {
+	root, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos = skipJSONCSpace(p.data, p.pos); p.pos < len(p.data) {
+		return nil, p.errorf("unexpected content after top-level value")
+	}
+	return root, nil
+}
+
+func (p *jsoncParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *jsoncParser) expect(c byte) error {
+	if p.pos = skipJSONCSpace(p.data, p.pos); p.pos >= len(p.data) || p.data[p.pos] != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *jsoncParser) value() (*jsoncNode, error) {
+	p.pos = skipJSONCSpace(p.data, p.pos)
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	n := &jsoncNode{start: p.pos}
+
+	switch p.data[p.pos] {
+	case '{':
+		n.object = true
+		p.pos++
+		for {
+			p.pos = skipJSONCSpace(p.data, p.pos)
+			if p.pos < len(p.data) && p.data[p.pos] == '}' {
+				p.pos++
+				break
+			}
+			if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+				return nil, p.errorf("expected object key")
+			}
+
+			m := &jsoncMember{keyStart: p.pos, comma: -1}
+			p.pos = stringEnd(p.data, p.pos)
+			if err := json.Unmarshal(p.data[m.keyStart:p.pos], &m.key); err != nil {
+				return nil, p.errorf("invalid object key")
+			}
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			m.value = value
+			n.members = append(n.members, m)
+
+			p.pos = skipJSONCSpace(p.data, p.pos)
+			if p.pos < len(p.data) && p.data[p.pos] == ',' {
+				m.comma = p.pos
+				p.pos++
+			} else if p.pos >= len(p.data) || p.data[p.pos] != '}' {
+				return nil, p.errorf("expected ',' or '}'")
+			}
+		}
+	case '[':
+		p.pos++
+		for {
+			p.pos = skipJSONCSpace(p.data, p.pos)
+			if p.pos < len(p.data) && p.data[p.pos] == ']' {
+				p.pos++
+				break
+			}
+			if _, err := p.value(); err != nil {
+				return nil, err
+			}
+			p.pos = skipJSONCSpace(p.data, p.pos)
+			if p.pos < len(p.data) && p.data[p.pos] == ',' {
+				p.pos++
+			} else if p.pos >= len(p.data) || p.data[p.pos] != ']' {
+				return nil, p.errorf("expected ',' or ']'")
+			}
+		}
+	case '"':
+		p.pos = stringEnd(p.data, p.pos)
+	default:
+		for p.pos < len(p.data) && bytes.IndexByte([]byte(",}] \t\r\n/"), p.data[p.pos]) < 0 {
+			p.pos++
+		}
+		if p.pos == n.start {
+			return nil, p.errorf("unexpected character %q", p.data[p.pos])
+		}
+	}
+
+	n.end = p.pos
+	return n, nil
+}
+
+// jsoncEdit replaces data[start:end] with text, seq orders insertions at
+// the same offset
+type jsoncEdit struct {
+	start int
+	end   int
+	text  string
+	seq   int
+}
+
+type jsoncPatcher struct {
+	data     []byte
+	unit     string
+	omitZero func(path []string) bool
+	edits    []jsoncEdit
+}
+
+func (p *jsoncPatcher) edit(start, end int, text string) {
+	p.edits = append(p.edits, jsoncEdit{start: start, end: end, text: text, seq: len(p.edits)})
+}
+
+// apply returns the document with all edits applied from last to first so
+// earlier offsets stay valid
+func (p *jsoncPatcher) apply() []byte {
+	sort.SliceStable(p.edits, func(i, j int) bool {
+		a, b := p.edits[i], p.edits[j]
+		if a.start != b.start {
+			return a.start > b.start
+		}
+		if a.end != b.end {
+			return a.end > b.end
+		}
+		return a.seq > b.seq
+	})
+
+	out := append([]byte{}, p.data...)
+	for _, e := range p.edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return out
+}
+
+func (p *jsoncPatcher) render(value interface{}, indent string, inline bool) string {
+	var buf bytes.Buffer
+	renderJSON(&buf, value, indent, p.unit, inline)
+	return buf.String()
+}
+
+func (p *jsoncPatcher) patch(node *jsoncNode, target interface{}, path []string) error {
+	current, err := decodeOrdered(StripJSONC(p.data[node.start:node.end]))
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(plainValue(current), plainValue(target)) {
+		return nil
+	}
+
+	if obj, ok := target.(*orderedObject); ok && node.object && p.lineStructured(node) {
+		return p.patchObject(node, obj, path)
+	}
+
+	// non-empty containers written on one line stay on one line
+	text := p.data[node.start:node.end]
+	inline := !bytes.Contains(text, []byte("\n")) && len(text) > 2 && (text[0] == '{' || text[0] == '[')
+	p.edit(node.start, node.end, p.render(target, lineIndent(p.data, node.start), inline))
+	return nil
+}
+
+// lineStructured returns whether every member of a multi-line object is on
+// its own lines so members can be added and removed by line
+func (p *jsoncPatcher) lineStructured(node *jsoncNode) bool {
+	if len(node.members) == 0 || !bytes.Contains(p.data[node.start:node.end], []byte("\n")) {
+		return false
+	}
+	for _, m := range node.members {
+		if len(bytes.TrimSpace(p.data[lineStart(p.data, m.keyStart):m.keyStart])) > 0 {
+			return false
+		}
+		if p.memberLineEnd(m) >= node.end-1 {
+			return false
+		}
+	}
+	return true
+}
+
+// memberLineEnd returns the offset of the newline ending the last line of m
+// or the closing brace offset if something other than a comment follows m
+// on its line
+func (p *jsoncPatcher) memberLineEnd(m *jsoncMember) int {
+	pos := m.value.end
+	if m.comma >= 0 {
+		pos = m.comma + 1
+	}
+	for pos < len(p.data) {
+		switch p.data[pos] {
+		case ' ', '\t', '\r':
+			pos++
+		case '\n':
+			return pos
+		case '/':
+			end := commentEnd(p.data, pos)
+			if end == pos || bytes.Contains(p.data[pos:end], []byte("\n")) {
+				return len(p.data)
+			}
+			pos = end
+		default:
+			return len(p.data)
+		}
+	}
+	return len(p.data)
+}
+
+// memberBlockStart returns the offset of the start of the first line of m
+// including any comment only lines directly above it
+func (p *jsoncPatcher) memberBlockStart(m *jsoncMember) int {
+	start := lineStart(p.data, m.keyStart)
+	for start > 0 {
+		prev := lineStart(p.data, start-1)
+		line := bytes.TrimSpace(p.data[prev : start-1])
+		if !bytes.HasPrefix(line, []byte("//")) {
+			break
+		}
+		start = prev
+	}
+	return start
+}
+
+func (p *jsoncPatcher) renderMember(key string, value interface{}, indent string) string {
+	return p.render(key, "", true) + ": " + p.render(value, indent, false)
+}
+
+func (p *jsoncPatcher) patchObject(node *jsoncNode, target *orderedObject, path []string) error {
+	trailingComma := node.members[len(node.members)-1].comma >= 0
+	indent := lineIndent(p.data, node.members[0].keyStart)
+
+	existing := make(map[string]*jsoncMember)
+	kept := []*jsoncMember{}
+	for _, m := range node.members {
+		existing[m.key] = m
+		if _, ok := target.values[m.key]; ok {
+			kept = append(kept, m)
+		}
+	}
+
+	if len(kept) == 0 {
+		// nothing to anchor new members to so the object is re-rendered
+		p.edit(node.start, node.end, p.render(target, lineIndent(p.data, node.start), false))
+		return nil
+	}
+
+	for _, m := range node.members {
+		value, ok := target.values[m.key]
+		if !ok {
+			p.edit(p.memberBlockStart(m), p.memberLineEnd(m)+1, "")
+			continue
+		}
+		if err := p.patch(m.value, value, append(append([]string{}, path...), m.key)); err != nil {
+			return err
+		}
+	}
+
+	// new members are added after the member preceding them in target
+	inserts := make(map[*jsoncMember][]string)
+	var leading []string
+	var previous *jsoncMember
+	for _, key := range target.keys {
+		if m, ok := existing[key]; ok {
+			if _, kept := target.values[key]; kept {
+				previous = m
+			}
+			continue
+		}
+		value := target.values[key]
+		childPath := append(append([]string{}, path...), key)
+		if isZeroValue(value) && p.omitZero != nil && p.omitZero(childPath) {
+			continue
+		}
+		member := p.renderMember(key, value, indent)
+		if previous == nil {
+			leading = append(leading, member)
+		} else {
+			inserts[previous] = append(inserts[previous], member)
+		}
+	}
+
+	for _, member := range leading {
+		p.edit(p.memberBlockStart(kept[0]), p.memberBlockStart(kept[0]), indent+member+",\n")
+	}
+
+	last := kept[len(kept)-1]
+	for i, m := range kept {
+		members := inserts[m]
+		isLast := m == last && len(members) == 0
+
+		switch {
+		case isLast && !trailingComma && m.comma >= 0:
+			p.edit(m.comma, m.comma+1, "")
+		case !isLast && m.comma < 0, isLast && trailingComma && m.comma < 0:
+			p.edit(m.value.end, m.value.end, ",")
+		}
+
+		if len(members) == 0 {
+			continue
+		}
+
+		pos := p.memberLineEnd(m) + 1
+		if i < len(kept)-1 {
+			// insert before the block of the next kept member
+			pos = p.memberBlockStart(kept[i+1])
+		}
+		for j, member := range members {
+			comma := ","
+			if m == last && j == len(members)-1 && !trailingComma {
+				comma = ""
+			}
+			if m == last {
+				p.edit(pos-1, pos-1, "\n"+indent+member+comma)
+			} else {
+				p.edit(pos, pos, indent+member+comma+"\n")
+			}
+		}
+	}
+
+	return nil
+}
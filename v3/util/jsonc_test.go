@@ -0,0 +1,186 @@
+package util_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const jsoncConfig = `{
+  // pinned until the 2.x api settles
+  "libraries": {
+    "Adafruit Pixie": "1.0.0",
+    "Servo": "1.1.8", // see #12
+  },
+  "platforms": {
+    "arduino:avr": "1.8.5"
+  },
+  "builds": {
+    "blink": {
+      "directory": "blink",
+      "sketch": "blink/blink.ino",
+      "fqbn": "arduino:avr:uno",
+      "props": {}
+    }
+  }
+}
+`
+
+func TestUtilJSONC(t *testing.T) {
+	parse := func(env *testutil.UnitTestEnv) *types.ArdiConfig {
+		config, err := util.ParseArdiConfig("ardi.json", []byte(jsoncConfig))
+		assert.NoError(env.T, err)
+		return config
+	}
+
+	testutil.RunUnitTest("strips comments and trailing commas keeping offsets", t, func(env *testutil.UnitTestEnv) {
+		data := "{\n\t\"url\": \"http://a//b\", // comment\n\t/* block\n\t*/ \"list\": [1, 2,],\n}"
+		expected := "{\n\t\"url\": \"http://a//b\",           \n\t        \n    \"list\": [1, 2 ] \n}"
+		stripped := util.StripJSONC([]byte(data))
+		assert.Equal(env.T, expected, string(stripped))
+
+		var value map[string]interface{}
+		assert.NoError(env.T, json.Unmarshal(stripped, &value))
+		assert.Equal(env.T, "http://a//b", value["url"])
+	})
+
+	testutil.RunUnitTest("parses ardi.json with comments", t, func(env *testutil.UnitTestEnv) {
+		config := parse(env)
+		assert.Equal(env.T, "1.1.8", config.Libraries["Servo"])
+		assert.Equal(env.T, "arduino:avr:uno", config.Builds["blink"].FQBN)
+	})
+
+	testutil.RunUnitTest("leaves unchanged config untouched", t, func(env *testutil.UnitTestEnv) {
+		data, err := util.FormatArdiConfig([]byte(jsoncConfig), parse(env))
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, jsoncConfig, string(data))
+	})
+
+	testutil.RunUnitTest("adds entries in sorted position", t, func(env *testutil.UnitTestEnv) {
+		config := parse(env)
+		config.Libraries["Bounce2"] = "2.71.0"
+		config.Libraries["WiFi"] = "1.2.7"
+		config.Platforms["arduino:samd"] = "1.8.13"
+
+		data, err := util.FormatArdiConfig([]byte(jsoncConfig), config)
+		assert.NoError(env.T, err)
+		expected := `{
+  // pinned until the 2.x api settles
+  "libraries": {
+    "Adafruit Pixie": "1.0.0",
+    "Bounce2": "2.71.0",
+    "Servo": "1.1.8", // see #12
+    "WiFi": "1.2.7",
+  },
+  "platforms": {
+    "arduino:avr": "1.8.5",
+    "arduino:samd": "1.8.13"
+  },
+  "builds": {
+    "blink": {
+      "directory": "blink",
+      "sketch": "blink/blink.ino",
+      "fqbn": "arduino:avr:uno",
+      "props": {}
+    }
+  }
+}
+`
+		assert.Equal(env.T, expected, string(data))
+	})
+
+	testutil.RunUnitTest("updates and removes entries in place", t, func(env *testutil.UnitTestEnv) {
+		config := parse(env)
+		config.Libraries["Adafruit Pixie"] = "1.0.1"
+		delete(config.Libraries, "Servo")
+		delete(config.Platforms, "arduino:avr")
+		build := config.Builds["blink"]
+		build.Baud = 115200
+		build.Props = map[string]string{"build.extra_flags": "-DDEBUG"}
+		config.Builds["blink"] = build
+
+		data, err := util.FormatArdiConfig([]byte(jsoncConfig), config)
+		assert.NoError(env.T, err)
+		expected := `{
+  // pinned until the 2.x api settles
+  "libraries": {
+    "Adafruit Pixie": "1.0.1",
+  },
+  "platforms": {},
+  "builds": {
+    "blink": {
+      "directory": "blink",
+      "sketch": "blink/blink.ino",
+      "baud": 115200,
+      "fqbn": "arduino:avr:uno",
+      "props": {
+        "build.extra_flags": "-DDEBUG"
+      }
+    }
+  }
+}
+`
+		assert.Equal(env.T, expected, string(data))
+	})
+
+	testutil.RunUnitTest("removes comments above removed entries", t, func(env *testutil.UnitTestEnv) {
+		original := "{\n\t\"platforms\": {\n\t\t\"arduino:avr\": \"1.8.5\",\n\t\t// needed for the zero\n\t\t\"arduino:samd\": \"1.8.13\"\n\t}\n}\n"
+		config, err := util.ParseArdiConfig("ardi.json", []byte(original))
+		assert.NoError(env.T, err)
+		delete(config.Platforms, "arduino:samd")
+
+		data, err := util.FormatArdiConfig([]byte(original), config)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "{\n\t\"platforms\": {\n\t\t\"arduino:avr\": \"1.8.5\"\n\t}\n}\n", string(data))
+	})
+
+	testutil.RunUnitTest("adds new builds using existing indentation", t, func(env *testutil.UnitTestEnv) {
+		config := parse(env)
+		config.Builds["alpha"] = types.ArdiBuild{
+			Directory: "alpha",
+			Sketch:    "alpha/alpha.ino",
+			FQBN:      "arduino:avr:nano",
+		}
+
+		data, err := util.FormatArdiConfig([]byte(jsoncConfig), config)
+		assert.NoError(env.T, err)
+		assert.Contains(env.T, string(data), `  "builds": {
+    "alpha": {
+      "directory": "alpha",
+      "sketch": "alpha/alpha.ino",
+      "baud": 0,
+      "fqbn": "arduino:avr:nano",
+      "props": null
+    },
+    "blink": {`)
+
+		reparsed, err := util.ParseArdiConfig("ardi.json", data)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, config.Builds, reparsed.Builds)
+	})
+
+	testutil.RunUnitTest("keeps single line values on one line", t, func(env *testutil.UnitTestEnv) {
+		original := "{\n\t\"boardUrls\": [\"a\"],\n\t\"platforms\": {}\n}"
+		config, err := util.ParseArdiConfig("ardi.json", []byte(original))
+		assert.NoError(env.T, err)
+		config.BoardURLS = append(config.BoardURLS, "b&c")
+
+		data, err := util.FormatArdiConfig([]byte(original), config)
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "{\n\t\"boardUrls\": [\"a\", \"b&c\"],\n\t\"platforms\": {}\n}", string(data))
+	})
+
+	testutil.RunUnitTest("formats new files with tabs", t, func(env *testutil.UnitTestEnv) {
+		config := util.GenArdiConfig()
+		config.Platforms["arduino:avr"] = "1.8.5"
+
+		data, err := util.FormatArdiConfig(nil, config)
+		assert.NoError(env.T, err)
+		expected := "{\n\t\"platforms\": {\n\t\t\"arduino:avr\": \"1.8.5\"\n\t},\n\t\"boardUrls\": [],\n\t\"libraries\": {},\n\t\"builds\": {}\n}\n"
+		assert.Equal(env.T, expected, string(data))
+	})
+}
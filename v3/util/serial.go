@@ -0,0 +1,93 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// serialGroup group granting access to serial ports on linux
+const serialGroup = "dialout"
+
+// addGroupFix adds the current user to serialGroup
+const addGroupFix = "run 'sudo usermod -aG " + serialGroup + " $(whoami)' and log in again"
+
+// SerialPortIssue describes why serial ports may not be usable by the
+// current user
+type SerialPortIssue struct {
+	Message string
+	// Fix how the user can resolve the issue
+	Fix string
+	// Blocking set if a connected serial port can't be opened
+	Blocking bool
+}
+
+// CheckSerialPorts returns an issue if the current user can't read and write
+// connected USB serial ports, or isn't in the dialout group when no ports are
+// connected. Only linux restricts serial ports to a group so nil is always
+// returned on other systems.
+func CheckSerialPorts() *SerialPortIssue {
+	if runtime.GOOS != "linux" || os.Geteuid() == 0 {
+		return nil
+	}
+
+	ports := []string{}
+	for _, pattern := range []string{"/dev/ttyUSB*", "/dev/ttyACM*"} {
+		matches, _ := filepath.Glob(pattern)
+		ports = append(ports, matches...)
+	}
+
+	denied := []string{}
+	for _, port := range ports {
+		// 6 checks read and write access
+		if err := syscall.Access(port, 6); err != nil {
+			denied = append(denied, port)
+		}
+	}
+	if len(denied) > 0 {
+		return &SerialPortIssue{
+			Message:  fmt.Sprintf("no read/write access to %s", strings.Join(denied, ", ")),
+			Fix:      addGroupFix,
+			Blocking: true,
+		}
+	}
+	if len(ports) > 0 {
+		return nil
+	}
+
+	group, err := user.LookupGroup(serialGroup)
+	if err != nil {
+		return nil
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		return nil
+	}
+	groupIDs, err := current.GroupIds()
+	if err != nil || !ArrayContains(groupIDs, group.Gid) {
+		return &SerialPortIssue{
+			Message: fmt.Sprintf("%s is not in the %s group", current.Username, serialGroup),
+			Fix:     addGroupFix,
+		}
+	}
+
+	// group changes only apply to new login sessions
+	processGroups, err := os.Getgroups()
+	if err != nil {
+		return nil
+	}
+	for _, gid := range processGroups {
+		if fmt.Sprint(gid) == group.Gid {
+			return nil
+		}
+	}
+	return &SerialPortIssue{
+		Message: fmt.Sprintf("%s was added to the %s group but hasn't logged in again", current.Username, serialGroup),
+		Fix:     "log out and back in",
+	}
+}
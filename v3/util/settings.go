@@ -0,0 +1,245 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// HasSetting returns whether the first segment of the dotted path names a
+// field of settings, whose fields are named by the given struct tag
+func HasSetting(settings interface{}, tag, path string) bool {
+	v := reflect.Indirect(reflect.ValueOf(settings))
+	_, ok := settingField(v, tag, strings.Split(path, ".")[0])
+	return ok
+}
+
+// GetSetting returns the value at the dotted path in settings, whose fields
+// are named by the given struct tag ("json" or "yaml"). Map keys containing
+// dots, such as build props, may be given unquoted as the rest of the path.
+// Values are returned as decoded JSON or YAML with string keys.
+func GetSetting(settings interface{}, tag, path string) (interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(settings))
+	segments := strings.Split(path, ".")
+
+	for len(segments) > 0 {
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := settingField(v, tag, segments[0])
+			if !ok {
+				return nil, fmt.Errorf("unknown setting %q", path)
+			}
+			v = field
+			segments = segments[1:]
+		case reflect.Map:
+			key, rest := settingKey(v, segments)
+			value := v.MapIndex(reflect.ValueOf(key))
+			if !value.IsValid() {
+				return nil, fmt.Errorf("setting %q not found", path)
+			}
+			v = value
+			segments = rest
+		case reflect.Slice:
+			i, err := strconv.Atoi(segments[0])
+			if err != nil || i < 0 || i >= v.Len() {
+				return nil, fmt.Errorf("setting %q not found", path)
+			}
+			v = v.Index(i)
+			segments = segments[1:]
+		default:
+			return nil, fmt.Errorf("setting %q not found", path)
+		}
+	}
+
+	return normalizeSetting(v.Interface(), tag)
+}
+
+// SetSetting parses value and stores it at the dotted path in settings,
+// which must be a pointer. Strings are stored as is, numbers and booleans
+// are parsed, and lists, maps, and objects are decoded from JSON or YAML
+// matching tag. Missing map entries are created.
+func SetSetting(settings interface{}, tag, path, value string) error {
+	v := reflect.ValueOf(settings)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("settings must be a non-nil pointer")
+	}
+	return setSetting(v.Elem(), tag, path, strings.Split(path, "."), value)
+}
+
+// private
+func setSetting(v reflect.Value, tag, path string, segments []string, value string) error {
+	if len(segments) == 0 {
+		return parseSetting(v, tag, path, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		field, ok := settingField(v, tag, segments[0])
+		if !ok {
+			return fmt.Errorf("unknown setting %q", path)
+		}
+		return setSetting(field, tag, path, segments[1:], value)
+	case reflect.Map:
+		key, rest := settingKey(v, segments)
+		// map values aren't addressable so the entry is updated on a copy
+		entry := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(reflect.ValueOf(key)); existing.IsValid() {
+			entry.Set(existing)
+		}
+		if err := setSetting(entry, tag, path, rest, value); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(reflect.ValueOf(key), entry)
+		return nil
+	case reflect.Slice:
+		i, err := strconv.Atoi(segments[0])
+		if err != nil || i < 0 || i >= v.Len() {
+			return fmt.Errorf("setting %q not found", path)
+		}
+		return setSetting(v.Index(i), tag, path, segments[1:], value)
+	default:
+		return fmt.Errorf("setting %q not found", path)
+	}
+}
+
+func parseSetting(v reflect.Value, tag, path, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected boolean, got %q", path, value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: expected integer, got %q", path, value)
+		}
+		v.SetInt(n)
+	default:
+		parsed := reflect.New(v.Type())
+		var err error
+		if tag == "yaml" {
+			err = yaml.UnmarshalStrict([]byte(value), parsed.Interface())
+		} else {
+			dec := json.NewDecoder(strings.NewReader(value))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(parsed.Interface())
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", path, err)
+		}
+		v.Set(parsed.Elem())
+	}
+	return nil
+}
+
+// settingField returns the struct field named name by tag
+func settingField(v reflect.Value, tag, name string) (reflect.Value, bool) {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	for i := 0; i < v.NumField(); i++ {
+		fieldName := strings.Split(v.Type().Field(i).Tag.Get(tag), ",")[0]
+		if fieldName != "" && fieldName != "-" && fieldName == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// settingKey returns the map key addressed by segments and the segments
+// remaining after it. Keys of maps holding plain values take the rest of the
+// path, otherwise the longest existing key is used.
+func settingKey(v reflect.Value, segments []string) (string, []string) {
+	switch v.Type().Elem().Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice:
+		for i := len(segments); i > 1; i-- {
+			key := strings.Join(segments[:i], ".")
+			if v.MapIndex(reflect.ValueOf(key)).IsValid() {
+				return key, segments[i:]
+			}
+		}
+		return segments[0], segments[1:]
+	default:
+		return strings.Join(segments, "."), nil
+	}
+}
+
+// normalizeSetting converts value to plain decoded JSON so it renders the
+// same way in every output format
+func normalizeSetting(value interface{}, tag string) (interface{}, error) {
+	if tag == "yaml" {
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		var decoded interface{}
+		if err := yaml.Unmarshal(data, &decoded); err != nil {
+			return nil, err
+		}
+		return stringKeys(decoded), nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var decoded interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return plainNumbers(decoded), nil
+}
+
+// plainNumbers converts json.Number values to int64 or float64 so they
+// aren't rendered as strings
+func plainNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = plainNumbers(child)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = plainNumbers(item)
+		}
+	}
+	return value
+}
+
+// stringKeys converts the map[interface{}]interface{} values decoded by
+// yaml into maps with string keys
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{})
+		for key, child := range v {
+			m[fmt.Sprint(key)] = stringKeys(child)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+	}
+	return value
+}
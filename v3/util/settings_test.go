@@ -0,0 +1,69 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/robgonnella/ardi/v3/testutil"
+	"github.com/robgonnella/ardi/v3/types"
+	"github.com/robgonnella/ardi/v3/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUtilSettings(t *testing.T) {
+	newConfig := func() *types.ArdiConfig {
+		config := util.GenArdiConfig()
+		config.Builds["v1.0"] = types.ArdiBuild{FQBN: "arduino:avr:uno", Baud: 9600}
+		return config
+	}
+
+	testutil.RunUnitTest("gets values by dotted path", t, func(env *testutil.UnitTestEnv) {
+		config := newConfig()
+
+		value, err := util.GetSetting(config, "json", "builds.v1.0.baud")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, int64(9600), value)
+
+		value, err = util.GetSetting(config, "json", "boardUrls")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, []interface{}{}, value)
+
+		value, err = util.GetSetting(util.GenArduinoCliSettings("data"), "yaml", "logging.level")
+		assert.NoError(env.T, err)
+		assert.Equal(env.T, "fatal", value)
+
+		_, err = util.GetSetting(config, "json", "builds.v2.baud")
+		assert.EqualError(env.T, err, `setting "builds.v2.baud" not found`)
+	})
+
+	testutil.RunUnitTest("sets values by dotted path", t, func(env *testutil.UnitTestEnv) {
+		config := newConfig()
+
+		err := util.SetSetting(config, "json", "builds.v1.0.props.build.extra_flags", "-DNDEBUG")
+		assert.NoError(env.T, err)
+		err = util.SetSetting(config, "json", "builds.v1.0.preBuild", `["make gen"]`)
+		assert.NoError(env.T, err)
+		err = util.SetSetting(config, "json", "libraries.Adafruit Pixie", "1.0.0")
+		assert.NoError(env.T, err)
+
+		assert.Equal(env.T, map[string]string{"build.extra_flags": "-DNDEBUG"}, config.Builds["v1.0"].Props)
+		assert.Equal(env.T, []string{"make gen"}, config.Builds["v1.0"].PreBuild)
+		assert.Equal(env.T, "1.0.0", config.Libraries["Adafruit Pixie"])
+
+		settings := util.GenArduinoCliSettings("data")
+		err = util.SetSetting(settings, "yaml", "metrics.enabled", "true")
+		assert.NoError(env.T, err)
+		assert.True(env.T, settings.Metrics.Enabled)
+	})
+
+	testutil.RunUnitTest("rejects values of the wrong type", t, func(env *testutil.UnitTestEnv) {
+		config := newConfig()
+
+		err := util.SetSetting(config, "json", "builds.v1.0.baud", "fast")
+		assert.EqualError(env.T, err, `invalid value for builds.v1.0.baud: expected integer, got "fast"`)
+		assert.Equal(env.T, 9600, config.Builds["v1.0"].Baud)
+
+		err = util.SetSetting(config, "json", "platform.arduino:avr", "1.8.5")
+		assert.EqualError(env.T, err, `unknown setting "platform.arduino:avr"`)
+		assert.False(env.T, util.HasSetting(config, "json", "platform"))
+	})
+}
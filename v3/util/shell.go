@@ -0,0 +1,150 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ShellOpts represents options for running a shell command
+type ShellOpts struct {
+	Command string
+	Dir     string
+	Env     []string
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// RunShell runs a command with sh in the specified directory. Env is added
+// to the current process environment.
+func RunShell(ctx context.Context, opts ShellOpts) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", opts.Command)
+	cmd.Dir = opts.Dir
+	cmd.Env = append(os.Environ(), opts.Env...)
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+	return cmd.Run()
+}
+
+// SplitScript splits a script into the steps separated by && outside of
+// quotes
+func SplitScript(script string) ([]string, error) {
+	steps := []string{}
+	start := 0
+	var quote rune
+	escaped := false
+
+	for i, r := range script {
+		switch {
+		case i < start:
+			// second character of a && separator
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '&' && strings.HasPrefix(script[i:], "&&"):
+			steps = append(steps, strings.TrimSpace(script[start:i]))
+			start = i + 2
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in script")
+	}
+
+	steps = append(steps, strings.TrimSpace(script[start:]))
+
+	for _, step := range steps {
+		if step == "" {
+			return nil, errors.New("empty step in script")
+		}
+	}
+
+	return steps, nil
+}
+
+// ShellOperator returns the first |, || or ; operator outside of quotes in a
+// command line, or an empty string if there are none
+func ShellOperator(line string) string {
+	var quote rune
+	escaped := false
+
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '|' && strings.HasPrefix(line[i:], "||"):
+			return "||"
+		case r == '|' || r == ';':
+			return string(r)
+		}
+	}
+
+	return ""
+}
+
+// SplitArgs splits a command line into arguments honoring single quotes,
+// double quotes, and backslash escapes
+func SplitArgs(line string) ([]string, error) {
+	args := []string{}
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape in command")
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,76 @@
+package util_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/robgonnella/ardi/v3/util"
+)
+
+func TestUtilSplitScript(t *testing.T) {
+	t.Run("splits steps on &&", func(st *testing.T) {
+		steps, err := util.SplitScript("ardi build release && echo 'a && b' && ./size.sh")
+		assert.NoError(st, err)
+		assert.Equal(st, []string{"ardi build release", "echo 'a && b'", "./size.sh"}, steps)
+	})
+
+	t.Run("errors on empty step", func(st *testing.T) {
+		_, err := util.SplitScript("ardi build release && ")
+		assert.Error(st, err)
+	})
+
+	t.Run("errors on unterminated quote", func(st *testing.T) {
+		_, err := util.SplitScript("echo \"oops")
+		assert.Error(st, err)
+	})
+}
+
+func TestUtilShellOperator(t *testing.T) {
+	t.Run("finds operators outside of quotes", func(st *testing.T) {
+		assert.Equal(st, "|", util.ShellOperator("ardi build release | tee build.log"))
+		assert.Equal(st, "||", util.ShellOperator("ardi upload release || true"))
+		assert.Equal(st, ";", util.ShellOperator("ardi clean; ardi build"))
+	})
+
+	t.Run("ignores quoted and escaped operators", func(st *testing.T) {
+		assert.Equal(st, "", util.ShellOperator(`ardi add build -p 'a|b' -p "c;d" e\;f`))
+	})
+}
+
+func TestUtilSplitArgs(t *testing.T) {
+	t.Run("splits arguments honoring quotes", func(st *testing.T) {
+		args, err := util.SplitArgs(`ardi add build -n "my build" --build-prop 'build.extra_flags=-DA -DB' a\ b`)
+		assert.NoError(st, err)
+		assert.Equal(st, []string{"ardi", "add", "build", "-n", "my build", "--build-prop", "build.extra_flags=-DA -DB", "a b"}, args)
+	})
+
+	t.Run("keeps empty quoted arguments", func(st *testing.T) {
+		args, err := util.SplitArgs(`echo ""`)
+		assert.NoError(st, err)
+		assert.Equal(st, []string{"echo", ""}, args)
+	})
+}
+
+func TestUtilRunShell(t *testing.T) {
+	t.Run("runs command with environment in directory", func(st *testing.T) {
+		dir := st.TempDir()
+		var out bytes.Buffer
+		err := util.RunShell(context.Background(), util.ShellOpts{
+			Command: "echo $ARDI_TEST_VAR && pwd",
+			Dir:     dir,
+			Env:     []string{"ARDI_TEST_VAR=hello"},
+			Stdout:  &out,
+			Stderr:  &out,
+		})
+		assert.NoError(st, err)
+		assert.Equal(st, "hello\n"+dir+"\n", out.String())
+	})
+
+	t.Run("returns error on failure", func(st *testing.T) {
+		err := util.RunShell(context.Background(), util.ShellOpts{Command: "exit 3"})
+		assert.Error(st, err)
+	})
+}
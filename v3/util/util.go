@@ -2,6 +2,7 @@ package util
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,12 +11,16 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/arduino/arduino-cli/inventory"
 	"github.com/google/uuid"
 	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/schema"
 	"github.com/robgonnella/ardi/v3/types"
 	"gopkg.in/yaml.v2"
 )
@@ -33,6 +38,16 @@ func ArrayContains(arr []string, str string) bool {
 	return false
 }
 
+// SortedKeys returns the keys of a string map in sorted order
+func SortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // CreateDataDir creates a data dir with proper permissions for ardi / arduino-cli
 func CreateDataDir(dir string) error {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
@@ -105,74 +120,279 @@ func GenArdiConfig() *types.ArdiConfig {
 	}
 }
 
-// ReadArdiConfig reads ardi.json and returns config
+// ReadArdiConfig reads ardi.json and returns config. A *schema.ConfigError
+// is returned if the file is not valid against the ardi.json schema.
 func ReadArdiConfig(confPath string) (*types.ArdiConfig, error) {
+	byteData, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseArdiConfig(confPath, byteData)
+}
+
+// ParseArdiConfig parses ardi.json content, which may contain comments and
+// trailing commas, returning a *schema.ConfigError naming file if it is not
+// valid against the ardi.json schema.
+func ParseArdiConfig(file string, data []byte) (*types.ArdiConfig, error) {
+	// stripping keeps offsets so problems are reported at their position
+	data = StripJSONC(data)
+
+	if errs := schema.ValidateArdiConfig(data); len(errs) > 0 {
+		return nil, &schema.ConfigError{File: file, Errors: errs}
+	}
+
 	var config types.ArdiConfig
-	configFile, err := os.Open(confPath)
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// FormatArdiConfig returns the content of ardi.json updated to hold config.
+// The key order, indentation, and comments of original are kept and new
+// entries are added in sorted position so updates produce minimal diffs.
+// Optional fields original leaves out aren't added while zero valued.
+func FormatArdiConfig(original []byte, config *types.ArdiConfig) ([]byte, error) {
+	return PatchJSONC(original, config, func(path []string) bool {
+		// top level and build fields, everything else is a map entry
+		return len(path) == 1 || (len(path) == 3 && path[0] == "builds")
+	})
+}
+
+// UpdateArdiConfig updates ardi.json while holding its lock. update is called
+// with the config currently on disk, or nil if there is no file, and returns
+// the config to write. The file is only rewritten if its content changes.
+func UpdateArdiConfig(confPath string, update func(disk *types.ArdiConfig) (*types.ArdiConfig, error)) (*types.ArdiConfig, error) {
+	unlock, err := LockFile(confPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var disk *types.ArdiConfig
+	original, err := ioutil.ReadFile(confPath)
+	if err == nil {
+		if disk, err = ParseArdiConfig(confPath, original); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	config, err := update(disk)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := FormatArdiConfig(original, config)
 	if err != nil {
 		return nil, err
 	}
-	defer configFile.Close()
 
-	byteData, err := ioutil.ReadAll(configFile)
+	if disk != nil && bytes.Equal(original, data) {
+		return config, nil
+	}
+
+	if err := WriteFileAtomic(confPath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// ReadWorkspaceConfig reads ardi-workspace.json and returns config
+func ReadWorkspaceConfig(confPath string) (*types.ArdiWorkspace, error) {
+	byteData, err := ioutil.ReadFile(confPath)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := json.Unmarshal(byteData, &config); err != nil {
+	return ParseWorkspaceConfig(confPath, byteData)
+}
+
+// ParseWorkspaceConfig parses ardi-workspace.json content, which like
+// ardi.json may contain comments and trailing commas, returning a
+// *schema.ConfigError naming file if it is not valid against the workspace
+// schema.
+func ParseWorkspaceConfig(file string, data []byte) (*types.ArdiWorkspace, error) {
+	data = StripJSONC(data)
+
+	if errs := schema.ValidateWorkspaceConfig(data); len(errs) > 0 {
+		return nil, &schema.ConfigError{File: file, Errors: errs}
+	}
+
+	var config types.ArdiWorkspace
+	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
 
 	return &config, nil
 }
 
-// GetAllSettings returns settings for both ardi and arduino-cli
-func GetAllSettings() (*types.ArdiConfig, *types.ArduinoCliSettings) {
+// ReadSketchYAML reads an arduino-cli sketch.yaml file of build profiles
+func ReadSketchYAML(confPath string) (*types.SketchYAML, error) {
+	var sketchYAML types.SketchYAML
+	byteData, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(byteData, &sketchYAML); err != nil {
+		return nil, err
+	}
+
+	return &sketchYAML, nil
+}
+
+// WriteSketchYAML writes an arduino-cli sketch.yaml file of build profiles
+func WriteSketchYAML(confPath string, sketchYAML *types.SketchYAML) error {
+	byteData, err := yaml.Marshal(sketchYAML)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(confPath, byteData, 0644)
+}
+
+// ReadSecrets reads a json file of secret names and values
+func ReadSecrets(confPath string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	byteData, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(byteData, &secrets); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// ReadDotEnv reads KEY=VALUE pairs from a .env file. Blank lines and lines
+// starting with # are ignored, an optional "export " prefix is allowed, and
+// values may be wrapped in single or double quotes.
+func ReadDotEnv(envPath string) (map[string]string, error) {
+	file, err := os.Open(envPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid line %d in %s", lineNum, envPath)
+		}
+
+		value := strings.TrimSpace(parts[1])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+
+	return values, scanner.Err()
+}
+
+// EnsureGitIgnored adds entries to the .gitignore in dir if not already
+// present, creating the file if needed
+func EnsureGitIgnored(dir string, entries ...string) error {
+	ignorePath := path.Join(dir, ".gitignore")
+
+	existing, err := ioutil.ReadFile(ignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(string(existing), "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	missing := []string{}
+	for _, entry := range entries {
+		if !ArrayContains(lines, entry) && !ArrayContains(lines, "/"+entry) {
+			missing = append(missing, entry)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	content := string(existing)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += strings.Join(missing, "\n") + "\n"
+
+	return ioutil.WriteFile(ignorePath, []byte(content), 0644)
+}
+
+// GetAllSettings returns settings for both ardi and arduino-cli. Defaults
+// are returned for missing files but an error is returned if either file
+// exists and is invalid so it is never replaced by defaults.
+func GetAllSettings(projectPaths paths.ProjectPaths) (*types.ArdiConfig, *types.ArduinoCliSettings, error) {
 	var ardiConfig *types.ArdiConfig
 	var cliSettings *types.ArduinoCliSettings
 
-	dataDir := paths.ArduinoCliProjectDataDir
-	ardiConf := paths.ArdiProjectConfig
-	cliConf := paths.ArduinoCliProjectConfig
+	dataDir := projectPaths.ArduinoCliDataDir
+	ardiConf := projectPaths.ArdiConfig
+	cliConf := projectPaths.ArduinoCliConfig
 
 	if _, err := os.Stat(ardiConf); os.IsNotExist(err) {
 		ardiConfig = GenArdiConfig()
 	} else if ardiConfig, err = ReadArdiConfig(ardiConf); err != nil {
-		ardiConfig = GenArdiConfig()
+		return nil, nil, err
 	}
 
 	if _, err := os.Stat(cliConf); os.IsNotExist(err) {
 		cliSettings = GenArduinoCliSettings(dataDir)
 	} else if cliSettings, err = ReadArduinoCliSettings(cliConf); err != nil {
-		cliSettings = GenArduinoCliSettings(dataDir)
+		return nil, nil, fmt.Errorf("invalid %s: %w", cliConf, err)
 	}
 
-	return ardiConfig, cliSettings
-}
-
-// GetCliSettingsPath returns path to arduino-cli.yaml based on scope
-func GetCliSettingsPath() string {
-	cliConf := paths.ArduinoCliProjectConfig
-	return cliConf
+	return ardiConfig, cliSettings, nil
 }
 
-// WriteAllSettings writes all settings files
-func WriteAllSettings(ardiConfig *types.ArdiConfig, arduinoSettings *types.ArduinoCliSettings) error {
-	dataDir := paths.ArduinoCliProjectDataDir
-	ardiConf := paths.ArdiProjectConfig
-	cliConf := paths.ArduinoCliProjectConfig
+// WriteAllSettings writes the settings files that don't exist yet. Existing
+// files are left untouched as another process may have updated them since
+// the given settings were read.
+func WriteAllSettings(projectPaths paths.ProjectPaths, ardiConfig *types.ArdiConfig, arduinoSettings *types.ArduinoCliSettings) error {
+	dataDir := projectPaths.ArduinoCliDataDir
+	ardiConf := projectPaths.ArdiConfig
+	cliConf := projectPaths.ArduinoCliConfig
 
 	if err := CreateDataDir(dataDir); err != nil {
 		return err
 	}
 
-	byteData, _ := json.MarshalIndent(ardiConfig, "", "\t")
-	if err := ioutil.WriteFile(ardiConf, byteData, 0644); err != nil {
+	writeConfig := func(disk *types.ArdiConfig) (*types.ArdiConfig, error) {
+		if disk != nil {
+			return disk, nil
+		}
+		return ardiConfig, nil
+	}
+	if _, err := UpdateArdiConfig(ardiConf, writeConfig); err != nil {
 		return err
 	}
 
-	byteData, _ = yaml.Marshal(arduinoSettings)
-	if err := ioutil.WriteFile(cliConf, byteData, 0644); err != nil {
+	byteData, _ := yaml.Marshal(arduinoSettings)
+	if err := writeSettingsFile(cliConf, byteData); err != nil {
 		return err
 	}
 
@@ -184,14 +404,17 @@ func WriteAllSettings(ardiConfig *types.ArdiConfig, arduinoSettings *types.Ardui
 }
 
 // InitProjectDirectory initializes a directory as an ardi project
-func InitProjectDirectory() error {
-	ardiConfig, cliSettings := GetAllSettings()
-	return WriteAllSettings(ardiConfig, cliSettings)
+func InitProjectDirectory(projectPaths paths.ProjectPaths) error {
+	ardiConfig, cliSettings, err := GetAllSettings(projectPaths)
+	if err != nil {
+		return err
+	}
+	return WriteAllSettings(projectPaths, ardiConfig, cliSettings)
 }
 
-// IsProjectDirectory returns whether or not currect directory has been initialized as an ardi project
-func IsProjectDirectory() bool {
-	_, buildErr := os.Stat(paths.ArdiProjectConfig)
+// IsProjectDirectory returns whether or not the project directory has been initialized as an ardi project
+func IsProjectDirectory(projectPaths paths.ProjectPaths) bool {
+	_, buildErr := os.Stat(projectPaths.ArdiConfig)
 	return !os.IsNotExist(buildErr)
 }
 
@@ -200,6 +423,63 @@ func CleanDataDirectory(dir string) error {
 	return os.RemoveAll(dir)
 }
 
+// SplitFQBN returns the base vendor:arch:board identifier of a fully
+// qualified board name along with the names and values of the board options
+// it specifies. Option names are returned in the order they appear.
+func SplitFQBN(fqbn string) (string, []string, map[string]string) {
+	names := []string{}
+	values := make(map[string]string)
+
+	parts := strings.SplitN(fqbn, ":", 4)
+	if len(parts) < 4 {
+		return fqbn, names, values
+	}
+
+	for _, option := range strings.Split(parts[3], ",") {
+		if option == "" {
+			continue
+		}
+		kv := strings.SplitN(option, "=", 2)
+		if _, ok := values[kv[0]]; !ok {
+			names = append(names, kv[0])
+		}
+		values[kv[0]] = ""
+		if len(kv) == 2 {
+			values[kv[0]] = kv[1]
+		}
+	}
+
+	return strings.Join(parts[:3], ":"), names, values
+}
+
+// FQBNWithOptions returns fqbn with the given board options set. Options
+// already specified in fqbn are replaced in place and new options are
+// appended in sorted order.
+func FQBNWithOptions(fqbn string, options map[string]string) string {
+	base, names, values := SplitFQBN(fqbn)
+
+	added := []string{}
+	for name, value := range options {
+		if _, ok := values[name]; !ok {
+			added = append(added, name)
+		}
+		values[name] = value
+	}
+	sort.Strings(added)
+	names = append(names, added...)
+
+	if len(names) == 0 {
+		return base
+	}
+
+	pairs := []string{}
+	for _, name := range names {
+		pairs = append(pairs, name+"="+values[name])
+	}
+
+	return base + ":" + strings.Join(pairs, ",")
+}
+
 // GeneratePropsMap returns map of build props from string array
 func GeneratePropsMap(buildProps []string) map[string]string {
 	props := make(map[string]string)
@@ -223,6 +503,38 @@ func GeneratePropsArray(props map[string]string) []string {
 	return buildProps
 }
 
+// AppendBuildProp returns a copy of props with values appended to the
+// specified property, preserving any existing value
+func AppendBuildProp(props map[string]string, prop string, values ...string) map[string]string {
+	merged := make(map[string]string)
+	for k, v := range props {
+		merged[k] = v
+	}
+
+	parts := []string{}
+	if existing := strings.TrimSpace(merged[prop]); existing != "" {
+		parts = append(parts, existing)
+	}
+	parts = append(parts, values...)
+	merged[prop] = strings.Join(parts, " ")
+
+	return merged
+}
+
+// BuildTime returns the time to record for a build, honoring
+// SOURCE_DATE_EPOCH for reproducible builds
+func BuildTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now().UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH: %s", epoch)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
 // ProcessSketch looks for .ino file in specified directory and parses
 func ProcessSketch(filePath string) (*types.Project, error) {
 	if filePath == "" {
@@ -258,6 +570,117 @@ func ProcessSketch(filePath string) (*types.Project, error) {
 	}, nil
 }
 
+// FindSketches returns the paths of all .ino files under root sorted by path.
+// Hidden directories are skipped.
+func FindSketches(root string) ([]string, error) {
+	sketches := []string{}
+
+	err := filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(p) == ".ino" {
+			sketches = append(sketches, p)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(sketches)
+	return sketches, nil
+}
+
+// ScanIncludes returns the headers included with angle brackets by the .ino,
+// .cpp, and .h files under dir sorted by name. Headers of files found under
+// dir are excluded. Hidden directories are skipped.
+func ScanIncludes(dir string) ([]string, error) {
+	rgx := regexp.MustCompile(`^\s*#\s*include\s*<([^>]+)>`)
+	local := make(map[string]bool)
+	found := make(map[string]bool)
+
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		local[d.Name()] = true
+
+		switch filepath.Ext(p) {
+		case ".ino", ".cpp", ".h":
+		default:
+			return nil
+		}
+
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if match := rgx.FindStringSubmatch(scanner.Text()); match != nil {
+				found[strings.TrimSpace(match[1])] = true
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []string{}
+	for header := range found {
+		if !local[filepath.Base(header)] {
+			headers = append(headers, header)
+		}
+	}
+	sort.Strings(headers)
+
+	return headers, nil
+}
+
+// FuzzyMatch returns whether the characters of query appear in order in
+// target ignoring case, along with a score where lower is a closer match.
+// Targets containing query as a substring always score lower than targets
+// that only match as a subsequence.
+func FuzzyMatch(query, target string) (int, bool) {
+	query = strings.ToLower(query)
+	target = strings.ToLower(target)
+
+	if idx := strings.Index(target, query); idx >= 0 {
+		return idx, true
+	}
+
+	score := len(target)
+	pos := 0
+	for _, r := range query {
+		idx := strings.IndexRune(target[pos:], r)
+		if idx < 0 {
+			return 0, false
+		}
+		score += idx
+		pos += idx + utf8.RuneLen(r)
+	}
+
+	return score, true
+}
+
 // ParseSketchBaud reads a sketch file and tries to parse baud rate
 func ParseSketchBaud(sketch string) int {
 	var baud = 9600
@@ -322,3 +745,21 @@ func findSketch(directory string) (string, error) {
 
 	return sketchFile, nil
 }
+
+// writeSettingsFile atomically writes a settings file while holding its lock
+// unless the file already exists.
+func writeSettingsFile(p string, data []byte) error {
+	unlock, err := LockFile(p)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(p); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return WriteFileAtomic(p, data, 0644)
+}
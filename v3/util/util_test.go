@@ -7,8 +7,10 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/robgonnella/ardi/v3/paths"
+	"github.com/robgonnella/ardi/v3/schema"
 	"github.com/robgonnella/ardi/v3/testutil"
 	"github.com/robgonnella/ardi/v3/types"
 	"github.com/robgonnella/ardi/v3/util"
@@ -16,6 +18,8 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+var projectPaths = paths.NewProjectPaths(".")
+
 func writeSettings(conf string, data []byte) error {
 	os.RemoveAll(conf)
 	return ioutil.WriteFile(conf, data, 0644)
@@ -111,11 +115,6 @@ func TestUtilArduinoCliSettings(t *testing.T) {
 		assert.Equal(st, expected, data)
 		os.RemoveAll(conf)
 	})
-
-	t.Run("returns project path", func(st *testing.T) {
-		settingsPath := util.GetCliSettingsPath()
-		assert.Equal(st, paths.ArduinoCliProjectConfig, settingsPath)
-	})
 }
 
 func TestUtilArdiConfig(t *testing.T) {
@@ -137,6 +136,18 @@ func TestUtilArdiConfig(t *testing.T) {
 		os.RemoveAll(conf)
 	})
 
+	t.Run("errors with location of unknown fields", func(st *testing.T) {
+		conf := "testconf"
+		data := []byte("{\n  \"builds\": {\n    \"uno\": {\"fqbm\": \"arduino:avr:uno\"}\n  }\n}\n")
+		err := writeSettings(conf, data)
+		assert.NoError(st, err)
+
+		settings, err := util.ReadArdiConfig(conf)
+		assert.EqualError(st, err, "invalid testconf\n  testconf:3:13: builds.uno: unknown field \"fqbm\", did you mean \"fqbn\"?")
+		assert.Nil(st, settings)
+		os.RemoveAll(conf)
+	})
+
 	t.Run("returns settings from file", func(st *testing.T) {
 		conf := "ardi-success-conf"
 		expected := util.GenArdiConfig()
@@ -158,70 +169,310 @@ func TestUtilArdiConfig(t *testing.T) {
 	})
 }
 
+func TestUtilWorkspaceConfig(t *testing.T) {
+	t.Run("errors if file does not exist", func(st *testing.T) {
+		data, err := util.ReadWorkspaceConfig("./noop")
+		assert.Error(st, err)
+		assert.Nil(st, data)
+	})
+
+	t.Run("returns members from file", func(st *testing.T) {
+		conf := "ardi-workspace-conf"
+		err := writeSettings(conf, []byte(`{"members": ["firmware/sensor", "firmware/gateway"]}`))
+		assert.NoError(st, err)
+
+		data, err := util.ReadWorkspaceConfig(conf)
+		assert.NoError(st, err)
+		assert.Equal(st, []string{"firmware/sensor", "firmware/gateway"}, data.Members)
+		os.RemoveAll(conf)
+	})
+
+	t.Run("accepts comments and trailing commas", func(st *testing.T) {
+		conf := path.Join(st.TempDir(), "ardi-workspace.json")
+		content := "{\n  // boards shipped together\n  \"members\": [\"sensor\", \"gateway\",],\n}\n"
+		err := writeSettings(conf, []byte(content))
+		assert.NoError(st, err)
+
+		data, err := util.ReadWorkspaceConfig(conf)
+		assert.NoError(st, err)
+		assert.Equal(st, []string{"sensor", "gateway"}, data.Members)
+	})
+
+	t.Run("errors with position of schema violations", func(st *testing.T) {
+		conf := path.Join(st.TempDir(), "ardi-workspace.json")
+		err := writeSettings(conf, []byte("{\n  \"members\": [\"sensor\", 1],\n  \"member\": []\n}"))
+		assert.NoError(st, err)
+
+		_, err = util.ReadWorkspaceConfig(conf)
+		var configErr *schema.ConfigError
+		assert.ErrorAs(st, err, &configErr)
+		assert.Len(st, configErr.Errors, 2)
+		assert.Equal(st, 2, configErr.Errors[0].Line)
+		assert.Equal(st, 3, configErr.Errors[1].Line)
+	})
+}
+
+func TestUtilSecrets(t *testing.T) {
+	t.Run("reads secrets file", func(st *testing.T) {
+		conf := path.Join(st.TempDir(), "ardi.secrets.json")
+		err := writeSettings(conf, []byte(`{"WIFI_PASS": "hunter22"}`))
+		assert.NoError(st, err)
+
+		secrets, err := util.ReadSecrets(conf)
+		assert.NoError(st, err)
+		assert.Equal(st, map[string]string{"WIFI_PASS": "hunter22"}, secrets)
+	})
+
+	t.Run("reads dotenv file", func(st *testing.T) {
+		envFile := path.Join(st.TempDir(), ".env")
+		data := "# comment\n\nWIFI_SSID=home\nexport API_KEY = \"abc 123\"\nTOKEN='t0k3n'\n"
+		err := writeSettings(envFile, []byte(data))
+		assert.NoError(st, err)
+
+		values, err := util.ReadDotEnv(envFile)
+		assert.NoError(st, err)
+		assert.Equal(st, map[string]string{
+			"WIFI_SSID": "home",
+			"API_KEY":   "abc 123",
+			"TOKEN":     "t0k3n",
+		}, values)
+	})
+
+	t.Run("errors on invalid dotenv line", func(st *testing.T) {
+		envFile := path.Join(st.TempDir(), ".env")
+		err := writeSettings(envFile, []byte("VALID=1\ninvalid\n"))
+		assert.NoError(st, err)
+
+		_, err = util.ReadDotEnv(envFile)
+		assert.EqualError(st, err, fmt.Sprintf("invalid line 2 in %s", envFile))
+	})
+
+	t.Run("adds missing entries to gitignore", func(st *testing.T) {
+		dir := st.TempDir()
+		ignoreFile := path.Join(dir, ".gitignore")
+		err := writeSettings(ignoreFile, []byte("build\n/.env"))
+		assert.NoError(st, err)
+
+		err = util.EnsureGitIgnored(dir, "ardi.secrets.json", ".env")
+		assert.NoError(st, err)
+		err = util.EnsureGitIgnored(dir, "ardi.secrets.json", ".env")
+		assert.NoError(st, err)
+
+		data, err := os.ReadFile(ignoreFile)
+		assert.NoError(st, err)
+		assert.Equal(st, "build\n/.env\nardi.secrets.json\n", string(data))
+	})
+}
+
+func TestUtilFQBNOptions(t *testing.T) {
+	t.Run("splits fqbn into base and options", func(st *testing.T) {
+		base, names, values := util.SplitFQBN("esp32:esp32:esp32:PartitionScheme=min_spiffs,FlashFreq=80")
+		assert.Equal(st, "esp32:esp32:esp32", base)
+		assert.Equal(st, []string{"PartitionScheme", "FlashFreq"}, names)
+		assert.Equal(st, map[string]string{"PartitionScheme": "min_spiffs", "FlashFreq": "80"}, values)
+
+		base, names, values = util.SplitFQBN("arduino:avr:mega")
+		assert.Equal(st, "arduino:avr:mega", base)
+		assert.Empty(st, names)
+		assert.Empty(st, values)
+	})
+
+	t.Run("assembles fqbn with options", func(st *testing.T) {
+		fqbn := util.FQBNWithOptions("esp32:esp32:esp32:PartitionScheme=default", map[string]string{
+			"PartitionScheme": "min_spiffs",
+			"UploadSpeed":     "921600",
+			"FlashFreq":       "80",
+		})
+		assert.Equal(st, "esp32:esp32:esp32:PartitionScheme=min_spiffs,FlashFreq=80,UploadSpeed=921600", fqbn)
+		assert.Equal(st, "arduino:avr:mega", util.FQBNWithOptions("arduino:avr:mega", nil))
+	})
+}
+
+func TestUtilFindSketches(t *testing.T) {
+	t.Run("finds sketches skipping hidden directories", func(st *testing.T) {
+		root := st.TempDir()
+		for _, p := range []string{"blink/blink.ino", "sub/pixie/pixie.ino", ".ardi/lib/example.ino", "notes.txt"} {
+			file := path.Join(root, p)
+			assert.NoError(st, os.MkdirAll(path.Dir(file), 0755))
+			assert.NoError(st, os.WriteFile(file, []byte{}, 0644))
+		}
+
+		sketches, err := util.FindSketches(root)
+		assert.NoError(st, err)
+		assert.Equal(st, []string{
+			path.Join(root, "blink/blink.ino"),
+			path.Join(root, "sub/pixie/pixie.ino"),
+		}, sketches)
+	})
+}
+
+func TestUtilScanIncludes(t *testing.T) {
+	t.Run("returns angle bracket includes not found in directory", func(st *testing.T) {
+		root := st.TempDir()
+		files := map[string]string{
+			"weather/weather.ino":   "#include <Adafruit_BME280.h>\n#include \"src/display.h\"\n#include <Wire.h>\n",
+			"weather/src/display.h": "#pragma once\n#  include <U8g2lib.h>\n#include <display.h>\n",
+			"weather/src/display.c": "#include <Ignored.h>\n",
+			".ardi/lib/lib.h":       "#include <Hidden.h>\n",
+		}
+		for p, content := range files {
+			file := path.Join(root, p)
+			assert.NoError(st, os.MkdirAll(path.Dir(file), 0755))
+			assert.NoError(st, os.WriteFile(file, []byte(content), 0644))
+		}
+
+		headers, err := util.ScanIncludes(root)
+		assert.NoError(st, err)
+		assert.Equal(st, []string{"Adafruit_BME280.h", "U8g2lib.h", "Wire.h"}, headers)
+	})
+}
+
+func TestUtilFuzzyMatch(t *testing.T) {
+	t.Run("matches substrings before subsequences", func(st *testing.T) {
+		substring, ok := util.FuzzyMatch("mega", "Arduino Mega (arduino:avr:mega)")
+		assert.True(st, ok)
+
+		subsequence, ok := util.FuzzyMatch("mga", "Arduino Mega (arduino:avr:mega)")
+		assert.True(st, ok)
+		assert.Less(st, substring, subsequence)
+
+		_, ok = util.FuzzyMatch("mega", "Arduino Uno (arduino:avr:uno)")
+		assert.False(st, ok)
+	})
+}
+
 func TestUtilGetAllSettings(t *testing.T) {
 	t.Run("returns default settings if project files not found", func(st *testing.T) {
-		dataDir := paths.ArduinoCliProjectDataDir
+		dataDir := projectPaths.ArduinoCliDataDir
 		os.RemoveAll(dataDir)
 
 		expectedConfig := util.GenArdiConfig()
 		expectedSettings := util.GenArduinoCliSettings(dataDir)
-		config, settings := util.GetAllSettings()
+		config, settings, err := util.GetAllSettings(projectPaths)
 
+		assert.NoError(st, err)
 		assert.Equal(st, expectedConfig, config)
 		assert.Equal(st, expectedSettings, settings)
 	})
 
 	t.Run("returns settings from project files", func(st *testing.T) {
-		dataDir := paths.ArduinoCliProjectDataDir
+		dataDir := projectPaths.ArduinoCliDataDir
 		expectedConfig := util.GenArdiConfig()
 		expectedSettings := util.GenArduinoCliSettings(dataDir)
 
 		os.RemoveAll(dataDir)
 
-		util.WriteAllSettings(expectedConfig, expectedSettings)
+		util.WriteAllSettings(projectPaths, expectedConfig, expectedSettings)
 
 		assert.DirExists(st, dataDir)
-		assert.FileExists(st, paths.ArdiProjectConfig)
-		assert.FileExists(st, paths.ArduinoCliProjectConfig)
+		assert.FileExists(st, projectPaths.ArdiConfig)
+		assert.FileExists(st, projectPaths.ArduinoCliConfig)
 
-		config, settings := util.GetAllSettings()
+		config, settings, err := util.GetAllSettings(projectPaths)
+		assert.NoError(st, err)
 		assert.Equal(st, expectedConfig, config)
 		assert.Equal(st, expectedSettings, settings)
 
 		os.RemoveAll(dataDir)
-		os.RemoveAll(paths.ArdiProjectConfig)
+		os.RemoveAll(projectPaths.ArdiConfig)
+		os.RemoveAll(util.LockPath(projectPaths.ArdiConfig))
+	})
+
+	t.Run("keeps settings files updated since they were read", func(st *testing.T) {
+		dataDir := projectPaths.ArduinoCliDataDir
+		os.RemoveAll(dataDir)
+		os.RemoveAll(projectPaths.ArdiConfig)
+
+		config, settings, err := util.GetAllSettings(projectPaths)
+		assert.NoError(st, err)
+		assert.NoError(st, util.WriteAllSettings(projectPaths, config, settings))
+
+		// another process updates both files after they were read
+		updatedConfig := util.GenArdiConfig()
+		updatedConfig.Platforms["arduino:avr"] = "1.8.6"
+		_, err = util.UpdateArdiConfig(projectPaths.ArdiConfig, func(*types.ArdiConfig) (*types.ArdiConfig, error) {
+			return updatedConfig, nil
+		})
+		assert.NoError(st, err)
+		updatedSettings := util.GenArduinoCliSettings(dataDir)
+		updatedSettings.BoardManager.AdditionalUrls = []string{"https://example.com/package_index.json"}
+		data, err := yaml.Marshal(updatedSettings)
+		assert.NoError(st, err)
+		assert.NoError(st, writeSettings(projectPaths.ArduinoCliConfig, data))
+
+		assert.NoError(st, util.WriteAllSettings(projectPaths, config, settings))
+
+		writtenConfig, writtenSettings, err := util.GetAllSettings(projectPaths)
+		assert.NoError(st, err)
+		assert.Equal(st, updatedConfig, writtenConfig)
+		assert.Equal(st, updatedSettings, writtenSettings)
+
+		os.RemoveAll(dataDir)
+		os.RemoveAll(projectPaths.ArdiConfig)
+	})
+
+	t.Run("errors rather than replacing an invalid arduino-cli.yaml", func(st *testing.T) {
+		assert.NoError(st, os.MkdirAll(projectPaths.ArduinoCliDataDir, 0755))
+		content := []byte("directories: [\n")
+		err := writeSettings(projectPaths.ArduinoCliConfig, content)
+		assert.NoError(st, err)
+
+		_, _, err = util.GetAllSettings(projectPaths)
+		assert.ErrorContains(st, err, "invalid "+projectPaths.ArduinoCliConfig)
+
+		data, err := ioutil.ReadFile(projectPaths.ArduinoCliConfig)
+		assert.NoError(st, err)
+		assert.Equal(st, content, data)
+
+		os.RemoveAll(projectPaths.ArduinoCliDataDir)
+	})
+
+	t.Run("errors rather than replacing an invalid ardi.json", func(st *testing.T) {
+		content := []byte("{\n\t\"platforms\": {\n}")
+		err := writeSettings(projectPaths.ArdiConfig, content)
+		assert.NoError(st, err)
+
+		_, _, err = util.GetAllSettings(projectPaths)
+		assert.ErrorContains(st, err, "ardi.json:3:1: unexpected end of JSON input")
+
+		data, err := ioutil.ReadFile(projectPaths.ArdiConfig)
+		assert.NoError(st, err)
+		assert.Equal(st, content, data)
+
+		os.RemoveAll(projectPaths.ArdiConfig)
 	})
 }
 
 func TestUtilInitProjectDirectory(t *testing.T) {
 	t.Run("initialized directory with project config files", func(st *testing.T) {
-		os.RemoveAll(paths.ArduinoCliProjectDataDir)
-		os.RemoveAll(paths.ArdiProjectConfig)
+		os.RemoveAll(projectPaths.ArduinoCliDataDir)
+		os.RemoveAll(projectPaths.ArdiConfig)
 
-		err := util.InitProjectDirectory()
+		err := util.InitProjectDirectory(projectPaths)
 		assert.NoError(st, err)
-		assert.DirExists(st, paths.ArduinoCliProjectDataDir)
-		assert.FileExists(st, paths.ArdiProjectConfig)
+		assert.DirExists(st, projectPaths.ArduinoCliDataDir)
+		assert.FileExists(st, projectPaths.ArdiConfig)
 
-		os.RemoveAll(paths.ArduinoCliProjectDataDir)
-		os.RemoveAll(paths.ArdiProjectConfig)
+		os.RemoveAll(projectPaths.ArduinoCliDataDir)
+		os.RemoveAll(projectPaths.ArdiConfig)
+		os.RemoveAll(util.LockPath(projectPaths.ArdiConfig))
 	})
 }
 
 func TestUtilIsProjectDirectory(t *testing.T) {
 	t.Run("returns false if project ardi.json not found", func(st *testing.T) {
-		os.RemoveAll(paths.ArdiProjectConfig)
-		assert.False(st, util.IsProjectDirectory())
+		os.RemoveAll(projectPaths.ArdiConfig)
+		assert.False(st, util.IsProjectDirectory(projectPaths))
 	})
 
 	t.Run("returns true if project ardi.json found", func(st *testing.T) {
-		os.RemoveAll(paths.ArdiProjectConfig)
-		file, _ := os.Create(paths.ArdiProjectConfig)
+		os.RemoveAll(projectPaths.ArdiConfig)
+		file, _ := os.Create(projectPaths.ArdiConfig)
 		defer func() {
 			file.Close()
-			os.RemoveAll(paths.ArdiProjectConfig)
+			os.RemoveAll(projectPaths.ArdiConfig)
 		}()
-		assert.True(st, util.IsProjectDirectory())
+		assert.True(st, util.IsProjectDirectory(projectPaths))
 	})
 }
 
@@ -247,6 +498,36 @@ func TestUtilGeneratePropsMap(t *testing.T) {
 	})
 }
 
+func TestUtilAppendBuildProp(t *testing.T) {
+	t.Run("appends to existing property without modifying original", func(st *testing.T) {
+		props := map[string]string{"build.extra_flags": "-DUSER_FLAG", "other": "value"}
+		merged := util.AppendBuildProp(props, "build.extra_flags", "-DA", "-DB")
+		assert.Equal(st, "-DUSER_FLAG -DA -DB", merged["build.extra_flags"])
+		assert.Equal(st, "value", merged["other"])
+		assert.Equal(st, "-DUSER_FLAG", props["build.extra_flags"])
+	})
+
+	t.Run("sets missing property", func(st *testing.T) {
+		merged := util.AppendBuildProp(nil, "build.extra_flags", "-DA")
+		assert.Equal(st, map[string]string{"build.extra_flags": "-DA"}, merged)
+	})
+}
+
+func TestUtilBuildTime(t *testing.T) {
+	t.Run("honors SOURCE_DATE_EPOCH", func(st *testing.T) {
+		st.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+		buildTime, err := util.BuildTime()
+		assert.NoError(st, err)
+		assert.Equal(st, "2023-11-14T22:13:20Z", buildTime.Format(time.RFC3339))
+	})
+
+	t.Run("errors on invalid SOURCE_DATE_EPOCH", func(st *testing.T) {
+		st.Setenv("SOURCE_DATE_EPOCH", "noop")
+		_, err := util.BuildTime()
+		assert.Error(st, err)
+	})
+}
+
 func TestUtilProcessSketch(t *testing.T) {
 	t.Run("errors if sketch param empty", func(st *testing.T) {
 		project, err := util.ProcessSketch("")